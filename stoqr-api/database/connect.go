@@ -1,7 +1,8 @@
 package database
 
 import (
-	"log"
+	"log/slog"
+	"os"
 
 	"gorm.io/gorm"
 )
@@ -10,15 +11,17 @@ import (
 func Connect() *gorm.DB {
 	db := &gorm.DB{}
 	if err := checkPostgresConfig(); err != nil {
-		log.Printf("Falling back to SQLite: %s", err)
+		slog.Warn("Falling back to SQLite", "error", err)
 		db, err = openSQLite()
 		if err != nil {
-			log.Fatal(err)
+			slog.Error("Connecting to the database", "error", err)
+			os.Exit(1)
 		}
 	} else {
 		db, err = openPostgres()
 		if err != nil {
-			log.Fatal(err)
+			slog.Error("Connecting to the database", "error", err)
+			os.Exit(1)
 		}
 	}
 	return db
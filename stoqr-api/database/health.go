@@ -0,0 +1,44 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrPendingMigrations is returned when data migrations were not applied to the database
+var ErrPendingMigrations = errors.New("pending migrations")
+
+// Ping checks that the database is reachable
+func Ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// CheckMigrations checks that every data migration was applied to the database
+func CheckMigrations(ctx context.Context, db *gorm.DB) error {
+	var applied []string
+	if err := db.WithContext(ctx).Model(&schemaMigration{}).Pluck("id", &applied).Error; err != nil {
+		return err
+	}
+	done := map[string]bool{}
+	for _, id := range applied {
+		done[id] = true
+	}
+	pending := []string{}
+	for _, m := range migrations {
+		if !done[m.id] {
+			pending = append(pending, m.id)
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %s", ErrPendingMigrations, strings.Join(pending, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPing(t *testing.T) {
+	db := openMemory(t)
+
+	if err := Ping(context.Background(), db); err != nil {
+		t.Errorf("wrong error: want %v, got %v", nil, err)
+	}
+}
+
+func TestCheckMigrations(t *testing.T) {
+	db := openMemory(t)
+	db.AutoMigrate(&schemaMigration{})
+
+	if err := CheckMigrations(context.Background(), db); !errors.Is(err, ErrPendingMigrations) {
+		t.Errorf("wrong error: want %v, got %v", ErrPendingMigrations, err)
+	}
+	if err := Migrate(db, &migratedItem{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckMigrations(context.Background(), db); err != nil {
+		t.Errorf("wrong error: want %v, got %v", nil, err)
+	}
+}
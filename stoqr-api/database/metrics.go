@@ -0,0 +1,53 @@
+package database
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+)
+
+// startKey is the key of the start time of a statement in the instance of a gorm session
+const startKey = "metrics:start"
+
+// RegisterMetrics measures the duration of the statements run by gorm, by operation and table
+func RegisterMetrics(db *gorm.DB, registerer prometheus.Registerer) error {
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "stoqr_db_query_duration_seconds",
+		Help:    "Duration of the database statements by operation and table.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation", "table"})
+	if err := registerer.Register(duration); err != nil {
+		return err
+	}
+	before := func(tx *gorm.DB) {
+		tx.InstanceSet(startKey, time.Now())
+	}
+	after := func(operation string) func(tx *gorm.DB) {
+		return func(tx *gorm.DB) {
+			if start, ok := tx.InstanceGet(startKey); ok {
+				duration.WithLabelValues(operation, tx.Statement.Table).Observe(time.Since(start.(time.Time)).Seconds())
+			}
+		}
+	}
+	callbacks := db.Callback()
+	for _, err := range []error{
+		callbacks.Create().Before("gorm:create").Register("metrics:before_create", before),
+		callbacks.Create().After("gorm:create").Register("metrics:after_create", after("create")),
+		callbacks.Query().Before("gorm:query").Register("metrics:before_query", before),
+		callbacks.Query().After("gorm:query").Register("metrics:after_query", after("query")),
+		callbacks.Update().Before("gorm:update").Register("metrics:before_update", before),
+		callbacks.Update().After("gorm:update").Register("metrics:after_update", after("update")),
+		callbacks.Delete().Before("gorm:delete").Register("metrics:before_delete", before),
+		callbacks.Delete().After("gorm:delete").Register("metrics:after_delete", after("delete")),
+		callbacks.Row().Before("gorm:row").Register("metrics:before_row", before),
+		callbacks.Row().After("gorm:row").Register("metrics:after_row", after("row")),
+		callbacks.Raw().Before("gorm:raw").Register("metrics:before_raw", before),
+		callbacks.Raw().After("gorm:raw").Register("metrics:after_raw", after("raw")),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
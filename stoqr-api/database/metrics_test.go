@@ -0,0 +1,35 @@
+package database
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func TestRegisterMetrics(t *testing.T) {
+	db := openMemory(t)
+	registry := prometheus.NewRegistry()
+	if err := RegisterMetrics(db, registry); err != nil {
+		t.Fatal(err)
+	}
+
+	db.AutoMigrate(&migratedItem{})
+	db.Create(&migratedItem{Name: "Test"})
+	var items []migratedItem
+	db.Find(&items)
+
+	rr := httptest.NewRecorder()
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`stoqr_db_query_duration_seconds_count{operation="create",table="items"} 1`,
+		`stoqr_db_query_duration_seconds_count{operation="query",table="items"} 1`,
+	} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("wrong metrics: want %v, got\n%s", want, rr.Body.String())
+		}
+	}
+}
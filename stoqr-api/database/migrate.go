@@ -0,0 +1,107 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// schemaMigration records a data migration applied to the database
+type schemaMigration struct {
+	ID        string `gorm:"primaryKey"`
+	AppliedAt time.Time
+}
+
+type migration struct {
+	id    string
+	apply func(tx *gorm.DB) error
+}
+
+// migrations are the data migrations applied in order after the schema is updated
+var migrations = []migration{
+	{id: "0001-scale-quantities", apply: scaleQuantities},
+	{id: "0002-movement-costs", apply: totalMovementCosts},
+	{id: "0003-stocktake-expected", apply: expectOpenCounts},
+}
+
+// Migrate updates the schema of the given models and applies the pending data
+// migrations. A new database is considered up to date.
+func Migrate(db *gorm.DB, models ...interface{}) error {
+	fresh := !db.Migrator().HasTable(&schemaMigration{}) && !db.Migrator().HasTable("items")
+	if err := db.AutoMigrate(append([]interface{}{&schemaMigration{}}, models...)...); err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var count int64
+			if err := tx.Model(&schemaMigration{}).Where("id = ?", m.id).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return nil
+			}
+			if !fresh {
+				if err := m.apply(tx); err != nil {
+					return err
+				}
+			}
+			return tx.Create(&schemaMigration{ID: m.id, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", m.id, err)
+		}
+	}
+	return nil
+}
+
+// scaleQuantities converts quantities stored as whole units into thousandths
+func scaleQuantities(tx *gorm.DB) error {
+	columns := map[string][]string{
+		"items":      {"desired", "actual"},
+		"components": {"quantity"},
+		"movements":  {"quantity"},
+	}
+	for table, names := range columns {
+		if !tx.Migrator().HasTable(table) {
+			continue
+		}
+		for _, name := range names {
+			statement := fmt.Sprintf("UPDATE %s SET %s = %s * 1000", table, name, name)
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// movementCosts are the columns of the movements holding their costs
+type movementCosts struct {
+	UnitCost int
+	Cost     int
+}
+
+func (movementCosts) TableName() string {
+	return "movements"
+}
+
+// totalMovementCosts converts the unit costs of the receipts into the total cost of the
+// quantity received
+func totalMovementCosts(tx *gorm.DB) error {
+	migrator := tx.Migrator()
+	if !migrator.HasTable(&movementCosts{}) || !migrator.HasColumn(&movementCosts{}, "unit_cost") || !migrator.HasColumn(&movementCosts{}, "cost") {
+		return nil
+	}
+	return tx.Exec("UPDATE movements SET cost = (unit_cost * quantity + 500) / 1000 WHERE unit_cost <> 0").Error
+}
+
+// expectOpenCounts sets the expected quantity of the counts of the open stocktakes, which
+// was only recorded on commit, to the current stock of their items
+func expectOpenCounts(tx *gorm.DB) error {
+	if !tx.Migrator().HasTable("stocktake_counts") || !tx.Migrator().HasTable("stocktakes") || !tx.Migrator().HasTable("items") {
+		return nil
+	}
+	return tx.Exec(`UPDATE stocktake_counts SET expected = (SELECT actual FROM items WHERE items.id = stocktake_counts.item_id)
+		WHERE stocktake_id IN (SELECT id FROM stocktakes WHERE status = 'open')`).Error
+}
@@ -0,0 +1,130 @@
+package database
+
+import (
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type migratedItem struct {
+	ID      int
+	Name    string
+	Desired int64
+	Actual  int64
+}
+
+func (migratedItem) TableName() string {
+	return "items"
+}
+
+func openMemory(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	return db
+}
+
+func TestMigrateFresh(t *testing.T) {
+	db := openMemory(t)
+	if err := Migrate(db, &migratedItem{}); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&migratedItem{Name: "Test", Desired: 1000, Actual: 1000})
+	if err := Migrate(db, &migratedItem{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var item migratedItem
+	db.First(&item)
+	if item.Actual != 1000 {
+		t.Errorf("wrong actual: want %v, got %v", 1000, item.Actual)
+	}
+}
+
+func TestMigrateScaleQuantities(t *testing.T) {
+	db := openMemory(t)
+	db.AutoMigrate(&migratedItem{})
+	db.Create(&migratedItem{Name: "Test", Desired: 3, Actual: 2})
+
+	if err := Migrate(db, &migratedItem{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var item migratedItem
+	db.First(&item)
+	if item.Desired != 3000 || item.Actual != 2000 {
+		t.Errorf("wrong quantities: want %v/%v, got %v/%v", 3000, 2000, item.Desired, item.Actual)
+	}
+}
+
+type migratedMovement struct {
+	ID       int
+	Quantity int64
+	UnitCost int
+	Cost     int
+}
+
+func (migratedMovement) TableName() string {
+	return "movements"
+}
+
+func TestMigrateMovementCosts(t *testing.T) {
+	db := openMemory(t)
+	db.AutoMigrate(&migratedItem{}, &migratedMovement{})
+	db.Create(&migratedMovement{Quantity: 3, UnitCost: 33})
+
+	if err := Migrate(db, &migratedItem{}, &migratedMovement{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var movement migratedMovement
+	db.First(&movement)
+	if movement.Quantity != 3000 || movement.Cost != 99 {
+		t.Errorf("wrong movement: want %v/%v, got %v/%v", 3000, 99, movement.Quantity, movement.Cost)
+	}
+}
+
+type migratedStocktake struct {
+	ID     int
+	Status string
+}
+
+func (migratedStocktake) TableName() string {
+	return "stocktakes"
+}
+
+type migratedCount struct {
+	ID          int
+	StocktakeID int
+	ItemID      int
+	Expected    int64
+}
+
+func (migratedCount) TableName() string {
+	return "stocktake_counts"
+}
+
+func TestMigrateStocktakeExpected(t *testing.T) {
+	db := openMemory(t)
+	db.AutoMigrate(&schemaMigration{}, &migratedItem{}, &migratedStocktake{}, &migratedCount{})
+	db.Create(&schemaMigration{ID: "0001-scale-quantities"})
+	db.Create(&migratedItem{Name: "Test", Actual: 2000})
+	db.Create(&migratedStocktake{Status: "open"})
+	db.Create(&migratedStocktake{Status: "committed"})
+	db.Create(&migratedCount{StocktakeID: 1, ItemID: 1})
+	db.Create(&migratedCount{StocktakeID: 2, ItemID: 1, Expected: 1000})
+
+	if err := Migrate(db, &migratedItem{}, &migratedStocktake{}, &migratedCount{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var counts []migratedCount
+	db.Order("id").Find(&counts)
+	if len(counts) != 2 || counts[0].Expected != 2000 || counts[1].Expected != 1000 {
+		t.Errorf("wrong counts: got %+v", counts)
+	}
+}
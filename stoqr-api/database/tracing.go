@@ -0,0 +1,71 @@
+package database
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// spanKey is the key of the span of a statement in the instance of a gorm session
+const spanKey = "tracing:span"
+
+// instrumentationName is the name of the tracer of the statements
+const instrumentationName = "github.com/leandroberetta/stoqr/stoqr-api/database"
+
+// RegisterTracing records a client span for each statement run by gorm as a child of the span
+// in the context of the session (see gorm.DB.WithContext), statements outside of a trace like
+// the migrations and the jobs aren't recorded. The gorm plugin of OpenTelemetry needs a newer
+// gorm, so the callbacks are registered here on the OpenTelemetry API.
+func RegisterTracing(db *gorm.DB, provider trace.TracerProvider) error {
+	tracer := provider.Tracer(instrumentationName)
+	system := db.Dialector.Name()
+	before := func(operation string) func(tx *gorm.DB) {
+		return func(tx *gorm.DB) {
+			if !trace.SpanContextFromContext(tx.Statement.Context).IsValid() {
+				return
+			}
+			_, span := tracer.Start(tx.Statement.Context, "gorm."+operation, trace.WithSpanKind(trace.SpanKindClient))
+			tx.InstanceSet(spanKey, span)
+		}
+	}
+	after := func(tx *gorm.DB) {
+		value, ok := tx.InstanceGet(spanKey)
+		if !ok {
+			return
+		}
+		span := value.(trace.Span)
+		span.SetAttributes(
+			attribute.String("db.system", system),
+			attribute.String("db.sql.table", tx.Statement.Table),
+			attribute.String("db.statement", tx.Statement.SQL.String()),
+			attribute.Int64("db.rows_affected", tx.Statement.RowsAffected))
+		if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			span.RecordError(tx.Error)
+			span.SetStatus(codes.Error, tx.Error.Error())
+		}
+		span.End()
+	}
+	callbacks := db.Callback()
+	for _, err := range []error{
+		callbacks.Create().Before("gorm:create").Register("tracing:before_create", before("create")),
+		callbacks.Create().After("gorm:create").Register("tracing:after_create", after),
+		callbacks.Query().Before("gorm:query").Register("tracing:before_query", before("query")),
+		callbacks.Query().After("gorm:query").Register("tracing:after_query", after),
+		callbacks.Update().Before("gorm:update").Register("tracing:before_update", before("update")),
+		callbacks.Update().After("gorm:update").Register("tracing:after_update", after),
+		callbacks.Delete().Before("gorm:delete").Register("tracing:before_delete", before("delete")),
+		callbacks.Delete().After("gorm:delete").Register("tracing:after_delete", after),
+		callbacks.Row().Before("gorm:row").Register("tracing:before_row", before("row")),
+		callbacks.Row().After("gorm:row").Register("tracing:after_row", after),
+		callbacks.Raw().Before("gorm:raw").Register("tracing:before_raw", before("raw")),
+		callbacks.Raw().After("gorm:raw").Register("tracing:after_raw", after),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
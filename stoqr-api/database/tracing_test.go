@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRegisterTracing(t *testing.T) {
+	db := openMemory(t)
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	if err := RegisterTracing(db, provider); err != nil {
+		t.Fatal(err)
+	}
+
+	db.AutoMigrate(&migratedItem{})
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	db.WithContext(ctx).Create(&migratedItem{Name: "Test"})
+	var item migratedItem
+	db.WithContext(ctx).First(&item, 2)
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("wrong spans: want %v, got %v", 3, len(spans))
+	}
+	for i, name := range []string{"gorm.create", "gorm.query"} {
+		span := spans[i]
+		if span.Name() != name || span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("wrong span: want %v child of %v, got %v child of %v", name, parent.SpanContext().SpanID(), span.Name(), span.Parent().SpanID())
+		}
+		if span.Status().Code != codes.Unset {
+			t.Errorf("wrong status of %v: want %v, got %v", name, codes.Unset, span.Status().Code)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+// Package forecast predicts the daily demand of an item from its history and
+// suggests how much to order to cover the lead time at a given service level
+package forecast
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Forecasting methods
+const (
+	MethodMovingAverage = "moving-average"
+	MethodExponential   = "exponential-smoothing"
+	MethodHoltWinters   = "holt-winters"
+)
+
+// ErrInvalidParams is returned when the parameters of a forecast cannot be used
+var ErrInvalidParams = errors.New("invalid forecast parameters")
+
+// ErrNotEnoughData is returned when the series is too short for the method
+var ErrNotEnoughData = errors.New("not enough data to forecast")
+
+// Params are the parameters of a forecast, zero values take the defaults
+type Params struct {
+	Method string
+	// Window is the number of days averaged by the moving average
+	Window int
+	// Alpha, Beta and Gamma smooth the level, trend and season respectively
+	Alpha float64
+	Beta  float64
+	Gamma float64
+	// Season is the length of a season in days for Holt-Winters
+	Season int
+}
+
+// Forecast is the predicted demand of the following days and the standard deviation
+// of the one day ahead errors of the method on the history
+type Forecast struct {
+	Method string    `json:"method"`
+	Daily  []float64 `json:"daily"`
+	Sigma  float64   `json:"sigma"`
+}
+
+// Total returns the demand predicted for the first days of the forecast
+func (f Forecast) Total(days int) float64 {
+	total := 0.0
+	for i := 0; i < days && i < len(f.Daily); i++ {
+		total += f.Daily[i]
+	}
+	return total
+}
+
+// Defaults returns the parameters with the defaults of the zero values
+func (p Params) Defaults() Params {
+	if p.Method == "" {
+		p.Method = MethodMovingAverage
+	}
+	if p.Window == 0 {
+		p.Window = 28
+	}
+	if p.Alpha == 0 {
+		p.Alpha = 0.3
+	}
+	if p.Beta == 0 {
+		p.Beta = 0.1
+	}
+	if p.Gamma == 0 {
+		p.Gamma = 0.2
+	}
+	if p.Season == 0 {
+		p.Season = 7
+	}
+	return p
+}
+
+// Predict forecasts the demand of the following horizon days from a daily series,
+// oldest first
+func Predict(series []float64, horizon int, params Params) (Forecast, error) {
+	params = params.Defaults()
+	if horizon < 0 || params.Window < 1 || params.Season < 2 ||
+		!inUnit(params.Alpha) || !inUnit(params.Beta) || !inUnit(params.Gamma) {
+		return Forecast{}, ErrInvalidParams
+	}
+	var forecast Forecast
+	var err error
+	switch params.Method {
+	case MethodMovingAverage:
+		forecast, err = movingAverage(series, horizon, params.Window)
+	case MethodExponential:
+		forecast, err = exponentialSmoothing(series, horizon, params.Alpha)
+	case MethodHoltWinters:
+		forecast, err = holtWinters(series, horizon, params)
+	default:
+		return Forecast{}, fmt.Errorf("%w: unknown method %s", ErrInvalidParams, params.Method)
+	}
+	if err != nil {
+		return Forecast{}, err
+	}
+	forecast.Method = params.Method
+	for i, demand := range forecast.Daily {
+		forecast.Daily[i] = math.Max(demand, 0)
+	}
+	return forecast, nil
+}
+
+func movingAverage(series []float64, horizon int, window int) (Forecast, error) {
+	if len(series) < window {
+		return Forecast{}, fmt.Errorf("%w: %d days for a window of %d", ErrNotEnoughData, len(series), window)
+	}
+	errs := []float64{}
+	for t := window; t < len(series); t++ {
+		errs = append(errs, series[t]-mean(series[t-window:t]))
+	}
+	average := mean(series[len(series)-window:])
+	return Forecast{Daily: repeat(average, horizon), Sigma: deviation(errs)}, nil
+}
+
+func exponentialSmoothing(series []float64, horizon int, alpha float64) (Forecast, error) {
+	if len(series) < 2 {
+		return Forecast{}, fmt.Errorf("%w: %d days", ErrNotEnoughData, len(series))
+	}
+	level := series[0]
+	errs := []float64{}
+	for _, demand := range series[1:] {
+		errs = append(errs, demand-level)
+		level = alpha*demand + (1-alpha)*level
+	}
+	return Forecast{Daily: repeat(level, horizon), Sigma: deviation(errs)}, nil
+}
+
+// holtWinters is the additive Holt-Winters method, initialized from the first two seasons
+func holtWinters(series []float64, horizon int, params Params) (Forecast, error) {
+	m := params.Season
+	if len(series) < 2*m {
+		return Forecast{}, fmt.Errorf("%w: %d days for two seasons of %d", ErrNotEnoughData, len(series), m)
+	}
+	level := mean(series[:m])
+	trend := (mean(series[m:2*m]) - level) / float64(m)
+	seasonal := make([]float64, m)
+	for i := 0; i < m; i++ {
+		seasonal[i] = series[i] - level
+	}
+	errs := []float64{}
+	for t := m; t < len(series); t++ {
+		season := seasonal[t%m]
+		errs = append(errs, series[t]-(level+trend+season))
+		previous := level
+		level = params.Alpha*(series[t]-season) + (1-params.Alpha)*(level+trend)
+		trend = params.Beta*(level-previous) + (1-params.Beta)*trend
+		seasonal[t%m] = params.Gamma*(series[t]-level) + (1-params.Gamma)*season
+	}
+	daily := make([]float64, horizon)
+	for h := 1; h <= horizon; h++ {
+		daily[h-1] = level + float64(h)*trend + seasonal[(len(series)+h-1)%m]
+	}
+	return Forecast{Daily: daily, Sigma: deviation(errs)}, nil
+}
+
+func inUnit(value float64) bool {
+	return value > 0 && value <= 1
+}
+
+func repeat(value float64, n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = value
+	}
+	return values
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+// deviation returns the root mean square of the errors, zero without errors
+func deviation(errs []float64) float64 {
+	if len(errs) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, err := range errs {
+		sum += err * err
+	}
+	return math.Sqrt(sum / float64(len(errs)))
+}
@@ -0,0 +1,111 @@
+package forecast
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+const tolerance = 1e-9
+
+// weekly returns weeks of a synthetic series repeating a weekly pattern on top of a linear trend
+func weekly(weeks int, pattern []float64, trend float64) []float64 {
+	series := []float64{}
+	for t := 0; t < weeks*len(pattern); t++ {
+		series = append(series, pattern[t%len(pattern)]+trend*float64(t))
+	}
+	return series
+}
+
+func TestMovingAverageConstant(t *testing.T) {
+	series := []float64{5, 5, 5, 5, 5, 5, 5, 5}
+	forecast, err := Predict(series, 3, Params{Method: MethodMovingAverage, Window: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, demand := range forecast.Daily {
+		if math.Abs(demand-5) > tolerance {
+			t.Errorf("wrong demand: want %v, got %v", 5, demand)
+		}
+	}
+	if forecast.Sigma != 0 {
+		t.Errorf("wrong sigma: want %v, got %v", 0, forecast.Sigma)
+	}
+}
+
+func TestMovingAverageWindow(t *testing.T) {
+	series := []float64{10, 0, 1, 2, 3}
+	forecast, err := Predict(series, 1, Params{Method: MethodMovingAverage, Window: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 2.5; math.Abs(forecast.Daily[0]-want) > tolerance {
+		t.Errorf("wrong demand: want %v, got %v", want, forecast.Daily[0])
+	}
+}
+
+func TestExponentialSmoothing(t *testing.T) {
+	series := []float64{4, 8}
+	forecast, err := Predict(series, 2, Params{Method: MethodExponential, Alpha: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 6.0; math.Abs(forecast.Daily[1]-want) > tolerance {
+		t.Errorf("wrong demand: want %v, got %v", want, forecast.Daily[1])
+	}
+	if want := 4.0; math.Abs(forecast.Sigma-want) > tolerance {
+		t.Errorf("wrong sigma: want %v, got %v", want, forecast.Sigma)
+	}
+}
+
+func TestHoltWintersSeasonal(t *testing.T) {
+	pattern := []float64{2, 2, 2, 2, 6, 10, 1}
+	series := weekly(6, pattern, 0)
+	forecast, err := Predict(series, 14, Params{Method: MethodHoltWinters, Season: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for h, demand := range forecast.Daily {
+		if want := pattern[(len(series)+h)%7]; math.Abs(demand-want) > tolerance {
+			t.Errorf("wrong demand on day %d: want %v, got %v", h, want, demand)
+		}
+	}
+	if forecast.Sigma > tolerance {
+		t.Errorf("wrong sigma: want %v, got %v", 0, forecast.Sigma)
+	}
+}
+
+func TestHoltWintersTrend(t *testing.T) {
+	pattern := []float64{2, 2, 2, 2, 6, 10, 1}
+	series := weekly(12, pattern, 0.1)
+	forecast, err := Predict(series, 7, Params{Method: MethodHoltWinters, Season: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := weekly(13, pattern, 0.1)[len(series):]
+	if math.Abs(sum(forecast.Daily)-sum(want)) > 0.5 {
+		t.Errorf("wrong weekly demand: want %v, got %v", sum(want), sum(forecast.Daily))
+	}
+}
+
+func TestPredictNotEnoughData(t *testing.T) {
+	_, err := Predict([]float64{1, 2, 3}, 7, Params{Method: MethodHoltWinters, Season: 7})
+	if !errors.Is(err, ErrNotEnoughData) {
+		t.Errorf("wrong error: want %v, got %v", ErrNotEnoughData, err)
+	}
+}
+
+func TestPredictUnknownMethod(t *testing.T) {
+	_, err := Predict([]float64{1, 2, 3}, 7, Params{Method: "crystal-ball"})
+	if !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("wrong error: want %v, got %v", ErrInvalidParams, err)
+	}
+}
+
+func sum(values []float64) float64 {
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	return total
+}
@@ -0,0 +1,80 @@
+package forecast
+
+import (
+	"fmt"
+	"math"
+)
+
+// Policy is how stock is replenished: an order takes LeadTime days to arrive and
+// has to last until the next order, ReviewPeriod days later
+type Policy struct {
+	LeadTime     int
+	ReviewPeriod int
+	// ServiceLevel is the probability of not running out before an order arrives
+	ServiceLevel float64
+}
+
+// Suggestion is the order suggested for an item and how it was computed
+type Suggestion struct {
+	Forecast      Forecast `json:"forecast"`
+	Demand        float64  `json:"demand"`
+	SafetyStock   float64  `json:"safetyStock"`
+	ParLevel      float64  `json:"parLevel"`
+	OrderQuantity float64  `json:"orderQuantity"`
+	Explanation   []string `json:"explanation"`
+}
+
+// Suggest forecasts the demand over the lead time and review period and suggests
+// ordering up to a par level that covers it plus a safety stock for the service level
+func Suggest(series []float64, available float64, params Params, policy Policy) (Suggestion, error) {
+	if policy.LeadTime < 0 || policy.ReviewPeriod < 0 || policy.LeadTime+policy.ReviewPeriod == 0 ||
+		policy.ServiceLevel <= 0 || policy.ServiceLevel >= 1 {
+		return Suggestion{}, fmt.Errorf("%w: lead time, review period or service level", ErrInvalidParams)
+	}
+	days := policy.LeadTime + policy.ReviewPeriod
+	forecast, err := Predict(series, days, params)
+	if err != nil {
+		return Suggestion{}, err
+	}
+	z := NormalQuantile(policy.ServiceLevel)
+	suggestion := Suggestion{Forecast: forecast, Demand: forecast.Total(days)}
+	suggestion.SafetyStock = math.Max(z*forecast.Sigma*math.Sqrt(float64(days)), 0)
+	suggestion.ParLevel = suggestion.Demand + suggestion.SafetyStock
+	suggestion.OrderQuantity = math.Max(suggestion.ParLevel-available, 0)
+	suggestion.Explanation = []string{
+		fmt.Sprintf("forecast with %s on %d days of history", forecast.Method, len(series)),
+		fmt.Sprintf("demand over %d days (lead time %d + review period %d) = %.3f", days, policy.LeadTime, policy.ReviewPeriod, suggestion.Demand),
+		fmt.Sprintf("safety stock = z %.3f (%.1f%% service level) × daily error %.3f × √%d = %.3f", z, policy.ServiceLevel*100, forecast.Sigma, days, suggestion.SafetyStock),
+		fmt.Sprintf("par level = demand %.3f + safety stock %.3f = %.3f", suggestion.Demand, suggestion.SafetyStock, suggestion.ParLevel),
+		fmt.Sprintf("order quantity = par level %.3f - available %.3f = %.3f", suggestion.ParLevel, available, suggestion.OrderQuantity),
+	}
+	return suggestion, nil
+}
+
+// NormalQuantile returns the quantile of the standard normal distribution for a
+// probability between 0 and 1, using Acklam's rational approximation
+func NormalQuantile(p float64) float64 {
+	a := []float64{-3.969683028665376e+01, 2.209460984245205e+02, -2.759285104469687e+02, 1.383577518672690e+02, -3.066479806614716e+01, 2.506628277459239e+00}
+	b := []float64{-5.447609879822406e+01, 1.615858368580409e+02, -1.556989798598866e+02, 6.680131188771972e+01, -1.328068155288572e+01}
+	c := []float64{-7.784894002430293e-03, -3.223964580411365e-01, -2.400758277161838e+00, -2.549732539343734e+00, 4.374664141464968e+00, 2.938163982698783e+00}
+	d := []float64{7.784695709041462e-03, 3.224671290700398e-01, 2.445134137142996e+00, 3.754408661907416e+00}
+	const low = 0.02425
+	switch {
+	case p <= 0:
+		return math.Inf(-1)
+	case p >= 1:
+		return math.Inf(1)
+	case p < low:
+		q := math.Sqrt(-2 * math.Log(p))
+		return (((((c[0]*q+c[1])*q+c[2])*q+c[3])*q+c[4])*q + c[5]) /
+			((((d[0]*q+d[1])*q+d[2])*q+d[3])*q + 1)
+	case p > 1-low:
+		q := math.Sqrt(-2 * math.Log(1-p))
+		return -(((((c[0]*q+c[1])*q+c[2])*q+c[3])*q+c[4])*q + c[5]) /
+			((((d[0]*q+d[1])*q+d[2])*q+d[3])*q + 1)
+	}
+	q := p - 0.5
+	r := q * q
+	return (((((a[0]*r+a[1])*r+a[2])*r+a[3])*r+a[4])*r + a[5]) * q /
+		(((((b[0]*r+b[1])*r+b[2])*r+b[3])*r+b[4])*r + 1)
+}
@@ -0,0 +1,69 @@
+package forecast
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestNormalQuantile(t *testing.T) {
+	cases := map[float64]float64{0.5: 0, 0.95: 1.6448536, 0.99: 2.3263479, 0.01: -2.3263479}
+	for p, want := range cases {
+		if got := NormalQuantile(p); math.Abs(got-want) > 1e-6 {
+			t.Errorf("wrong quantile of %v: want %v, got %v", p, want, got)
+		}
+	}
+}
+
+func TestSuggestConstantDemand(t *testing.T) {
+	series := []float64{2, 2, 2, 2, 2, 2, 2, 2}
+	suggestion, err := Suggest(series, 4, Params{Window: 7}, Policy{LeadTime: 5, ReviewPeriod: 2, ServiceLevel: 0.95})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(suggestion.Demand-14) > tolerance {
+		t.Errorf("wrong demand: want %v, got %v", 14, suggestion.Demand)
+	}
+	if suggestion.SafetyStock != 0 {
+		t.Errorf("wrong safety stock: want %v, got %v", 0, suggestion.SafetyStock)
+	}
+	if math.Abs(suggestion.OrderQuantity-10) > tolerance {
+		t.Errorf("wrong order quantity: want %v, got %v", 10, suggestion.OrderQuantity)
+	}
+	if len(suggestion.Explanation) == 0 {
+		t.Errorf("missing explanation")
+	}
+}
+
+func TestSuggestSafetyStock(t *testing.T) {
+	series := []float64{1, 3, 1, 3, 1, 3, 1, 3}
+	suggestion, err := Suggest(series, 0, Params{Window: 2}, Policy{LeadTime: 4, ServiceLevel: 0.95})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the moving average of 2 days always predicts 2, missing by 1 every day
+	if want := NormalQuantile(0.95) * 1 * 2; math.Abs(suggestion.SafetyStock-want) > tolerance {
+		t.Errorf("wrong safety stock: want %v, got %v", want, suggestion.SafetyStock)
+	}
+	if want := 8 + suggestion.SafetyStock; math.Abs(suggestion.ParLevel-want) > tolerance {
+		t.Errorf("wrong par level: want %v, got %v", want, suggestion.ParLevel)
+	}
+}
+
+func TestSuggestNothingToOrder(t *testing.T) {
+	series := []float64{1, 1, 1, 1}
+	suggestion, err := Suggest(series, 50, Params{Window: 4}, Policy{LeadTime: 3, ServiceLevel: 0.9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if suggestion.OrderQuantity != 0 {
+		t.Errorf("wrong order quantity: want %v, got %v", 0, suggestion.OrderQuantity)
+	}
+}
+
+func TestSuggestInvalidServiceLevel(t *testing.T) {
+	_, err := Suggest([]float64{1, 1}, 0, Params{Window: 1}, Policy{LeadTime: 3, ServiceLevel: 1})
+	if !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("wrong error: want %v, got %v", ErrInvalidParams, err)
+	}
+}
@@ -0,0 +1,149 @@
+// Package logging configures the structured logs of the api (log/slog). Their level and format
+// can be changed while the api runs, and the attributes of a request, like its ID, are added to
+// every record logged in its context along with the trace of the request if it's traced.
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Formats of the logs
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// ErrUnknownFormat is returned when the format of the logs is not supported
+var ErrUnknownFormat = errors.New("unknown log format")
+
+// Handler writes the records in JSON or text, with a level and a format that can be changed at
+// any time by every goroutine, including the loggers derived from it
+type Handler struct {
+	settings *settings
+	json     slog.Handler
+	text     slog.Handler
+}
+
+// settings are shared by a handler and the handlers derived from it
+type settings struct {
+	level slog.LevelVar
+	// text is set to 1 when the records are written in text
+	text int32
+}
+
+// NewHandler creates a handler writing the records of a level and above in a format
+func NewHandler(w io.Writer, level slog.Level, format string) (*Handler, error) {
+	handler := &Handler{settings: &settings{}}
+	if err := handler.SetFormat(format); err != nil {
+		return nil, err
+	}
+	handler.settings.level.Set(level)
+	options := &slog.HandlerOptions{Level: &handler.settings.level}
+	handler.json = slog.NewJSONHandler(w, options)
+	handler.text = slog.NewTextHandler(w, options)
+	return handler, nil
+}
+
+// FromEnv creates a handler writing to stderr with the level of STOQR_API_LOG_LEVEL (debug,
+// info, warn or error, info by default) and the format of STOQR_API_LOG_FORMAT (json or text,
+// json by default)
+func FromEnv() (*Handler, error) {
+	level, err := ParseLevel(os.Getenv("STOQR_API_LOG_LEVEL"))
+	if err != nil {
+		return nil, err
+	}
+	format := os.Getenv("STOQR_API_LOG_FORMAT")
+	if format == "" {
+		format = FormatJSON
+	}
+	return NewHandler(os.Stderr, level, format)
+}
+
+// ParseLevel parses the name of a level, info if empty
+func ParseLevel(name string) (slog.Level, error) {
+	level := slog.LevelInfo
+	if name == "" {
+		return level, nil
+	}
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return level, fmt.Errorf("unknown log level: %s", name)
+	}
+	return level, nil
+}
+
+// Level returns the level of the records written
+func (handler *Handler) Level() slog.Level {
+	return handler.settings.level.Level()
+}
+
+// SetLevel changes the level of the records written
+func (handler *Handler) SetLevel(level slog.Level) {
+	handler.settings.level.Set(level)
+}
+
+// Format returns the format of the records
+func (handler *Handler) Format() string {
+	if atomic.LoadInt32(&handler.settings.text) == 1 {
+		return FormatText
+	}
+	return FormatJSON
+}
+
+// SetFormat changes the format of the records, json or text
+func (handler *Handler) SetFormat(format string) error {
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		atomic.StoreInt32(&handler.settings.text, 0)
+	case FormatText:
+		atomic.StoreInt32(&handler.settings.text, 1)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+	return nil
+}
+
+// Enabled returns true if records of a level are written
+func (handler *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= handler.settings.level.Level()
+}
+
+// Handle writes a record with the attributes of its context and the ids of its trace
+func (handler *Handler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs, ok := ctx.Value(attrsKey{}).([]slog.Attr); ok {
+		record.AddAttrs(attrs...)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		record.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	if atomic.LoadInt32(&handler.settings.text) == 1 {
+		return handler.text.Handle(ctx, record)
+	}
+	return handler.json.Handle(ctx, record)
+}
+
+// WithAttrs returns a handler adding attributes to every record
+func (handler *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{settings: handler.settings, json: handler.json.WithAttrs(attrs), text: handler.text.WithAttrs(attrs)}
+}
+
+// WithGroup returns a handler qualifying the attributes of every record with a group
+func (handler *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{settings: handler.settings, json: handler.json.WithGroup(name), text: handler.text.WithGroup(name)}
+}
+
+// With returns a context whose records have attributes, in addition to the ones of the parent
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	parent, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return context.WithValue(ctx, attrsKey{}, append(append([]slog.Attr{}, parent...), attrs...))
+}
+
+type attrsKey struct{}
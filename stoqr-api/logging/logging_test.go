@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestHandler(t *testing.T) {
+	buffer := bytes.Buffer{}
+	handler, err := NewHandler(&buffer, slog.LevelInfo, FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(handler).With("component", "test")
+	ctx := With(context.Background(), slog.String("request_id", "abc"))
+	ctx = With(ctx, slog.String("item_id", "1"))
+
+	logger.DebugContext(ctx, "hidden")
+	logger.InfoContext(ctx, "shown")
+
+	record := map[string]interface{}{}
+	if err := json.Unmarshal(buffer.Bytes(), &record); err != nil {
+		t.Fatalf("wrong record: %v\n%s", err, buffer.String())
+	}
+	want := map[string]interface{}{"level": "INFO", "msg": "shown", "component": "test", "request_id": "abc", "item_id": "1"}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("wrong %v: want %v, got %v", key, value, record[key])
+		}
+	}
+}
+
+func TestHandlerSettings(t *testing.T) {
+	buffer := bytes.Buffer{}
+	handler, _ := NewHandler(&buffer, slog.LevelInfo, FormatJSON)
+	logger := slog.New(handler).With("component", "test")
+
+	handler.SetLevel(slog.LevelDebug)
+	if err := handler.SetFormat("TEXT"); err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug("shown")
+
+	if !strings.Contains(buffer.String(), "level=DEBUG msg=shown component=test") {
+		t.Errorf("wrong record: want text at the debug level, got %v", buffer.String())
+	}
+	if handler.Format() != FormatText || handler.Level() != slog.LevelDebug {
+		t.Errorf("wrong settings: want %v %v, got %v %v", FormatText, slog.LevelDebug, handler.Format(), handler.Level())
+	}
+	if err := handler.SetFormat("xml"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("wrong error: want %v, got %v", ErrUnknownFormat, err)
+	}
+}
+
+func TestHandlerTrace(t *testing.T) {
+	buffer := bytes.Buffer{}
+	handler, _ := NewHandler(&buffer, slog.LevelInfo, FormatText)
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.NeverSample()))
+	ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+
+	slog.New(handler).InfoContext(ctx, "traced")
+
+	want := "trace_id=" + span.SpanContext().TraceID().String() + " span_id=" + span.SpanContext().SpanID().String()
+	if !strings.Contains(buffer.String(), want) {
+		t.Errorf("wrong record: want %v, got %v", want, buffer.String())
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		level  string
+		format string
+		err    bool
+	}{
+		{"", "", false},
+		{"debug", "text", false},
+		{"WARN", "json", false},
+		{"verbose", "", true},
+		{"", "xml", true},
+	}
+
+	for _, test := range tests {
+		os.Setenv("STOQR_API_LOG_LEVEL", test.level)
+		os.Setenv("STOQR_API_LOG_FORMAT", test.format)
+		_, err := FromEnv()
+		if (err != nil) != test.err {
+			t.Errorf("wrong error for %v %v: got %v", test.level, test.format, err)
+		}
+	}
+	os.Unsetenv("STOQR_API_LOG_LEVEL")
+	os.Unsetenv("STOQR_API_LOG_FORMAT")
+}
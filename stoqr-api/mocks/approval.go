@@ -0,0 +1,182 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repositories/approval.go
+
+// Package mock_repositories is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "github.com/leandroberetta/stoqr/stoqr-api/models"
+	repositories "github.com/leandroberetta/stoqr/stoqr-api/repositories"
+)
+
+// MockApprovalRepository is a mock of ApprovalRepository interface.
+type MockApprovalRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockApprovalRepositoryMockRecorder
+}
+
+// MockApprovalRepositoryMockRecorder is the mock recorder for MockApprovalRepository.
+type MockApprovalRepositoryMockRecorder struct {
+	mock *MockApprovalRepository
+}
+
+// NewMockApprovalRepository creates a new mock instance.
+func NewMockApprovalRepository(ctrl *gomock.Controller) *MockApprovalRepository {
+	mock := &MockApprovalRepository{ctrl: ctrl}
+	mock.recorder = &MockApprovalRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockApprovalRepository) EXPECT() *MockApprovalRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ApproveRequest mocks base method.
+func (m *MockApprovalRepository) ApproveRequest(id int, decision models.Decision) (models.WithdrawalRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApproveRequest", id, decision)
+	ret0, _ := ret[0].(models.WithdrawalRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApproveRequest indicates an expected call of ApproveRequest.
+func (mr *MockApprovalRepositoryMockRecorder) ApproveRequest(id, decision interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApproveRequest", reflect.TypeOf((*MockApprovalRepository)(nil).ApproveRequest), id, decision)
+}
+
+// CreatePolicy mocks base method.
+func (m *MockApprovalRepository) CreatePolicy(policy *models.Policy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePolicy", policy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePolicy indicates an expected call of CreatePolicy.
+func (mr *MockApprovalRepositoryMockRecorder) CreatePolicy(policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePolicy", reflect.TypeOf((*MockApprovalRepository)(nil).CreatePolicy), policy)
+}
+
+// CreateRequest mocks base method.
+func (m *MockApprovalRepository) CreateRequest(request *models.WithdrawalRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRequest", request)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRequest indicates an expected call of CreateRequest.
+func (mr *MockApprovalRepositoryMockRecorder) CreateRequest(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRequest", reflect.TypeOf((*MockApprovalRepository)(nil).CreateRequest), request)
+}
+
+// DeletePolicy mocks base method.
+func (m *MockApprovalRepository) DeletePolicy(id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePolicy", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePolicy indicates an expected call of DeletePolicy.
+func (mr *MockApprovalRepositoryMockRecorder) DeletePolicy(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePolicy", reflect.TypeOf((*MockApprovalRepository)(nil).DeletePolicy), id)
+}
+
+// ReadPolicies mocks base method.
+func (m *MockApprovalRepository) ReadPolicies() ([]models.Policy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadPolicies")
+	ret0, _ := ret[0].([]models.Policy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadPolicies indicates an expected call of ReadPolicies.
+func (mr *MockApprovalRepositoryMockRecorder) ReadPolicies() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadPolicies", reflect.TypeOf((*MockApprovalRepository)(nil).ReadPolicies))
+}
+
+// ReadPolicy mocks base method.
+func (m *MockApprovalRepository) ReadPolicy(id int) (models.Policy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadPolicy", id)
+	ret0, _ := ret[0].(models.Policy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadPolicy indicates an expected call of ReadPolicy.
+func (mr *MockApprovalRepositoryMockRecorder) ReadPolicy(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadPolicy", reflect.TypeOf((*MockApprovalRepository)(nil).ReadPolicy), id)
+}
+
+// ReadRequest mocks base method.
+func (m *MockApprovalRepository) ReadRequest(id int) (models.WithdrawalRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadRequest", id)
+	ret0, _ := ret[0].(models.WithdrawalRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadRequest indicates an expected call of ReadRequest.
+func (mr *MockApprovalRepositoryMockRecorder) ReadRequest(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadRequest", reflect.TypeOf((*MockApprovalRepository)(nil).ReadRequest), id)
+}
+
+// ReadRequests mocks base method.
+func (m *MockApprovalRepository) ReadRequests(filter repositories.RequestFilter) ([]models.WithdrawalRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadRequests", filter)
+	ret0, _ := ret[0].([]models.WithdrawalRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadRequests indicates an expected call of ReadRequests.
+func (mr *MockApprovalRepositoryMockRecorder) ReadRequests(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadRequests", reflect.TypeOf((*MockApprovalRepository)(nil).ReadRequests), filter)
+}
+
+// RejectRequest mocks base method.
+func (m *MockApprovalRepository) RejectRequest(id int, decision models.Decision) (models.WithdrawalRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectRequest", id, decision)
+	ret0, _ := ret[0].(models.WithdrawalRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RejectRequest indicates an expected call of RejectRequest.
+func (mr *MockApprovalRepositoryMockRecorder) RejectRequest(id, decision interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectRequest", reflect.TypeOf((*MockApprovalRepository)(nil).RejectRequest), id, decision)
+}
+
+// UpdatePolicy mocks base method.
+func (m *MockApprovalRepository) UpdatePolicy(id int, policy models.Policy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePolicy", id, policy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePolicy indicates an expected call of UpdatePolicy.
+func (mr *MockApprovalRepositoryMockRecorder) UpdatePolicy(id, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePolicy", reflect.TypeOf((*MockApprovalRepository)(nil).UpdatePolicy), id, policy)
+}
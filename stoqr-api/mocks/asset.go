@@ -0,0 +1,94 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repositories/asset.go
+
+// Package mock_repositories is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "github.com/leandroberetta/stoqr/stoqr-api/models"
+)
+
+// MockAssetRepository is a mock of AssetRepository interface.
+type MockAssetRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAssetRepositoryMockRecorder
+}
+
+// MockAssetRepositoryMockRecorder is the mock recorder for MockAssetRepository.
+type MockAssetRepositoryMockRecorder struct {
+	mock *MockAssetRepository
+}
+
+// NewMockAssetRepository creates a new mock instance.
+func NewMockAssetRepository(ctrl *gomock.Controller) *MockAssetRepository {
+	mock := &MockAssetRepository{ctrl: ctrl}
+	mock.recorder = &MockAssetRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAssetRepository) EXPECT() *MockAssetRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateAsset mocks base method.
+func (m *MockAssetRepository) CreateAsset(asset *models.Asset) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAsset", asset)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAsset indicates an expected call of CreateAsset.
+func (mr *MockAssetRepositoryMockRecorder) CreateAsset(asset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAsset", reflect.TypeOf((*MockAssetRepository)(nil).CreateAsset), asset)
+}
+
+// ReadAsset mocks base method.
+func (m *MockAssetRepository) ReadAsset(serial string) (models.Asset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadAsset", serial)
+	ret0, _ := ret[0].(models.Asset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadAsset indicates an expected call of ReadAsset.
+func (mr *MockAssetRepositoryMockRecorder) ReadAsset(serial interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadAsset", reflect.TypeOf((*MockAssetRepository)(nil).ReadAsset), serial)
+}
+
+// ReadAssets mocks base method.
+func (m *MockAssetRepository) ReadAssets(itemID int) ([]models.Asset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadAssets", itemID)
+	ret0, _ := ret[0].([]models.Asset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadAssets indicates an expected call of ReadAssets.
+func (mr *MockAssetRepositoryMockRecorder) ReadAssets(itemID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadAssets", reflect.TypeOf((*MockAssetRepository)(nil).ReadAssets), itemID)
+}
+
+// UpdateAsset mocks base method.
+func (m *MockAssetRepository) UpdateAsset(serial string, change models.AssetEvent) (models.Asset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAsset", serial, change)
+	ret0, _ := ret[0].(models.Asset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAsset indicates an expected call of UpdateAsset.
+func (mr *MockAssetRepositoryMockRecorder) UpdateAsset(serial, change interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAsset", reflect.TypeOf((*MockAssetRepository)(nil).UpdateAsset), serial, change)
+}
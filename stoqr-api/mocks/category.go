@@ -0,0 +1,164 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repositories/category.go
+
+// Package mock_repositories is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "github.com/leandroberetta/stoqr/stoqr-api/models"
+)
+
+// MockCategoryRepository is a mock of CategoryRepository interface.
+type MockCategoryRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCategoryRepositoryMockRecorder
+}
+
+// MockCategoryRepositoryMockRecorder is the mock recorder for MockCategoryRepository.
+type MockCategoryRepositoryMockRecorder struct {
+	mock *MockCategoryRepository
+}
+
+// NewMockCategoryRepository creates a new mock instance.
+func NewMockCategoryRepository(ctrl *gomock.Controller) *MockCategoryRepository {
+	mock := &MockCategoryRepository{ctrl: ctrl}
+	mock.recorder = &MockCategoryRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCategoryRepository) EXPECT() *MockCategoryRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateCategory mocks base method.
+func (m *MockCategoryRepository) CreateCategory(category *models.Category) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCategory", category)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCategory indicates an expected call of CreateCategory.
+func (mr *MockCategoryRepositoryMockRecorder) CreateCategory(category interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCategory", reflect.TypeOf((*MockCategoryRepository)(nil).CreateCategory), category)
+}
+
+// CreateTag mocks base method.
+func (m *MockCategoryRepository) CreateTag(tag *models.Tag) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTag", tag)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateTag indicates an expected call of CreateTag.
+func (mr *MockCategoryRepositoryMockRecorder) CreateTag(tag interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTag", reflect.TypeOf((*MockCategoryRepository)(nil).CreateTag), tag)
+}
+
+// DeleteCategory mocks base method.
+func (m *MockCategoryRepository) DeleteCategory(id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCategory", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCategory indicates an expected call of DeleteCategory.
+func (mr *MockCategoryRepositoryMockRecorder) DeleteCategory(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCategory", reflect.TypeOf((*MockCategoryRepository)(nil).DeleteCategory), id)
+}
+
+// DeleteTag mocks base method.
+func (m *MockCategoryRepository) DeleteTag(name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTag", name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTag indicates an expected call of DeleteTag.
+func (mr *MockCategoryRepositoryMockRecorder) DeleteTag(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTag", reflect.TypeOf((*MockCategoryRepository)(nil).DeleteTag), name)
+}
+
+// ReadCategories mocks base method.
+func (m *MockCategoryRepository) ReadCategories() (models.Categories, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadCategories")
+	ret0, _ := ret[0].(models.Categories)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadCategories indicates an expected call of ReadCategories.
+func (mr *MockCategoryRepositoryMockRecorder) ReadCategories() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadCategories", reflect.TypeOf((*MockCategoryRepository)(nil).ReadCategories))
+}
+
+// ReadCategory mocks base method.
+func (m *MockCategoryRepository) ReadCategory(id int) (models.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadCategory", id)
+	ret0, _ := ret[0].(models.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadCategory indicates an expected call of ReadCategory.
+func (mr *MockCategoryRepositoryMockRecorder) ReadCategory(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadCategory", reflect.TypeOf((*MockCategoryRepository)(nil).ReadCategory), id)
+}
+
+// ReadTags mocks base method.
+func (m *MockCategoryRepository) ReadTags() ([]models.Tag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadTags")
+	ret0, _ := ret[0].([]models.Tag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadTags indicates an expected call of ReadTags.
+func (mr *MockCategoryRepositoryMockRecorder) ReadTags() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadTags", reflect.TypeOf((*MockCategoryRepository)(nil).ReadTags))
+}
+
+// UpdateCategory mocks base method.
+func (m *MockCategoryRepository) UpdateCategory(id int, category models.Category) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCategory", id, category)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCategory indicates an expected call of UpdateCategory.
+func (mr *MockCategoryRepositoryMockRecorder) UpdateCategory(id, category interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCategory", reflect.TypeOf((*MockCategoryRepository)(nil).UpdateCategory), id, category)
+}
+
+// UpdateTag mocks base method.
+func (m *MockCategoryRepository) UpdateTag(name string, tag models.Tag) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTag", name, tag)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTag indicates an expected call of UpdateTag.
+func (mr *MockCategoryRepositoryMockRecorder) UpdateTag(name, tag interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTag", reflect.TypeOf((*MockCategoryRepository)(nil).UpdateTag), name, tag)
+}
@@ -0,0 +1,107 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repositories/channel.go
+
+// Package mock_repositories is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "github.com/leandroberetta/stoqr/stoqr-api/models"
+)
+
+// MockChannelRepository is a mock of ChannelRepository interface.
+type MockChannelRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockChannelRepositoryMockRecorder
+}
+
+// MockChannelRepositoryMockRecorder is the mock recorder for MockChannelRepository.
+type MockChannelRepositoryMockRecorder struct {
+	mock *MockChannelRepository
+}
+
+// NewMockChannelRepository creates a new mock instance.
+func NewMockChannelRepository(ctrl *gomock.Controller) *MockChannelRepository {
+	mock := &MockChannelRepository{ctrl: ctrl}
+	mock.recorder = &MockChannelRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockChannelRepository) EXPECT() *MockChannelRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateChannel mocks base method.
+func (m *MockChannelRepository) CreateChannel(channel *models.Channel) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateChannel", channel)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateChannel indicates an expected call of CreateChannel.
+func (mr *MockChannelRepositoryMockRecorder) CreateChannel(channel interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateChannel", reflect.TypeOf((*MockChannelRepository)(nil).CreateChannel), channel)
+}
+
+// DeleteChannel mocks base method.
+func (m *MockChannelRepository) DeleteChannel(id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteChannel", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteChannel indicates an expected call of DeleteChannel.
+func (mr *MockChannelRepositoryMockRecorder) DeleteChannel(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteChannel", reflect.TypeOf((*MockChannelRepository)(nil).DeleteChannel), id)
+}
+
+// ReadChannel mocks base method.
+func (m *MockChannelRepository) ReadChannel(id int) (models.Channel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadChannel", id)
+	ret0, _ := ret[0].(models.Channel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadChannel indicates an expected call of ReadChannel.
+func (mr *MockChannelRepositoryMockRecorder) ReadChannel(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadChannel", reflect.TypeOf((*MockChannelRepository)(nil).ReadChannel), id)
+}
+
+// ReadChannels mocks base method.
+func (m *MockChannelRepository) ReadChannels() ([]models.Channel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadChannels")
+	ret0, _ := ret[0].([]models.Channel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadChannels indicates an expected call of ReadChannels.
+func (mr *MockChannelRepositoryMockRecorder) ReadChannels() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadChannels", reflect.TypeOf((*MockChannelRepository)(nil).ReadChannels))
+}
+
+// UpdateChannel mocks base method.
+func (m *MockChannelRepository) UpdateChannel(id int, channel models.Channel) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateChannel", id, channel)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateChannel indicates an expected call of UpdateChannel.
+func (mr *MockChannelRepositoryMockRecorder) UpdateChannel(id, channel interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateChannel", reflect.TypeOf((*MockChannelRepository)(nil).UpdateChannel), id, channel)
+}
@@ -6,9 +6,11 @@ package mocks
 
 import (
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	models "github.com/leandroberetta/stoqr/stoqr-api/models"
+	repositories "github.com/leandroberetta/stoqr/stoqr-api/repositories"
 )
 
 // MockItemRepository is a mock of ItemRepository interface.
@@ -62,6 +64,36 @@ func (mr *MockItemRepositoryMockRecorder) DeleteItem(id interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteItem", reflect.TypeOf((*MockItemRepository)(nil).DeleteItem), id)
 }
 
+// ReadComponents mocks base method.
+func (m *MockItemRepository) ReadComponents(id int) ([]models.Component, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadComponents", id)
+	ret0, _ := ret[0].([]models.Component)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadComponents indicates an expected call of ReadComponents.
+func (mr *MockItemRepositoryMockRecorder) ReadComponents(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadComponents", reflect.TypeOf((*MockItemRepository)(nil).ReadComponents), id)
+}
+
+// ReadConversions mocks base method.
+func (m *MockItemRepository) ReadConversions(id int) ([]models.Conversion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadConversions", id)
+	ret0, _ := ret[0].([]models.Conversion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadConversions indicates an expected call of ReadConversions.
+func (mr *MockItemRepositoryMockRecorder) ReadConversions(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadConversions", reflect.TypeOf((*MockItemRepository)(nil).ReadConversions), id)
+}
+
 // ReadItem mocks base method.
 func (m *MockItemRepository) ReadItem(id int) (models.Item, error) {
 	m.ctrl.T.Helper()
@@ -77,8 +109,23 @@ func (mr *MockItemRepositoryMockRecorder) ReadItem(id interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadItem", reflect.TypeOf((*MockItemRepository)(nil).ReadItem), id)
 }
 
+// ReadItemAt mocks base method.
+func (m *MockItemRepository) ReadItemAt(id int, at time.Time) (models.Item, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadItemAt", id, at)
+	ret0, _ := ret[0].(models.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadItemAt indicates an expected call of ReadItemAt.
+func (mr *MockItemRepositoryMockRecorder) ReadItemAt(id, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadItemAt", reflect.TypeOf((*MockItemRepository)(nil).ReadItemAt), id, at)
+}
+
 // ReadItems mocks base method.
-func (m *MockItemRepository) ReadItems(filter string) ([]models.Item, error) {
+func (m *MockItemRepository) ReadItems(filter repositories.ItemFilter) ([]models.Item, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ReadItems", filter)
 	ret0, _ := ret[0].([]models.Item)
@@ -92,6 +139,94 @@ func (mr *MockItemRepositoryMockRecorder) ReadItems(filter interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadItems", reflect.TypeOf((*MockItemRepository)(nil).ReadItems), filter)
 }
 
+// ReadStockHistory mocks base method.
+func (m *MockItemRepository) ReadStockHistory(id int, from, to time.Time, step time.Duration) ([]models.StockPoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadStockHistory", id, from, to, step)
+	ret0, _ := ret[0].([]models.StockPoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadStockHistory indicates an expected call of ReadStockHistory.
+func (mr *MockItemRepositoryMockRecorder) ReadStockHistory(id, from, to, step interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadStockHistory", reflect.TypeOf((*MockItemRepository)(nil).ReadStockHistory), id, from, to, step)
+}
+
+// RestockItem mocks base method.
+func (m *MockItemRepository) RestockItem(id int, restock models.Restock) (models.Item, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestockItem", id, restock)
+	ret0, _ := ret[0].(models.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestockItem indicates an expected call of RestockItem.
+func (mr *MockItemRepositoryMockRecorder) RestockItem(id, restock interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestockItem", reflect.TypeOf((*MockItemRepository)(nil).RestockItem), id, restock)
+}
+
+// TakeSnapshot mocks base method.
+func (m *MockItemRepository) TakeSnapshot() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeSnapshot")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeSnapshot indicates an expected call of TakeSnapshot.
+func (mr *MockItemRepositoryMockRecorder) TakeSnapshot() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeSnapshot", reflect.TypeOf((*MockItemRepository)(nil).TakeSnapshot))
+}
+
+// UndoOperation mocks base method.
+func (m *MockItemRepository) UndoOperation(id string, since time.Time) (models.Item, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UndoOperation", id, since)
+	ret0, _ := ret[0].(models.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UndoOperation indicates an expected call of UndoOperation.
+func (mr *MockItemRepositoryMockRecorder) UndoOperation(id, since interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UndoOperation", reflect.TypeOf((*MockItemRepository)(nil).UndoOperation), id, since)
+}
+
+// UpdateComponents mocks base method.
+func (m *MockItemRepository) UpdateComponents(id int, components []models.Component) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateComponents", id, components)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateComponents indicates an expected call of UpdateComponents.
+func (mr *MockItemRepositoryMockRecorder) UpdateComponents(id, components interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateComponents", reflect.TypeOf((*MockItemRepository)(nil).UpdateComponents), id, components)
+}
+
+// UpdateConversions mocks base method.
+func (m *MockItemRepository) UpdateConversions(id int, conversions []models.Conversion) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateConversions", id, conversions)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateConversions indicates an expected call of UpdateConversions.
+func (mr *MockItemRepositoryMockRecorder) UpdateConversions(id, conversions interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateConversions", reflect.TypeOf((*MockItemRepository)(nil).UpdateConversions), id, conversions)
+}
+
 // UpdateItem mocks base method.
 func (m *MockItemRepository) UpdateItem(id int, item models.Item) error {
 	m.ctrl.T.Helper()
@@ -105,3 +240,32 @@ func (mr *MockItemRepositoryMockRecorder) UpdateItem(id, item interface{}) *gomo
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateItem", reflect.TypeOf((*MockItemRepository)(nil).UpdateItem), id, item)
 }
+
+// UpdateTags mocks base method.
+func (m *MockItemRepository) UpdateTags(id int, tags []models.Tag) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTags", id, tags)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTags indicates an expected call of UpdateTags.
+func (mr *MockItemRepositoryMockRecorder) UpdateTags(id, tags interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTags", reflect.TypeOf((*MockItemRepository)(nil).UpdateTags), id, tags)
+}
+
+// WithdrawItem mocks base method.
+func (m *MockItemRepository) WithdrawItem(id int, quantity models.Quantity) (models.Item, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithdrawItem", id, quantity)
+	ret0, _ := ret[0].(models.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WithdrawItem indicates an expected call of WithdrawItem.
+func (mr *MockItemRepositoryMockRecorder) WithdrawItem(id, quantity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithdrawItem", reflect.TypeOf((*MockItemRepository)(nil).WithdrawItem), id, quantity)
+}
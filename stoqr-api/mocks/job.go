@@ -0,0 +1,123 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repositories/job.go
+
+// Package mock_repositories is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	models "github.com/leandroberetta/stoqr/stoqr-api/models"
+)
+
+// MockJobRepository is a mock of JobRepository interface.
+type MockJobRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockJobRepositoryMockRecorder
+}
+
+// MockJobRepositoryMockRecorder is the mock recorder for MockJobRepository.
+type MockJobRepositoryMockRecorder struct {
+	mock *MockJobRepository
+}
+
+// NewMockJobRepository creates a new mock instance.
+func NewMockJobRepository(ctrl *gomock.Controller) *MockJobRepository {
+	mock := &MockJobRepository{ctrl: ctrl}
+	mock.recorder = &MockJobRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJobRepository) EXPECT() *MockJobRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AcquireJob mocks base method.
+func (m *MockJobRepository) AcquireJob(name, owner string, now, until time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcquireJob", name, owner, now, until)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcquireJob indicates an expected call of AcquireJob.
+func (mr *MockJobRepositoryMockRecorder) AcquireJob(name, owner, now, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcquireJob", reflect.TypeOf((*MockJobRepository)(nil).AcquireJob), name, owner, now, until)
+}
+
+// CompleteJob mocks base method.
+func (m *MockJobRepository) CompleteJob(name, owner string, run models.Job) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteJob", name, owner, run)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteJob indicates an expected call of CompleteJob.
+func (mr *MockJobRepositoryMockRecorder) CompleteJob(name, owner, run interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteJob", reflect.TypeOf((*MockJobRepository)(nil).CompleteJob), name, owner, run)
+}
+
+// ReadJob mocks base method.
+func (m *MockJobRepository) ReadJob(name string) (models.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadJob", name)
+	ret0, _ := ret[0].(models.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadJob indicates an expected call of ReadJob.
+func (mr *MockJobRepositoryMockRecorder) ReadJob(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadJob", reflect.TypeOf((*MockJobRepository)(nil).ReadJob), name)
+}
+
+// ReadJobs mocks base method.
+func (m *MockJobRepository) ReadJobs() ([]models.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadJobs")
+	ret0, _ := ret[0].([]models.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadJobs indicates an expected call of ReadJobs.
+func (mr *MockJobRepositoryMockRecorder) ReadJobs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadJobs", reflect.TypeOf((*MockJobRepository)(nil).ReadJobs))
+}
+
+// RegisterJob mocks base method.
+func (m *MockJobRepository) RegisterJob(job *models.Job) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterJob", job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegisterJob indicates an expected call of RegisterJob.
+func (mr *MockJobRepositoryMockRecorder) RegisterJob(job interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterJob", reflect.TypeOf((*MockJobRepository)(nil).RegisterJob), job)
+}
+
+// TriggerJob mocks base method.
+func (m *MockJobRepository) TriggerJob(name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TriggerJob", name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TriggerJob indicates an expected call of TriggerJob.
+func (mr *MockJobRepositoryMockRecorder) TriggerJob(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TriggerJob", reflect.TypeOf((*MockJobRepository)(nil).TriggerJob), name)
+}
@@ -0,0 +1,95 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repositories/loan.go
+
+// Package mock_repositories is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "github.com/leandroberetta/stoqr/stoqr-api/models"
+	repositories "github.com/leandroberetta/stoqr/stoqr-api/repositories"
+)
+
+// MockLoanRepository is a mock of LoanRepository interface.
+type MockLoanRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoanRepositoryMockRecorder
+}
+
+// MockLoanRepositoryMockRecorder is the mock recorder for MockLoanRepository.
+type MockLoanRepositoryMockRecorder struct {
+	mock *MockLoanRepository
+}
+
+// NewMockLoanRepository creates a new mock instance.
+func NewMockLoanRepository(ctrl *gomock.Controller) *MockLoanRepository {
+	mock := &MockLoanRepository{ctrl: ctrl}
+	mock.recorder = &MockLoanRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoanRepository) EXPECT() *MockLoanRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CheckIn mocks base method.
+func (m *MockLoanRepository) CheckIn(id int) (models.Loan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckIn", id)
+	ret0, _ := ret[0].(models.Loan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckIn indicates an expected call of CheckIn.
+func (mr *MockLoanRepositoryMockRecorder) CheckIn(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckIn", reflect.TypeOf((*MockLoanRepository)(nil).CheckIn), id)
+}
+
+// CheckOut mocks base method.
+func (m *MockLoanRepository) CheckOut(loan *models.Loan) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckOut", loan)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckOut indicates an expected call of CheckOut.
+func (mr *MockLoanRepositoryMockRecorder) CheckOut(loan interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckOut", reflect.TypeOf((*MockLoanRepository)(nil).CheckOut), loan)
+}
+
+// ReadLoan mocks base method.
+func (m *MockLoanRepository) ReadLoan(id int) (models.Loan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadLoan", id)
+	ret0, _ := ret[0].(models.Loan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadLoan indicates an expected call of ReadLoan.
+func (mr *MockLoanRepositoryMockRecorder) ReadLoan(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadLoan", reflect.TypeOf((*MockLoanRepository)(nil).ReadLoan), id)
+}
+
+// ReadLoans mocks base method.
+func (m *MockLoanRepository) ReadLoans(filter repositories.LoanFilter) ([]models.Loan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadLoans", filter)
+	ret0, _ := ret[0].([]models.Loan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadLoans indicates an expected call of ReadLoans.
+func (mr *MockLoanRepositoryMockRecorder) ReadLoans(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadLoans", reflect.TypeOf((*MockLoanRepository)(nil).ReadLoans), filter)
+}
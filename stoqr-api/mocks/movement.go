@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repositories/movement.go
+
+// Package mock_repositories is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	models "github.com/leandroberetta/stoqr/stoqr-api/models"
+)
+
+// MockMovementRepository is a mock of MovementRepository interface.
+type MockMovementRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockMovementRepositoryMockRecorder
+}
+
+// MockMovementRepositoryMockRecorder is the mock recorder for MockMovementRepository.
+type MockMovementRepositoryMockRecorder struct {
+	mock *MockMovementRepository
+}
+
+// NewMockMovementRepository creates a new mock instance.
+func NewMockMovementRepository(ctrl *gomock.Controller) *MockMovementRepository {
+	mock := &MockMovementRepository{ctrl: ctrl}
+	mock.recorder = &MockMovementRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMovementRepository) EXPECT() *MockMovementRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ReadMovements mocks base method.
+func (m *MockMovementRepository) ReadMovements(itemID int, to time.Time) ([]models.Movement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadMovements", itemID, to)
+	ret0, _ := ret[0].([]models.Movement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadMovements indicates an expected call of ReadMovements.
+func (mr *MockMovementRepositoryMockRecorder) ReadMovements(itemID, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadMovements", reflect.TypeOf((*MockMovementRepository)(nil).ReadMovements), itemID, to)
+}
+
+// ReadWithdrawn mocks base method.
+func (m *MockMovementRepository) ReadWithdrawn(from, to time.Time) (map[int]models.Quantity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadWithdrawn", from, to)
+	ret0, _ := ret[0].(map[int]models.Quantity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadWithdrawn indicates an expected call of ReadWithdrawn.
+func (mr *MockMovementRepositoryMockRecorder) ReadWithdrawn(from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadWithdrawn", reflect.TypeOf((*MockMovementRepository)(nil).ReadWithdrawn), from, to)
+}
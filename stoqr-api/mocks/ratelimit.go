@@ -0,0 +1,67 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repositories/ratelimit.go
+
+// Package mock_repositories is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	ratelimit "github.com/leandroberetta/stoqr/stoqr-api/ratelimit"
+)
+
+// MockRateLimitRepository is a mock of RateLimitRepository interface.
+type MockRateLimitRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRateLimitRepositoryMockRecorder
+}
+
+// MockRateLimitRepositoryMockRecorder is the mock recorder for MockRateLimitRepository.
+type MockRateLimitRepositoryMockRecorder struct {
+	mock *MockRateLimitRepository
+}
+
+// NewMockRateLimitRepository creates a new mock instance.
+func NewMockRateLimitRepository(ctrl *gomock.Controller) *MockRateLimitRepository {
+	mock := &MockRateLimitRepository{ctrl: ctrl}
+	mock.recorder = &MockRateLimitRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRateLimitRepository) EXPECT() *MockRateLimitRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteIdle mocks base method.
+func (m *MockRateLimitRepository) DeleteIdle(before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdle", before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteIdle indicates an expected call of DeleteIdle.
+func (mr *MockRateLimitRepositoryMockRecorder) DeleteIdle(before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdle", reflect.TypeOf((*MockRateLimitRepository)(nil).DeleteIdle), before)
+}
+
+// Take mocks base method.
+func (m *MockRateLimitRepository) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Take", ctx, key, limit)
+	ret0, _ := ret[0].(ratelimit.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Take indicates an expected call of Take.
+func (mr *MockRateLimitRepositoryMockRecorder) Take(ctx, key, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Take", reflect.TypeOf((*MockRateLimitRepository)(nil).Take), ctx, key, limit)
+}
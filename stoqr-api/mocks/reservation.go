@@ -0,0 +1,123 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repositories/reservation.go
+
+// Package mock_repositories is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "github.com/leandroberetta/stoqr/stoqr-api/models"
+)
+
+// MockReservationRepository is a mock of ReservationRepository interface.
+type MockReservationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockReservationRepositoryMockRecorder
+}
+
+// MockReservationRepositoryMockRecorder is the mock recorder for MockReservationRepository.
+type MockReservationRepositoryMockRecorder struct {
+	mock *MockReservationRepository
+}
+
+// NewMockReservationRepository creates a new mock instance.
+func NewMockReservationRepository(ctrl *gomock.Controller) *MockReservationRepository {
+	mock := &MockReservationRepository{ctrl: ctrl}
+	mock.recorder = &MockReservationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReservationRepository) EXPECT() *MockReservationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateReservation mocks base method.
+func (m *MockReservationRepository) CreateReservation(reservation *models.Reservation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReservation", reservation)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateReservation indicates an expected call of CreateReservation.
+func (mr *MockReservationRepositoryMockRecorder) CreateReservation(reservation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReservation", reflect.TypeOf((*MockReservationRepository)(nil).CreateReservation), reservation)
+}
+
+// ExpireReservations mocks base method.
+func (m *MockReservationRepository) ExpireReservations() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireReservations")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireReservations indicates an expected call of ExpireReservations.
+func (mr *MockReservationRepositoryMockRecorder) ExpireReservations() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireReservations", reflect.TypeOf((*MockReservationRepository)(nil).ExpireReservations))
+}
+
+// ReadReservation mocks base method.
+func (m *MockReservationRepository) ReadReservation(id int) (models.Reservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadReservation", id)
+	ret0, _ := ret[0].(models.Reservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadReservation indicates an expected call of ReadReservation.
+func (mr *MockReservationRepositoryMockRecorder) ReadReservation(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadReservation", reflect.TypeOf((*MockReservationRepository)(nil).ReadReservation), id)
+}
+
+// ReadReservations mocks base method.
+func (m *MockReservationRepository) ReadReservations(itemID int) ([]models.Reservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadReservations", itemID)
+	ret0, _ := ret[0].([]models.Reservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadReservations indicates an expected call of ReadReservations.
+func (mr *MockReservationRepositoryMockRecorder) ReadReservations(itemID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadReservations", reflect.TypeOf((*MockReservationRepository)(nil).ReadReservations), itemID)
+}
+
+// ReleaseReservation mocks base method.
+func (m *MockReservationRepository) ReleaseReservation(id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseReservation", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseReservation indicates an expected call of ReleaseReservation.
+func (mr *MockReservationRepositoryMockRecorder) ReleaseReservation(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseReservation", reflect.TypeOf((*MockReservationRepository)(nil).ReleaseReservation), id)
+}
+
+// WithdrawReservation mocks base method.
+func (m *MockReservationRepository) WithdrawReservation(id int, quantity models.Quantity) (models.Item, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithdrawReservation", id, quantity)
+	ret0, _ := ret[0].(models.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WithdrawReservation indicates an expected call of WithdrawReservation.
+func (mr *MockReservationRepositoryMockRecorder) WithdrawReservation(id, quantity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithdrawReservation", reflect.TypeOf((*MockReservationRepository)(nil).WithdrawReservation), id, quantity)
+}
@@ -0,0 +1,123 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repositories/stocktake.go
+
+// Package mock_repositories is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "github.com/leandroberetta/stoqr/stoqr-api/models"
+)
+
+// MockStocktakeRepository is a mock of StocktakeRepository interface.
+type MockStocktakeRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockStocktakeRepositoryMockRecorder
+}
+
+// MockStocktakeRepositoryMockRecorder is the mock recorder for MockStocktakeRepository.
+type MockStocktakeRepositoryMockRecorder struct {
+	mock *MockStocktakeRepository
+}
+
+// NewMockStocktakeRepository creates a new mock instance.
+func NewMockStocktakeRepository(ctrl *gomock.Controller) *MockStocktakeRepository {
+	mock := &MockStocktakeRepository{ctrl: ctrl}
+	mock.recorder = &MockStocktakeRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStocktakeRepository) EXPECT() *MockStocktakeRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CancelStocktake mocks base method.
+func (m *MockStocktakeRepository) CancelStocktake(id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelStocktake", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelStocktake indicates an expected call of CancelStocktake.
+func (mr *MockStocktakeRepositoryMockRecorder) CancelStocktake(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelStocktake", reflect.TypeOf((*MockStocktakeRepository)(nil).CancelStocktake), id)
+}
+
+// CommitStocktake mocks base method.
+func (m *MockStocktakeRepository) CommitStocktake(id int) (models.Stocktake, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommitStocktake", id)
+	ret0, _ := ret[0].(models.Stocktake)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CommitStocktake indicates an expected call of CommitStocktake.
+func (mr *MockStocktakeRepositoryMockRecorder) CommitStocktake(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitStocktake", reflect.TypeOf((*MockStocktakeRepository)(nil).CommitStocktake), id)
+}
+
+// CreateStocktake mocks base method.
+func (m *MockStocktakeRepository) CreateStocktake(stocktake *models.Stocktake) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateStocktake", stocktake)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateStocktake indicates an expected call of CreateStocktake.
+func (mr *MockStocktakeRepositoryMockRecorder) CreateStocktake(stocktake interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateStocktake", reflect.TypeOf((*MockStocktakeRepository)(nil).CreateStocktake), stocktake)
+}
+
+// ReadStocktake mocks base method.
+func (m *MockStocktakeRepository) ReadStocktake(id int) (models.Stocktake, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadStocktake", id)
+	ret0, _ := ret[0].(models.Stocktake)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadStocktake indicates an expected call of ReadStocktake.
+func (mr *MockStocktakeRepositoryMockRecorder) ReadStocktake(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadStocktake", reflect.TypeOf((*MockStocktakeRepository)(nil).ReadStocktake), id)
+}
+
+// ReadStocktakes mocks base method.
+func (m *MockStocktakeRepository) ReadStocktakes() ([]models.Stocktake, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadStocktakes")
+	ret0, _ := ret[0].([]models.Stocktake)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadStocktakes indicates an expected call of ReadStocktakes.
+func (mr *MockStocktakeRepositoryMockRecorder) ReadStocktakes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadStocktakes", reflect.TypeOf((*MockStocktakeRepository)(nil).ReadStocktakes))
+}
+
+// RecordCount mocks base method.
+func (m *MockStocktakeRepository) RecordCount(id, itemID int, quantity models.Quantity, add bool) (models.StocktakeCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordCount", id, itemID, quantity, add)
+	ret0, _ := ret[0].(models.StocktakeCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordCount indicates an expected call of RecordCount.
+func (mr *MockStocktakeRepositoryMockRecorder) RecordCount(id, itemID, quantity, add interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordCount", reflect.TypeOf((*MockStocktakeRepository)(nil).RecordCount), id, itemID, quantity, add)
+}
@@ -0,0 +1,107 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repositories/subscription.go
+
+// Package mock_repositories is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	models "github.com/leandroberetta/stoqr/stoqr-api/models"
+)
+
+// MockSubscriptionRepository is a mock of SubscriptionRepository interface.
+type MockSubscriptionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSubscriptionRepositoryMockRecorder
+}
+
+// MockSubscriptionRepositoryMockRecorder is the mock recorder for MockSubscriptionRepository.
+type MockSubscriptionRepositoryMockRecorder struct {
+	mock *MockSubscriptionRepository
+}
+
+// NewMockSubscriptionRepository creates a new mock instance.
+func NewMockSubscriptionRepository(ctrl *gomock.Controller) *MockSubscriptionRepository {
+	mock := &MockSubscriptionRepository{ctrl: ctrl}
+	mock.recorder = &MockSubscriptionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSubscriptionRepository) EXPECT() *MockSubscriptionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateSubscription mocks base method.
+func (m *MockSubscriptionRepository) CreateSubscription(subscription *models.Subscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSubscription", subscription)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSubscription indicates an expected call of CreateSubscription.
+func (mr *MockSubscriptionRepositoryMockRecorder) CreateSubscription(subscription interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSubscription", reflect.TypeOf((*MockSubscriptionRepository)(nil).CreateSubscription), subscription)
+}
+
+// DeleteSubscription mocks base method.
+func (m *MockSubscriptionRepository) DeleteSubscription(id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSubscription", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSubscription indicates an expected call of DeleteSubscription.
+func (mr *MockSubscriptionRepositoryMockRecorder) DeleteSubscription(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSubscription", reflect.TypeOf((*MockSubscriptionRepository)(nil).DeleteSubscription), id)
+}
+
+// ReadSubscription mocks base method.
+func (m *MockSubscriptionRepository) ReadSubscription(id int) (models.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadSubscription", id)
+	ret0, _ := ret[0].(models.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadSubscription indicates an expected call of ReadSubscription.
+func (mr *MockSubscriptionRepositoryMockRecorder) ReadSubscription(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadSubscription", reflect.TypeOf((*MockSubscriptionRepository)(nil).ReadSubscription), id)
+}
+
+// ReadSubscriptions mocks base method.
+func (m *MockSubscriptionRepository) ReadSubscriptions(event string) ([]models.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadSubscriptions", event)
+	ret0, _ := ret[0].([]models.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadSubscriptions indicates an expected call of ReadSubscriptions.
+func (mr *MockSubscriptionRepositoryMockRecorder) ReadSubscriptions(event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadSubscriptions", reflect.TypeOf((*MockSubscriptionRepository)(nil).ReadSubscriptions), event)
+}
+
+// UpdateSubscription mocks base method.
+func (m *MockSubscriptionRepository) UpdateSubscription(id int, subscription models.Subscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSubscription", id, subscription)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSubscription indicates an expected call of UpdateSubscription.
+func (mr *MockSubscriptionRepositoryMockRecorder) UpdateSubscription(id, subscription interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSubscription", reflect.TypeOf((*MockSubscriptionRepository)(nil).UpdateSubscription), id, subscription)
+}
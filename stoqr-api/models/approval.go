@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Withdrawal request statuses
+const (
+	RequestPending  = "pending"
+	RequestApproved = "approved"
+	RequestRejected = "rejected"
+)
+
+// Policy requires approval to withdraw an item, or the items of a category and its
+// subcategories, when it's restricted or the quantity is above the maximum
+type Policy struct {
+	ID          int       `json:"id"`
+	ItemID      *int      `json:"itemId" gorm:"uniqueIndex"`
+	CategoryID  *int      `json:"categoryId" gorm:"uniqueIndex"`
+	MaxQuantity *Quantity `json:"maxQuantity"`
+	Restricted  bool      `json:"restricted"`
+}
+
+// Requires returns true if withdrawing the quantity needs an approval
+func (policy *Policy) Requires(quantity Quantity) bool {
+	return policy.Restricted || (policy.MaxQuantity != nil && quantity > *policy.MaxQuantity)
+}
+
+// WithdrawalRequest is a withdrawal waiting for an approver to accept it before
+// the stock changes, the decision is emailed to the contact of the requester
+type WithdrawalRequest struct {
+	ID        int        `json:"id"`
+	ItemID    int        `json:"itemId" gorm:"index"`
+	Quantity  Quantity   `json:"quantity"`
+	Requester string     `json:"requester"`
+	Contact   string     `json:"contact,omitempty"`
+	Reason    string     `json:"reason"`
+	Status    string     `json:"status" gorm:"index"`
+	Approver  string     `json:"approver,omitempty"`
+	Comment   string     `json:"comment,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	DecidedAt *time.Time `json:"decidedAt"`
+}
+
+// Decision is an approver's answer to a withdrawal request
+type Decision struct {
+	Approver string `json:"approver"`
+	Comment  string `json:"comment"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Asset statuses
+const (
+	AssetInStock  = "in_stock"
+	AssetLent     = "lent"
+	AssetInRepair = "in_repair"
+	AssetRetired  = "retired"
+)
+
+// Asset is a physical unit of a serialized item identified by its serial number
+type Asset struct {
+	ID        int          `json:"id"`
+	ItemID    int          `json:"itemId" gorm:"index"`
+	Serial    string       `json:"serial" gorm:"uniqueIndex"`
+	Status    string       `json:"status" gorm:"index"`
+	Location  string       `json:"location"`
+	CreatedAt time.Time    `json:"createdAt"`
+	UpdatedAt time.Time    `json:"updatedAt"`
+	Events    []AssetEvent `json:"events,omitempty"`
+}
+
+// AssetEvent records the status and location of an asset after each change
+type AssetEvent struct {
+	ID        int       `json:"-"`
+	AssetID   int       `json:"-" gorm:"index"`
+	Status    string    `json:"status"`
+	Location  string    `json:"location"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// IsAssetStatus returns true if the status is one of the asset statuses
+func IsAssetStatus(status string) bool {
+	switch status {
+	case AssetInStock, AssetLent, AssetInRepair, AssetRetired:
+		return true
+	}
+	return false
+}
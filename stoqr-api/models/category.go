@@ -0,0 +1,77 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Category groups items, categories can be nested into a parent category
+type Category struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	ParentID *int   `json:"parentId"`
+}
+
+// Tag is a free-form label of items, it's represented in JSON by its name
+type Tag struct {
+	ID   int    `json:"-"`
+	Name string `json:"-" gorm:"uniqueIndex"`
+}
+
+// MarshalJSON encodes the tag as its name
+func (tag Tag) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tag.Name)
+}
+
+// UnmarshalJSON decodes the tag from its name
+func (tag *Tag) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &tag.Name)
+}
+
+// Categories is a list of categories forming one or more trees
+type Categories []Category
+
+// Path returns the names of a category and its ancestors, from the root down, joined by a slash
+func (categories Categories) Path(id int) string {
+	byID := map[int]Category{}
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+	names := []string{}
+	category, ok := byID[id]
+	for ok && len(names) < len(categories) {
+		names = append([]string{category.Name}, names...)
+		if category.ParentID == nil {
+			break
+		}
+		category, ok = byID[*category.ParentID]
+	}
+	return strings.Join(names, " / ")
+}
+
+// Descendants returns the ids of a category and all of its subcategories
+func (categories Categories) Descendants(id int) []int {
+	ids := []int{id}
+	seen := map[int]bool{id: true}
+	for i := 0; i < len(ids); i++ {
+		for _, category := range categories {
+			if category.ParentID != nil && *category.ParentID == ids[i] && !seen[category.ID] {
+				seen[category.ID] = true
+				ids = append(ids, category.ID)
+			}
+		}
+	}
+	return ids
+}
+
+// ShoppingListEntry is an item below its desired stock and the quantity missing to reach it
+type ShoppingListEntry struct {
+	Item
+	Missing Quantity `json:"missing"`
+}
+
+// ShoppingListGroup is a group of entries of the shopping list
+type ShoppingListGroup struct {
+	Name    string              `json:"name"`
+	Entries []ShoppingListEntry `json:"entries"`
+}
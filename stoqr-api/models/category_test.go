@@ -0,0 +1,30 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func createFakeCategories() Categories {
+	home, cleaning := 1, 2
+	return Categories{
+		{ID: 1, Name: "Home"},
+		{ID: 2, Name: "Cleaning", ParentID: &home},
+		{ID: 3, Name: "Kitchen", ParentID: &cleaning},
+		{ID: 4, Name: "Garden"},
+	}
+}
+
+func TestCategoriesPath(t *testing.T) {
+	want := "Home / Cleaning / Kitchen"
+	if got := createFakeCategories().Path(3); got != want {
+		t.Errorf("wrong path: want %v, got %v", want, got)
+	}
+}
+
+func TestCategoriesDescendants(t *testing.T) {
+	want := []int{1, 2, 3}
+	if got := createFakeCategories().Descendants(1); !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong descendants: want %v, got %v", want, got)
+	}
+}
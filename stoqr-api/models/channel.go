@@ -0,0 +1,102 @@
+package models
+
+import (
+	"encoding/json"
+	"net/url"
+)
+
+// Kinds of notification channels
+const (
+	ChannelWebhook  = "webhook"
+	ChannelNtfy     = "ntfy"
+	ChannelTelegram = "telegram"
+	ChannelMQTT     = "mqtt"
+)
+
+// Payload formats of the chat webhooks
+const (
+	WebhookSlack   = "slack"
+	WebhookDiscord = "discord"
+)
+
+// Channel is a destination of notifications besides email. The URL is the webhook, the
+// base URL of the push service or the MQTT broker, the target is the ntfy topic, the
+// Telegram chat or the MQTT topic and the token authenticates with the push service.
+// The token is write-only and the URL is read without its secrets, see MarshalJSON.
+type Channel struct {
+	ID     int           `json:"id"`
+	Name   string        `json:"name"`
+	Kind   string        `json:"kind"`
+	URL    string        `json:"url"`
+	Format string        `json:"format,omitempty"`
+	Target string        `json:"target,omitempty"`
+	Token  string        `json:"token,omitempty"`
+	Rules  []ChannelRule `json:"rules"`
+}
+
+// MarshalJSON encodes the channel without its secrets: the token is replaced by whether
+// it is set and the URL by its public part
+func (channel Channel) MarshalJSON() ([]byte, error) {
+	type plain Channel
+	redacted := struct {
+		plain
+		HasToken bool `json:"hasToken"`
+	}{plain(channel), channel.Token != ""}
+	redacted.URL = channel.PublicURL()
+	redacted.Token = ""
+	return json.Marshal(redacted)
+}
+
+// PublicURL returns the URL of a channel without its credentials, query and fragment.
+// The path of a webhook is dropped as well since chat services keep the secret in it.
+func (channel *Channel) PublicURL() string {
+	if channel.URL == "" {
+		return ""
+	}
+	u, err := url.Parse(channel.URL)
+	if err != nil {
+		return ""
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	if channel.Kind == ChannelWebhook {
+		u.Path = ""
+		u.RawPath = ""
+	}
+	return u.String()
+}
+
+// ChannelRule routes to a channel the events of a type, the events of items in a
+// category (including its subcategories) or both, an empty rule routes every event
+type ChannelRule struct {
+	ID         int    `json:"-"`
+	ChannelID  int    `json:"-" gorm:"index"`
+	Event      string `json:"event,omitempty"`
+	CategoryID *int   `json:"categoryId,omitempty"`
+}
+
+// Routes returns true if a channel gets an event of an item in a category (nil if the
+// event has no item or the item no category). A channel without rules gets every event.
+func (channel *Channel) Routes(event string, categoryID *int, categories Categories) bool {
+	if len(channel.Rules) == 0 {
+		return true
+	}
+	for _, rule := range channel.Rules {
+		if rule.Event != "" && rule.Event != event {
+			continue
+		}
+		if rule.CategoryID == nil {
+			return true
+		}
+		if categoryID == nil {
+			continue
+		}
+		for _, id := range categories.Descendants(*rule.CategoryID) {
+			if id == *categoryID {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Missed run policies of a job, applied when a replica finds that scheduled runs were
+// missed (e.g. every replica was down)
+const (
+	MissedSkip    = "skip"
+	MissedRunOnce = "run_once"
+)
+
+// Job statuses of the last run
+const (
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobSkipped   = "skipped"
+)
+
+// Job is the state of a recurring background job shared by every replica, the replica
+// holding the lock until its expiration is the only one running the job
+type Job struct {
+	Name        string     `json:"name" gorm:"primaryKey"`
+	Schedule    string     `json:"schedule"`
+	Missed      string     `json:"missed"`
+	NextRunAt   time.Time  `json:"nextRunAt"`
+	LastRunAt   *time.Time `json:"lastRunAt"`
+	LastStatus  string     `json:"lastStatus,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+	Runs        int        `json:"runs"`
+	Failures    int        `json:"failures"`
+	LockedBy    string     `json:"lockedBy,omitempty"`
+	LockedUntil *time.Time `json:"lockedUntil,omitempty"`
+}
+
+// IsMissedPolicy returns true if the value is a known missed run policy
+func IsMissedPolicy(value string) bool {
+	return value == MissedSkip || value == MissedRunOnce
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Loan is a unit of a borrowable item checked out to a borrower until it's returned,
+// the unit is identified by its asset when the item is serialized
+type Loan struct {
+	ID           int        `json:"id"`
+	ItemID       int        `json:"itemId" gorm:"index"`
+	AssetID      *int       `json:"assetId,omitempty" gorm:"index"`
+	Borrower     string     `json:"borrower"`
+	CheckedOutAt time.Time  `json:"checkedOutAt"`
+	DueAt        time.Time  `json:"dueAt"`
+	ReturnedAt   *time.Time `json:"returnedAt"`
+}
+
+// IsOverdue returns true if the loan was not returned by its due date
+func (loan *Loan) IsOverdue(now time.Time) bool {
+	return loan.ReturnedAt == nil && loan.DueAt.Before(now)
+}
@@ -0,0 +1,7 @@
+package models
+
+// LogSettings are the level and format of the logs of the api
+type LogSettings struct {
+	Level  string `json:"level"`
+	Format string `json:"format"`
+}
@@ -1,9 +1,36 @@
 package models
 
+import "time"
+
 // Item is the model of the item object
 type Item struct {
-	ID      int    `json:"id"`
-	Name    string `json:"name"`
-	Desired int    `json:"desired"`
-	Actual  int    `json:"actual"`
+	ID          int          `json:"id"`
+	Name        string       `json:"name"`
+	Desired     Quantity     `json:"desired"`
+	Actual      Quantity     `json:"actual"`
+	Available   Quantity     `json:"available" gorm:"-"`
+	OperationID string       `json:"operationId,omitempty" gorm:"-"`
+	Stats       *ItemStats   `json:"stats,omitempty" gorm:"-"`
+	Unit        string       `json:"unit"`
+	CategoryID  *int         `json:"categoryId"`
+	Location    string       `json:"location"`
+	Borrowable  bool         `json:"borrowable"`
+	Serialized  bool         `json:"serialized"`
+	Tags        []Tag        `json:"tags,omitempty" gorm:"many2many:item_tags"`
+	Components  []Component  `json:"components,omitempty" gorm:"foreignKey:KitID"`
+	Conversions []Conversion `json:"conversions,omitempty"`
+	CreatedAt   time.Time    `json:"createdAt"`
+}
+
+// Component is an item (and its quantity) that is part of a kit
+type Component struct {
+	ID          int      `json:"-"`
+	KitID       int      `json:"-"`
+	ComponentID int      `json:"componentId"`
+	Quantity    Quantity `json:"quantity"`
+}
+
+// IsKit returns true if the item is assembled from other items
+func (item *Item) IsKit() bool {
+	return len(item.Components) > 0
 }
@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// Movement kinds
+const (
+	MovementReceipt    = "receipt"
+	MovementWithdrawal = "withdrawal"
+	MovementAdjustment = "adjustment"
+	MovementUndo       = "undo"
+)
+
+// Movement is a change in the stock of an item, the quantity of an adjustment is
+// positive when stock was found and negative when it was lost. An undo compensates a
+// movement of the operation it belongs to, its quantity is positive when it gives back
+// a withdrawal and negative when it takes back a receipt. The cost of a receipt, and of
+// the undo of a receipt, is the total in cents of the quantity received.
+type Movement struct {
+	ID          int       `json:"id"`
+	ItemID      int       `json:"itemId" gorm:"index"`
+	OperationID string    `json:"operationId,omitempty" gorm:"index"`
+	Kind        string    `json:"kind"`
+	Quantity    Quantity  `json:"quantity"`
+	Cost        int       `json:"cost"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"index"`
+}
+
+// Operation groups the movements of a withdrawal or a restock so they can be undone
+type Operation struct {
+	ID        string     `json:"id" gorm:"primaryKey"`
+	ItemID    int        `json:"itemId" gorm:"index"`
+	Kind      string     `json:"kind"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UndoneAt  *time.Time `json:"undoneAt"`
+}
+
+// Restock is the payload to receive stock of an item, the unit cost is expressed
+// in cents per unit of the restock (the unit of the item if empty)
+type Restock struct {
+	Quantity Quantity `json:"quantity"`
+	Unit     string   `json:"unit"`
+	UnitCost int      `json:"unitCost"`
+	// Cost is the total cost in cents of the restock, kept when its quantity is
+	// converted to the unit of the item
+	Cost int `json:"-"`
+}
+
+// TotalCost returns the cost in cents of the quantity of a restock at its unit cost
+func (restock Restock) TotalCost() int {
+	return int((int64(restock.UnitCost)*int64(restock.Quantity) + quantityFactor/2) / quantityFactor)
+}
+
+// Delta returns how much the movement changed the stock of its item
+func (movement *Movement) Delta() Quantity {
+	if movement.Kind == MovementWithdrawal {
+		return -movement.Quantity
+	}
+	return movement.Quantity
+}
+
+// Withdrawn returns how much the movement withdrew of its item, negative when it gives
+// back a withdrawal that was undone
+func (movement *Movement) Withdrawn() Quantity {
+	switch {
+	case movement.Kind == MovementWithdrawal:
+		return movement.Quantity
+	case movement.Kind == MovementUndo && movement.Quantity > 0:
+		return -movement.Quantity
+	}
+	return 0
+}
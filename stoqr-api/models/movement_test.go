@@ -0,0 +1,25 @@
+package models
+
+import "testing"
+
+func TestMovementDeltaAndWithdrawn(t *testing.T) {
+	cases := []struct {
+		movement  Movement
+		delta     Quantity
+		withdrawn Quantity
+	}{
+		{Movement{Kind: MovementReceipt, Quantity: NewQuantity(3)}, NewQuantity(3), 0},
+		{Movement{Kind: MovementWithdrawal, Quantity: NewQuantity(2)}, NewQuantity(-2), NewQuantity(2)},
+		{Movement{Kind: MovementAdjustment, Quantity: NewQuantity(-1)}, NewQuantity(-1), 0},
+		{Movement{Kind: MovementUndo, Quantity: NewQuantity(2)}, NewQuantity(2), NewQuantity(-2)},
+		{Movement{Kind: MovementUndo, Quantity: NewQuantity(-3)}, NewQuantity(-3), 0},
+	}
+	for _, c := range cases {
+		if delta := c.movement.Delta(); delta != c.delta {
+			t.Errorf("wrong delta of %s %s: want %v, got %v", c.movement.Kind, c.movement.Quantity, c.delta, delta)
+		}
+		if withdrawn := c.movement.Withdrawn(); withdrawn != c.withdrawn {
+			t.Errorf("wrong withdrawn of %s %s: want %v, got %v", c.movement.Kind, c.movement.Quantity, c.withdrawn, withdrawn)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// QuantityScale is the number of decimal places kept by a Quantity
+const QuantityScale = 3
+
+const quantityFactor = 1000
+
+// ErrInvalidQuantity is returned when a quantity cannot be parsed
+var ErrInvalidQuantity = errors.New("invalid quantity")
+
+// ErrInexactQuantity is returned when a quantity needs more decimal places than QuantityScale
+var ErrInexactQuantity = errors.New("quantity cannot be represented exactly")
+
+// Quantity is a fixed-precision decimal amount stored as an integer number of
+// thousandths, so arithmetic stays exact in every database
+type Quantity int64
+
+// NewQuantity returns a Quantity of whole units
+func NewQuantity(units int64) Quantity {
+	return Quantity(units * quantityFactor)
+}
+
+// ParseQuantity parses a decimal number such as "1.25"
+func ParseQuantity(value string) (Quantity, error) {
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "-")
+	digits := strings.TrimPrefix(strings.TrimPrefix(value, "-"), "+")
+	whole, fraction := digits, ""
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		whole, fraction = digits[:i], digits[i+1:]
+	}
+	if whole == "" && fraction == "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidQuantity, value)
+	}
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > QuantityScale {
+		return 0, fmt.Errorf("%w: %q", ErrInexactQuantity, value)
+	}
+	fraction += strings.Repeat("0", QuantityScale-len(fraction))
+	if whole == "" {
+		whole = "0"
+	}
+	for _, part := range []string{whole, fraction} {
+		if strings.Trim(part, "0123456789") != "" {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidQuantity, value)
+		}
+	}
+	n, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidQuantity, value)
+	}
+	if negative {
+		n = -n
+	}
+	return Quantity(n), nil
+}
+
+// QuantityFromRat converts a rational number into a Quantity if it can be represented exactly
+func QuantityFromRat(r *big.Rat) (Quantity, error) {
+	scaled := new(big.Rat).Mul(r, big.NewRat(quantityFactor, 1))
+	if !scaled.IsInt() || !scaled.Num().IsInt64() {
+		return 0, fmt.Errorf("%w: %s", ErrInexactQuantity, r.FloatString(QuantityScale+3))
+	}
+	return Quantity(scaled.Num().Int64()), nil
+}
+
+// Rat returns the quantity as a rational number
+func (q Quantity) Rat() *big.Rat {
+	return big.NewRat(int64(q), quantityFactor)
+}
+
+// IsWhole returns true if the quantity has no decimal part
+func (q Quantity) IsWhole() bool {
+	return q%quantityFactor == 0
+}
+
+// Units returns the number of whole units in the quantity
+func (q Quantity) Units() int64 {
+	return int64(q) / quantityFactor
+}
+
+// String formats the quantity without trailing zeros
+func (q Quantity) String() string {
+	sign := ""
+	n := int64(q)
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+	whole := n / quantityFactor
+	fraction := strings.TrimRight(fmt.Sprintf("%0*d", QuantityScale, n%quantityFactor), "0")
+	if fraction == "" {
+		return fmt.Sprintf("%s%d", sign, whole)
+	}
+	return fmt.Sprintf("%s%d.%s", sign, whole, fraction)
+}
+
+// MarshalJSON encodes the quantity as a JSON number
+func (q Quantity) MarshalJSON() ([]byte, error) {
+	return []byte(q.String()), nil
+}
+
+// UnmarshalJSON decodes the quantity from a JSON number or string
+func (q *Quantity) UnmarshalJSON(data []byte) error {
+	value, err := ParseQuantity(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*q = value
+	return nil
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseQuantity(t *testing.T) {
+	cases := []struct {
+		value string
+		want  Quantity
+		err   error
+	}{
+		{value: "1", want: 1000},
+		{value: "1.5", want: 1500},
+		{value: "0.125", want: 125},
+		{value: ".5", want: 500},
+		{value: "-2.25", want: -2250},
+		{value: "1.2500", want: 1250},
+		{value: "1.0001", err: ErrInexactQuantity},
+		{value: "abc", err: ErrInvalidQuantity},
+		{value: "1e3", err: ErrInvalidQuantity},
+		{value: "", err: ErrInvalidQuantity},
+	}
+
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			got, err := ParseQuantity(c.value)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("wrong error: want %v, got %v", c.err, err)
+			}
+			if got != c.want {
+				t.Errorf("wrong quantity: want %v, got %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestQuantityJSON(t *testing.T) {
+	item := Item{Desired: Quantity(2500), Actual: Quantity(-125)}
+	data, _ := json.Marshal(item)
+
+	decoded := Item{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Desired != item.Desired || decoded.Actual != item.Actual {
+		t.Errorf("wrong quantities: want %v/%v, got %v/%v", item.Desired, item.Actual, decoded.Desired, decoded.Actual)
+	}
+	if err := json.Unmarshal([]byte(`{"actual":"0.75"}`), &decoded); err != nil || decoded.Actual != 750 {
+		t.Errorf("wrong actual: want %v, got %v (%v)", 750, decoded.Actual, err)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	item := Item{Unit: "kg", Conversions: []Conversion{{From: "bag", To: "kg", Factor: NewQuantity(5)}}}
+
+	cases := []struct {
+		name     string
+		quantity Quantity
+		unit     string
+		want     Quantity
+		err      error
+	}{
+		{name: "same", quantity: 1500, unit: "kg", want: 1500},
+		{name: "empty", quantity: 1500, unit: "", want: 1500},
+		{name: "grams", quantity: NewQuantity(250), unit: "g", want: 250},
+		{name: "milligrams", quantity: NewQuantity(2000), unit: "mg", want: 2},
+		{name: "item", quantity: NewQuantity(2), unit: "bag", want: NewQuantity(10)},
+		{name: "inexact", quantity: 1, unit: "g", err: ErrInexactQuantity},
+		{name: "incompatible", quantity: 1, unit: "l", err: ErrIncompatibleUnits},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := item.Convert(c.quantity, c.unit)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("wrong error: want %v, got %v", c.err, err)
+			}
+			if got != c.want {
+				t.Errorf("wrong quantity: want %v, got %v", c.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// RateLimit is the token bucket of a client or an item shared by every replica, its row is
+// locked while a token is taken so that concurrent takes don't overwrite each other
+type RateLimit struct {
+	Name     string `gorm:"primaryKey"`
+	Tokens   float64
+	Refilled time.Time `gorm:"index"`
+}
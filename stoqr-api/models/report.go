@@ -0,0 +1,92 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// ItemValuation is the value of the stock of an item, expressed in cents
+type ItemValuation struct {
+	ItemID   int      `json:"itemId"`
+	Name     string   `json:"name"`
+	Quantity Quantity `json:"quantity"`
+	Value    int      `json:"value"`
+}
+
+// Valuation is the value of the whole inventory, expressed in cents
+type Valuation struct {
+	Method string          `json:"method"`
+	Items  []ItemValuation `json:"items"`
+	Total  int             `json:"total"`
+}
+
+// ItemConsumption is the cost of the units of an item withdrawn in a period, expressed in cents
+type ItemConsumption struct {
+	ItemID   int      `json:"itemId"`
+	Name     string   `json:"name"`
+	Quantity Quantity `json:"quantity"`
+	Cost     int      `json:"cost"`
+}
+
+// Consumption is the cost of the goods withdrawn in a period, expressed in cents
+type Consumption struct {
+	Method string            `json:"method"`
+	From   time.Time         `json:"from"`
+	To     time.Time         `json:"to"`
+	Items  []ItemConsumption `json:"items"`
+	Total  int               `json:"total"`
+}
+
+// ItemStats is the average daily consumption of an item over a window of days and, at
+// that pace, how many days its available stock lasts and the date it runs out
+type ItemStats struct {
+	Window           int        `json:"window"`
+	Consumed         Quantity   `json:"consumed"`
+	DailyConsumption Quantity   `json:"dailyConsumption"`
+	DaysRemaining    *float64   `json:"daysRemaining"`
+	RunOutDate       *time.Time `json:"runOutDate"`
+}
+
+// ItemStatsReport is the consumption stats of an item over several windows
+type ItemStatsReport struct {
+	ItemID    int         `json:"itemId"`
+	Name      string      `json:"name"`
+	Available Quantity    `json:"available"`
+	Stats     []ItemStats `json:"stats"`
+}
+
+// NewItemStats computes the stats of an item that had the consumed quantity withdrawn
+// in the last window days, the run-out date is unknown if nothing was consumed
+func NewItemStats(available Quantity, consumed Quantity, window int, now time.Time) ItemStats {
+	stats := ItemStats{Window: window, Consumed: consumed}
+	if window <= 0 || consumed <= 0 {
+		return stats
+	}
+	stats.DailyConsumption = (consumed*2 + Quantity(window)) / Quantity(2*window)
+	days := 0.0
+	if available > 0 {
+		days = math.Round(float64(available)*float64(window)/float64(consumed)*10) / 10
+	}
+	runOut := now.Add(time.Duration(days * float64(24*time.Hour)))
+	stats.DaysRemaining = &days
+	stats.RunOutDate = &runOut
+	return stats
+}
+
+// Forecast is the demand predicted for an item and the par level and order quantity
+// suggested to cover the lead time and review period at a service level
+type Forecast struct {
+	ItemID        int       `json:"itemId"`
+	Name          string    `json:"name"`
+	Method        string    `json:"method"`
+	LeadTime      int       `json:"leadTime"`
+	ReviewPeriod  int       `json:"reviewPeriod"`
+	ServiceLevel  float64   `json:"serviceLevel"`
+	Daily         []float64 `json:"daily"`
+	Available     Quantity  `json:"available"`
+	Desired       Quantity  `json:"desired"`
+	ParLevel      Quantity  `json:"parLevel"`
+	OrderQuantity Quantity  `json:"orderQuantity"`
+	Applied       bool      `json:"applied"`
+	Explanation   []string  `json:"explanation"`
+}
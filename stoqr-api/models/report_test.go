@@ -0,0 +1,29 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewItemStats(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	stats := NewItemStats(NewQuantity(6), NewQuantity(14), 7, now)
+
+	if want := NewQuantity(2); stats.DailyConsumption != want {
+		t.Errorf("wrong daily consumption: want %v, got %v", want, stats.DailyConsumption)
+	}
+	if stats.DaysRemaining == nil || *stats.DaysRemaining != 3 {
+		t.Errorf("wrong days remaining: want %v, got %v", 3, stats.DaysRemaining)
+	}
+	if want := now.AddDate(0, 0, 3); stats.RunOutDate == nil || !stats.RunOutDate.Equal(want) {
+		t.Errorf("wrong run-out date: want %v, got %v", want, stats.RunOutDate)
+	}
+}
+
+func TestNewItemStatsWithoutConsumption(t *testing.T) {
+	stats := NewItemStats(NewQuantity(6), 0, 30, time.Now())
+
+	if stats.DaysRemaining != nil || stats.RunOutDate != nil {
+		t.Errorf("wrong stats: want no run-out date, got %v", stats.RunOutDate)
+	}
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Reservation statuses
+const (
+	ReservationActive   = "active"
+	ReservationConsumed = "consumed"
+	ReservationReleased = "released"
+	ReservationExpired  = "expired"
+)
+
+// Reservation sets aside a quantity of an item for a holder until it expires,
+// the remaining quantity decreases as the reservation is withdrawn
+type Reservation struct {
+	ID        int        `json:"id"`
+	ItemID    int        `json:"itemId" gorm:"index"`
+	Quantity  Quantity   `json:"quantity"`
+	Remaining Quantity   `json:"remaining"`
+	Holder    string     `json:"holder"`
+	Status    string     `json:"status" gorm:"index"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
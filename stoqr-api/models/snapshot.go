@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Snapshot is the stock of an item at a point in time, taken periodically and
+// whenever the stock is set directly instead of through movements
+type Snapshot struct {
+	ID      int       `json:"-"`
+	ItemID  int       `json:"itemId" gorm:"index:idx_snapshots_item_taken_at"`
+	Actual  Quantity  `json:"actual"`
+	TakenAt time.Time `json:"takenAt" gorm:"index:idx_snapshots_item_taken_at"`
+}
+
+// StockPoint is the stock of an item at a point of a time series
+type StockPoint struct {
+	At     time.Time `json:"at"`
+	Actual Quantity  `json:"actual"`
+}
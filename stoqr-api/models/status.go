@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Statuses of the api
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+// Status is the status of the api, its build and the checks of its dependencies
+type Status struct {
+	Status    string            `json:"status"`
+	Version   string            `json:"version,omitempty"`
+	Commit    string            `json:"commit,omitempty"`
+	BuildDate string            `json:"buildDate,omitempty"`
+	GoVersion string            `json:"goVersion,omitempty"`
+	StartedAt *time.Time        `json:"startedAt,omitempty"`
+	Uptime    string            `json:"uptime,omitempty"`
+	Database  string            `json:"database,omitempty"`
+	Checks    map[string]string `json:"checks,omitempty"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Stocktake statuses
+const (
+	StocktakeOpen      = "open"
+	StocktakeCommitted = "committed"
+	StocktakeCancelled = "cancelled"
+)
+
+// Stocktake is a session where the items of a category and/or location are physically counted
+type Stocktake struct {
+	ID          int              `json:"id"`
+	CategoryID  *int             `json:"categoryId"`
+	Location    string           `json:"location"`
+	Status      string           `json:"status"`
+	CreatedAt   time.Time        `json:"createdAt"`
+	CommittedAt *time.Time       `json:"committedAt"`
+	Counts      []StocktakeCount `json:"counts"`
+	Lines       []StocktakeLine  `json:"lines,omitempty" gorm:"-"`
+}
+
+// StocktakeCount is the quantity of an item counted in a stocktake, the expected
+// quantity is the stock of the item when it was last counted and the variance is
+// recorded when the stocktake is committed
+type StocktakeCount struct {
+	ID          int       `json:"-"`
+	StocktakeID int       `json:"-" gorm:"index"`
+	ItemID      int       `json:"itemId"`
+	Counted     Quantity  `json:"counted"`
+	Expected    Quantity  `json:"expected"`
+	Variance    Quantity  `json:"variance"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// StocktakeLine compares the stock of an item in the scope of an open stocktake with its count
+type StocktakeLine struct {
+	ItemID   int       `json:"itemId"`
+	Name     string    `json:"name"`
+	Actual   Quantity  `json:"actual"`
+	Counted  *Quantity `json:"counted"`
+	Variance *Quantity `json:"variance"`
+}
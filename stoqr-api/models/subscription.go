@@ -0,0 +1,32 @@
+package models
+
+// Events that can be notified
+const (
+	EventLowStock = "low_stock"
+	EventDigest   = "digest"
+)
+
+// IsEvent returns true if the value is an event that can be notified
+func IsEvent(value string) bool {
+	return value == EventLowStock || value == EventDigest
+}
+
+// Subscription is the email address of a person and the notifications they want to get
+type Subscription struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Email    string `json:"email" gorm:"uniqueIndex"`
+	LowStock bool   `json:"lowStock"`
+	Digest   bool   `json:"digest"`
+}
+
+// Wants returns true if the subscription includes an event
+func (subscription *Subscription) Wants(event string) bool {
+	switch event {
+	case EventLowStock:
+		return subscription.LowStock
+	case EventDigest:
+		return subscription.Digest
+	}
+	return false
+}
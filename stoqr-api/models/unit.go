@@ -0,0 +1,91 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// UnitEach is the unit of items counted one by one, it's used when an item has no unit
+const UnitEach = "unit"
+
+// ErrIncompatibleUnits is returned when there is no conversion between two units
+var ErrIncompatibleUnits = errors.New("incompatible units")
+
+// Conversion defines that one From equals Factor To, an ItemID of zero
+// makes the conversion valid for every item
+type Conversion struct {
+	ID     int      `json:"-"`
+	ItemID int      `json:"-" gorm:"index"`
+	From   string   `json:"from"`
+	To     string   `json:"to"`
+	Factor Quantity `json:"factor"`
+}
+
+// Conversions contains the conversions that are valid for every item
+var Conversions = []Conversion{
+	{From: "kg", To: "g", Factor: NewQuantity(1000)},
+	{From: "g", To: "mg", Factor: NewQuantity(1000)},
+	{From: "l", To: "ml", Factor: NewQuantity(1000)},
+	{From: "l", To: "cl", Factor: NewQuantity(100)},
+	{From: "km", To: "m", Factor: NewQuantity(1000)},
+	{From: "m", To: "cm", Factor: NewQuantity(100)},
+	{From: "m", To: "mm", Factor: NewQuantity(1000)},
+	{From: "dozen", To: UnitEach, Factor: NewQuantity(12)},
+}
+
+// UnitOfMeasure returns the unit the stock of the item is expressed in
+func (item *Item) UnitOfMeasure() string {
+	if item.Unit == "" {
+		return UnitEach
+	}
+	return item.Unit
+}
+
+// Convert converts a quantity of an item expressed in a unit into the unit of the item,
+// an empty unit means the unit of the item
+func (item *Item) Convert(quantity Quantity, unit string) (Quantity, error) {
+	to := item.UnitOfMeasure()
+	if unit == "" || unit == to {
+		return quantity, nil
+	}
+	conversions := append(append([]Conversion{}, item.Conversions...), Conversions...)
+	factor := conversionFactor(unit, to, conversions)
+	if factor == nil {
+		return 0, fmt.Errorf("%w: %s to %s", ErrIncompatibleUnits, unit, to)
+	}
+	return QuantityFromRat(new(big.Rat).Mul(quantity.Rat(), factor))
+}
+
+// conversionFactor finds how many to are in one from walking the conversions in both directions
+func conversionFactor(from string, to string, conversions []Conversion) *big.Rat {
+	factors := map[string]*big.Rat{from: big.NewRat(1, 1)}
+	pending := []string{from}
+	for len(pending) > 0 {
+		unit := pending[0]
+		pending = pending[1:]
+		if unit == to {
+			return factors[unit]
+		}
+		for _, conversion := range conversions {
+			if conversion.Factor <= 0 {
+				continue
+			}
+			next, factor := "", (*big.Rat)(nil)
+			switch unit {
+			case conversion.From:
+				next, factor = conversion.To, conversion.Factor.Rat()
+			case conversion.To:
+				next, factor = conversion.From, new(big.Rat).Inv(conversion.Factor.Rat())
+			default:
+				continue
+			}
+			if _, ok := factors[next]; ok {
+				continue
+			}
+			factors[next] = new(big.Rat).Mul(factors[unit], factor)
+			pending = append(pending, next)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+)
+
+// Default base URLs of the public push services, a channel can point to another instance
+const (
+	DefaultNtfyURL     = "https://ntfy.sh"
+	DefaultTelegramURL = "https://api.telegram.org"
+)
+
+// ErrUnknownChannel is returned when creating a notifier for an unknown kind of channel
+var ErrUnknownChannel = errors.New("unknown channel")
+
+// errInvalidURL is returned instead of the error parsing the URL of a channel, which would
+// contain its secrets
+var errInvalidURL = errors.New("invalid channel url")
+
+// Notifier delivers a message to a channel
+type Notifier interface {
+	Notify(message Message) error
+}
+
+// WebhookNotifier posts messages to a chat incoming webhook, the payload is the Slack one
+// (also understood by Mattermost) or the Discord one
+type WebhookNotifier struct {
+	URL    string
+	Format string
+	Client *http.Client
+}
+
+// Notify posts a message to the webhook
+func (notifier *WebhookNotifier) Notify(message Message) error {
+	field := "text"
+	if notifier.Format == models.WebhookDiscord {
+		field = "content"
+	}
+	return postJSON(notifier.Client, notifier.URL, map[string]string{field: summary(message)})
+}
+
+// NtfyNotifier publishes messages to a topic of an ntfy server
+type NtfyNotifier struct {
+	URL    string
+	Topic  string
+	Token  string
+	Client *http.Client
+}
+
+// Notify publishes a message to the topic
+func (notifier *NtfyNotifier) Notify(message Message) error {
+	url := strings.TrimSuffix(notifier.URL, "/") + "/" + notifier.Topic
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(message.Text))
+	if err != nil {
+		return errInvalidURL
+	}
+	request.Header.Set("Title", message.Subject)
+	if notifier.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+notifier.Token)
+	}
+	return do(notifier.Client, request)
+}
+
+// TelegramNotifier sends messages to a chat through a Telegram bot
+type TelegramNotifier struct {
+	URL    string
+	Token  string
+	ChatID string
+	Client *http.Client
+}
+
+// Notify sends a message to the chat
+func (notifier *TelegramNotifier) Notify(message Message) error {
+	url := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimSuffix(notifier.URL, "/"), notifier.Token)
+	return postJSON(notifier.Client, url, map[string]string{"chat_id": notifier.ChatID, "text": summary(message)})
+}
+
+// NewNotifier creates the notifier of a channel, the base url of the push services
+// defaults to the public ones
+func NewNotifier(channel models.Channel, client *http.Client) (Notifier, error) {
+	switch channel.Kind {
+	case models.ChannelWebhook:
+		return &WebhookNotifier{URL: channel.URL, Format: channel.Format, Client: client}, nil
+	case models.ChannelNtfy:
+		return &NtfyNotifier{URL: orDefault(channel.URL, DefaultNtfyURL), Topic: channel.Target, Token: channel.Token, Client: client}, nil
+	case models.ChannelTelegram:
+		return &TelegramNotifier{URL: orDefault(channel.URL, DefaultTelegramURL), Token: channel.Token, ChatID: channel.Target, Client: client}, nil
+	case models.ChannelMQTT:
+		return &MQTTNotifier{URL: channel.URL, Topic: channel.Target, ClientID: fmt.Sprintf("stoqr-%d", channel.ID)}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownChannel, channel.Kind)
+}
+
+// summary joins the subject and the text of a message for chats
+func summary(message Message) string {
+	return strings.TrimSpace(message.Subject + "\n\n" + message.Text)
+}
+
+func orDefault(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func postJSON(client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return errInvalidURL
+	}
+	request.Header.Set("Content-Type", "application/json")
+	return do(client, request)
+}
+
+// do sends a request to a channel, its errors name the host only since the URL may contain
+// secrets like a bot token or the path of a webhook
+func do(client *http.Client, request *http.Request) error {
+	response, err := client.Do(request)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("notifying %s: %w", request.URL.Host, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("notifying %s: %s", request.URL.Host, response.Status)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+)
+
+// request is a request received by a local stand-in of a push service
+type request struct {
+	path   string
+	header http.Header
+	body   []byte
+}
+
+func newStandIn(t *testing.T) (*httptest.Server, chan request) {
+	requests := make(chan request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{path: r.URL.Path, header: r.Header, body: body}
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+var message = Message{Subject: "Milk is running low", Text: "Add 3 l to the next shopping."}
+
+func TestWebhookNotifier(t *testing.T) {
+	cases := []struct {
+		format string
+		field  string
+	}{
+		{models.WebhookSlack, "text"},
+		{models.WebhookDiscord, "content"},
+	}
+	for _, c := range cases {
+		t.Run(c.format, func(t *testing.T) {
+			server, requests := newStandIn(t)
+			notifier, err := NewNotifier(models.Channel{Kind: models.ChannelWebhook, URL: server.URL + "/hooks/kitchen", Format: c.format}, server.Client())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := notifier.Notify(message); err != nil {
+				t.Fatal(err)
+			}
+
+			received := <-requests
+			payload := map[string]string{}
+			json.Unmarshal(received.body, &payload)
+			if want := "Milk is running low\n\nAdd 3 l to the next shopping."; payload[c.field] != want {
+				t.Errorf("wrong %s: want %q, got %q", c.field, want, payload[c.field])
+			}
+		})
+	}
+}
+
+func TestNtfyNotifier(t *testing.T) {
+	server, requests := newStandIn(t)
+	notifier, err := NewNotifier(models.Channel{Kind: models.ChannelNtfy, URL: server.URL, Target: "pantry", Token: "tk"}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := notifier.Notify(message); err != nil {
+		t.Fatal(err)
+	}
+
+	received := <-requests
+	if received.path != "/pantry" {
+		t.Errorf("wrong topic: want %v, got %v", "/pantry", received.path)
+	}
+	if title := received.header.Get("Title"); title != message.Subject {
+		t.Errorf("wrong title: want %v, got %v", message.Subject, title)
+	}
+	if auth := received.header.Get("Authorization"); auth != "Bearer tk" {
+		t.Errorf("wrong authorization: want %v, got %v", "Bearer tk", auth)
+	}
+	if string(received.body) != message.Text {
+		t.Errorf("wrong body: want %v, got %v", message.Text, string(received.body))
+	}
+}
+
+func TestTelegramNotifier(t *testing.T) {
+	server, requests := newStandIn(t)
+	notifier, err := NewNotifier(models.Channel{Kind: models.ChannelTelegram, URL: server.URL, Target: "-1001", Token: "123:abc"}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := notifier.Notify(message); err != nil {
+		t.Fatal(err)
+	}
+
+	received := <-requests
+	if want := "/bot123:abc/sendMessage"; received.path != want {
+		t.Errorf("wrong path: want %v, got %v", want, received.path)
+	}
+	payload := map[string]string{}
+	json.Unmarshal(received.body, &payload)
+	if payload["chat_id"] != "-1001" {
+		t.Errorf("wrong chat: want %v, got %v", "-1001", payload["chat_id"])
+	}
+}
+
+func TestNotifierFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+	notifier, _ := NewNotifier(models.Channel{Kind: models.ChannelWebhook, URL: server.URL}, server.Client())
+
+	if err := notifier.Notify(message); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestNotifierErrorHidesSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	unreachable := server.URL
+	server.Close()
+	channels := []models.Channel{
+		{Kind: models.ChannelTelegram, URL: unreachable, Token: "123:secret", Target: "42"},
+		{Kind: models.ChannelWebhook, URL: unreachable + "/services/T00/B00/secret"},
+		{Kind: models.ChannelWebhook, URL: "http://user:secret@[::1"},
+	}
+	for _, channel := range channels {
+		notifier, _ := NewNotifier(channel, http.DefaultClient)
+
+		err := notifier.Notify(message)
+		if err == nil {
+			t.Fatalf("expected an error for %s", channel.Kind)
+		}
+		if strings.Contains(err.Error(), "secret") {
+			t.Errorf("secret in the error of %s: %v", channel.Kind, err)
+		}
+	}
+}
+
+func TestNewNotifierDefaults(t *testing.T) {
+	notifier, err := NewNotifier(models.Channel{Kind: models.ChannelNtfy, Target: "pantry"}, http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url := notifier.(*NtfyNotifier).URL; url != DefaultNtfyURL {
+		t.Errorf("wrong url: want %v, got %v", DefaultNtfyURL, url)
+	}
+	if _, err := NewNotifier(models.Channel{Kind: "sms"}, http.DefaultClient); err == nil {
+		t.Error("expected an error")
+	}
+}
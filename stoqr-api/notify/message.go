@@ -0,0 +1,58 @@
+// Package notify renders notifications from templates and delivers them by email
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Message is a notification with a plain text and an HTML body
+type Message struct {
+	To      []string `json:"to,omitempty"`
+	Subject string   `json:"subject"`
+	Text    string   `json:"text"`
+	HTML    string   `json:"html"`
+}
+
+// Bytes encodes a message as a multipart/alternative email sent by the given address
+func (message Message) Bytes(from string, date time.Time) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", message.Text},
+		{"text/html; charset=utf-8", message.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"8bit"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(crlf(part.content))); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	var email bytes.Buffer
+	fmt.Fprintf(&email, "From: %s\r\n", from)
+	fmt.Fprintf(&email, "To: %s\r\n", strings.Join(message.To, ", "))
+	fmt.Fprintf(&email, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&email, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(&email, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&email, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", parts.Boundary())
+	email.Write(body.Bytes())
+	return email.Bytes(), nil
+}
+
+// crlf normalizes the line endings of a body to the ones required by SMTP
+func crlf(content string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(content, "\r\n", "\n"), "\n", "\r\n")
+}
@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+)
+
+// fakeBroker is a local MQTT broker that answers a connection with a return code and
+// records the packets it receives
+type fakeBroker struct {
+	listener net.Listener
+	code     byte
+	packets  chan []byte
+}
+
+func newFakeBroker(t *testing.T, code byte) *fakeBroker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	broker := &fakeBroker{listener: listener, code: code, packets: make(chan []byte, 10)}
+	go broker.serve()
+	t.Cleanup(func() { listener.Close() })
+	return broker
+}
+
+func (broker *fakeBroker) serve() {
+	conn, err := broker.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		header, err := reader.ReadByte()
+		if err != nil {
+			return
+		}
+		length, multiplier := 0, 1
+		for {
+			digit, err := reader.ReadByte()
+			if err != nil {
+				return
+			}
+			length += int(digit&0x7f) * multiplier
+			multiplier *= 128
+			if digit&0x80 == 0 {
+				break
+			}
+		}
+		body := make([]byte, length)
+		if _, err := io.ReadFull(reader, body); err != nil {
+			return
+		}
+		broker.packets <- append([]byte{header}, body...)
+		if header == 0x10 {
+			conn.Write([]byte{0x20, 0x02, 0x00, broker.code})
+		}
+	}
+}
+
+func TestMQTTNotifier(t *testing.T) {
+	broker := newFakeBroker(t, 0)
+	notifier, err := NewNotifier(mqttChannel("mqtt://stoqr:secret@"+broker.listener.Addr().String(), "home/pantry"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := notifier.Notify(message); err != nil {
+		t.Fatal(err)
+	}
+
+	connect := <-broker.packets
+	if connect[0] != 0x10 || connect[8]&0xc0 != 0xc0 {
+		t.Errorf("wrong connect packet: % x", connect)
+	}
+	publish := <-broker.packets
+	if publish[0] != 0x30 {
+		t.Fatalf("wrong publish packet: % x", publish)
+	}
+	length := int(binary.BigEndian.Uint16(publish[1:3]))
+	if topic := string(publish[3 : 3+length]); topic != "home/pantry" {
+		t.Errorf("wrong topic: want %v, got %v", "home/pantry", topic)
+	}
+	if payload := string(publish[3+length:]); payload != summary(message) {
+		t.Errorf("wrong payload: want %q, got %q", summary(message), payload)
+	}
+	if disconnect := <-broker.packets; disconnect[0] != 0xe0 {
+		t.Errorf("wrong disconnect packet: % x", disconnect)
+	}
+}
+
+func TestMQTTNotifierRefused(t *testing.T) {
+	broker := newFakeBroker(t, 5)
+	notifier, _ := NewNotifier(mqttChannel("mqtt://"+broker.listener.Addr().String(), "home/pantry"), nil)
+
+	if err := notifier.Notify(message); !errors.Is(err, ErrMQTTRefused) {
+		t.Errorf("wrong error: want %v, got %v", ErrMQTTRefused, err)
+	}
+}
+
+func mqttChannel(url string, topic string) models.Channel {
+	return models.Channel{ID: 1, Kind: models.ChannelMQTT, URL: url, Target: topic}
+}
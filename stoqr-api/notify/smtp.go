@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// ErrNoRecipients is returned when sending a message without recipients
+var ErrNoRecipients = errors.New("no recipients")
+
+// Mailer delivers messages by email
+type Mailer interface {
+	Send(message Message) error
+}
+
+// SMTPMailer delivers messages through an SMTP server, authenticating when a username is set
+type SMTPMailer struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+}
+
+// Send delivers a message to its recipients
+func (mailer *SMTPMailer) Send(message Message) error {
+	if len(message.To) == 0 {
+		return ErrNoRecipients
+	}
+	email, err := message.Bytes(mailer.From, time.Now())
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if mailer.Username != "" {
+		host, _, err := net.SplitHostPort(mailer.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", mailer.Username, mailer.Password, host)
+	}
+	if err := smtp.SendMail(mailer.Addr, auth, mailer.From, message.To, email); err != nil {
+		return fmt.Errorf("sending %q: %w", message.Subject, err)
+	}
+	return nil
+}
+
+// NewSMTPMailer creates a mailer for an SMTP server
+func NewSMTPMailer(host string, port int, username string, password string, from string) *SMTPMailer {
+	return &SMTPMailer{
+		Addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+		Username: username,
+		Password: password,
+		From:     from,
+	}
+}
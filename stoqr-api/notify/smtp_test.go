@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeSMTP is a local SMTP server accepting every message, without TLS nor authentication
+type fakeSMTP struct {
+	listener net.Listener
+	messages chan fakeMessage
+}
+
+type fakeMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeSMTP{listener: listener, messages: make(chan fakeMessage, 10)}
+	go server.serve()
+	t.Cleanup(func() { listener.Close() })
+	return server
+}
+
+func (server *fakeSMTP) serve() {
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+		go server.handle(conn)
+	}
+}
+
+func (server *fakeSMTP) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 localhost fake SMTP")
+	message := fakeMessage{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			message.from = strings.Trim(strings.TrimSpace(line)[10:], "<>")
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			message.to = append(message.to, strings.Trim(strings.TrimSpace(line)[8:], "<>"))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			message.data = data.String()
+			server.messages <- message
+			message = fakeMessage{}
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	server := newFakeSMTP(t)
+	mailer := &SMTPMailer{Addr: server.listener.Addr().String(), From: "stoqr@example.com"}
+
+	err := mailer.Send(Message{To: []string{"ana@example.com"}, Subject: "Milk is running low", Text: "Buy milk", HTML: "<p>Buy milk</p>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := <-server.messages
+	if message.from != "stoqr@example.com" {
+		t.Errorf("wrong sender: want %v, got %v", "stoqr@example.com", message.from)
+	}
+	if len(message.to) != 1 || message.to[0] != "ana@example.com" {
+		t.Errorf("wrong recipients: want %v, got %v", []string{"ana@example.com"}, message.to)
+	}
+	for _, want := range []string{"Subject: Milk is running low", "multipart/alternative", "Buy milk", "<p>Buy milk</p>"} {
+		if !strings.Contains(message.data, want) {
+			t.Errorf("message doesn't contain %q:\n%s", want, message.data)
+		}
+	}
+}
+
+func TestSMTPMailerNoRecipients(t *testing.T) {
+	mailer := NewSMTPMailer("127.0.0.1", 25, "", "", "stoqr@example.com")
+
+	if err := mailer.Send(Message{Subject: "Milk is running low"}); err != ErrNoRecipients {
+		t.Errorf("wrong error: want %v, got %v", ErrNoRecipients, err)
+	}
+}
@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+)
+
+// Templates of the notifications, expiring lots have none since items have no lots
+const (
+	TemplateLowStock        = "low-stock"
+	TemplateDigest          = "digest"
+	TemplateRequestDecision = "request-decision"
+)
+
+// ErrUnknownTemplate is returned when rendering a template that doesn't exist
+var ErrUnknownTemplate = errors.New("unknown template")
+
+//go:embed templates
+var files embed.FS
+
+var functions = map[string]interface{}{
+	"quantity": func(q models.Quantity, item models.Item) string {
+		if unit := item.UnitOfMeasure(); unit != models.UnitEach {
+			return q.String() + " " + unit
+		}
+		return q.String()
+	},
+	"sub": func(a models.Quantity, b models.Quantity) models.Quantity {
+		return a - b
+	},
+	"date": func(t time.Time) string {
+		return t.Format("Monday, January 2")
+	},
+}
+
+// texts and pages are the parsed templates by name, each one parsed on its own so
+// that every template can define its subject
+var (
+	texts = map[string]*template.Template{}
+	pages = map[string]*htmltemplate.Template{}
+)
+
+func init() {
+	for _, name := range []string{TemplateLowStock, TemplateDigest, TemplateRequestDecision} {
+		texts[name] = template.Must(template.New(name + ".txt").Funcs(functions).ParseFS(files, "templates/"+name+".txt"))
+		pages[name] = htmltemplate.Must(htmltemplate.New(name + ".html").Funcs(functions).ParseFS(files, "templates/"+name+".html"))
+	}
+}
+
+// LowStock is the data of the notification sent when an item falls below its desired stock
+type LowStock struct {
+	Item models.Item
+}
+
+// Digest is the data of the daily digest of the shopping list
+type Digest struct {
+	Date   time.Time
+	Groups []models.ShoppingListGroup
+}
+
+// RequestDecision is the data of the notification sent when a withdrawal request is
+// approved or rejected
+type RequestDecision struct {
+	Request models.WithdrawalRequest
+	Item    models.Item
+}
+
+// Render renders the subject and bodies of a notification from its templates, the
+// subject is the "subject" template defined in the text template
+func Render(name string, data interface{}) (Message, error) {
+	text, page := texts[name], pages[name]
+	if text == nil || page == nil {
+		return Message{}, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+	var subject, body, html bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := text.Execute(&body, data); err != nil {
+		return Message{}, err
+	}
+	if err := page.Execute(&html, data); err != nil {
+		return Message{}, err
+	}
+	return Message{Subject: strings.TrimSpace(subject.String()), Text: body.String(), HTML: html.String()}, nil
+}
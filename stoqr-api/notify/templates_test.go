@@ -0,0 +1,85 @@
+package notify
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+)
+
+func TestRenderLowStock(t *testing.T) {
+	item := models.Item{Name: "Milk", Actual: models.NewQuantity(1), Desired: models.NewQuantity(4), Unit: "l", Location: "Fridge"}
+
+	message, err := Render(TemplateLowStock, LowStock{Item: item})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "Milk is running low"; message.Subject != want {
+		t.Errorf("wrong subject: want %v, got %v", want, message.Subject)
+	}
+	for _, want := range []string{"down to 1 l", "desired 4 l", "kept at Fridge", "Add 3 l"} {
+		if !strings.Contains(message.Text, want) {
+			t.Errorf("text doesn't contain %q:\n%s", want, message.Text)
+		}
+	}
+	if !strings.Contains(message.HTML, "<strong>3 l</strong>") {
+		t.Errorf("html doesn't contain the missing quantity:\n%s", message.HTML)
+	}
+}
+
+func TestRenderDigest(t *testing.T) {
+	digest := Digest{
+		Date: time.Date(2021, time.March, 1, 8, 0, 0, 0, time.UTC),
+		Groups: []models.ShoppingListGroup{{Name: "Food & Drinks", Entries: []models.ShoppingListEntry{
+			{Item: models.Item{Name: "Milk", Actual: models.NewQuantity(1)}, Missing: models.NewQuantity(3)},
+		}}},
+	}
+
+	message, err := Render(TemplateDigest, digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "Shopping list for Monday, March 1"; message.Subject != want {
+		t.Errorf("wrong subject: want %v, got %v", want, message.Subject)
+	}
+	if !strings.Contains(message.Text, "- Milk: 3") {
+		t.Errorf("text doesn't contain the entry:\n%s", message.Text)
+	}
+	if !strings.Contains(message.HTML, "Food &amp; Drinks") {
+		t.Errorf("html doesn't escape the group name:\n%s", message.HTML)
+	}
+}
+
+func TestRenderRequestDecision(t *testing.T) {
+	decision := RequestDecision{
+		Request: models.WithdrawalRequest{Quantity: models.NewQuantity(10), Requester: "Alice", Status: models.RequestRejected, Approver: "Bob", Comment: "Too <many>"},
+		Item:    models.Item{Name: "Gloves"},
+	}
+
+	message, err := Render(TemplateRequestDecision, decision)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "Withdrawal of Gloves rejected"; message.Subject != want {
+		t.Errorf("wrong subject: want %v, got %v", want, message.Subject)
+	}
+	for _, want := range []string{"10 of Gloves requested by Alice was rejected by Bob", "Comment: Too <many>"} {
+		if !strings.Contains(message.Text, want) {
+			t.Errorf("text doesn't contain %q:\n%s", want, message.Text)
+		}
+	}
+	if !strings.Contains(message.HTML, "Too &lt;many&gt;") {
+		t.Errorf("html doesn't escape the comment:\n%s", message.HTML)
+	}
+}
+
+func TestRenderUnknownTemplate(t *testing.T) {
+	if _, err := Render("expiring-lots", nil); !errors.Is(err, ErrUnknownTemplate) {
+		t.Errorf("wrong error: want %v, got %v", ErrUnknownTemplate, err)
+	}
+}
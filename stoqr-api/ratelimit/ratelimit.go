@@ -0,0 +1,184 @@
+// Package ratelimit limits the rate of requests with token buckets: a bucket holds up to the
+// number of requests of its limit, each request takes a token, and the tokens are refilled
+// continuously over the period of the limit. The buckets are kept in memory, or in the
+// database when several replicas must share them.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the idle buckets are removed from a memory store
+const sweepInterval = time.Minute
+
+// ErrInvalidLimit is returned when a limit can't be parsed
+var ErrInvalidLimit = errors.New("invalid rate limit")
+
+// ErrContention is returned by a store when a token couldn't be taken in time because other
+// replicas kept the bucket locked
+var ErrContention = errors.New("the rate limit is updated concurrently")
+
+// Limit is a number of requests allowed over a period, in bursts of up to that number
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit parses a limit formatted as requests/period, where the period is s, m, h or a
+// duration (e.g. 60/m or 10/30s). Off, 0 and an empty value disable the limit.
+func ParseLimit(value string) (Limit, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" || strings.EqualFold(value, "off") {
+		return Limit{}, nil
+	}
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 {
+		return Limit{}, fmt.Errorf("%w: %s", ErrInvalidLimit, value)
+	}
+	requests, err := strconv.Atoi(parts[0])
+	if err != nil || requests < 0 {
+		return Limit{}, fmt.Errorf("%w: %s", ErrInvalidLimit, value)
+	}
+	period, err := parsePeriod(parts[1])
+	if err != nil || period <= 0 {
+		return Limit{}, fmt.Errorf("%w: %s", ErrInvalidLimit, value)
+	}
+	return Limit{Requests: requests, Period: period}, nil
+}
+
+// String formats a limit as requests/period
+func (limit Limit) String() string {
+	if !limit.Enabled() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", limit.Requests, limit.Period)
+}
+
+// Enabled returns true if the limit allows a number of requests over a period
+func (limit Limit) Enabled() bool {
+	return limit.Requests > 0 && limit.Period > 0
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed bool
+	Limit   Limit
+	// Remaining is the number of whole tokens left in the bucket
+	Remaining int
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is how long until a token is available when the request isn't allowed
+	RetryAfter time.Duration
+}
+
+// Bucket is the state of a token bucket, a bucket never refilled is full
+type Bucket struct {
+	Tokens   float64
+	Refilled time.Time
+}
+
+// Take refills a bucket up to now and takes a token if there is one, returning the new
+// state of the bucket
+func (bucket Bucket) Take(limit Limit, now time.Time) (Bucket, Result) {
+	capacity := float64(limit.Requests)
+	rate := capacity / limit.Period.Seconds()
+	if bucket.Refilled.IsZero() {
+		bucket.Tokens = capacity
+	} else if elapsed := now.Sub(bucket.Refilled).Seconds(); elapsed > 0 {
+		bucket.Tokens = math.Min(capacity, bucket.Tokens+elapsed*rate)
+	}
+	bucket.Refilled = now
+	result := Result{Limit: limit}
+	if bucket.Tokens >= 1 {
+		bucket.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - bucket.Tokens) / rate)
+	}
+	result.Remaining = int(bucket.Tokens)
+	result.Reset = seconds((capacity - bucket.Tokens) / rate)
+	return bucket, result
+}
+
+// Idle returns true if a bucket refilled at a time is full at another
+func (bucket Bucket) Idle(limit Limit, now time.Time) bool {
+	return !now.Before(bucket.Refilled.Add(limit.Period))
+}
+
+// Store keeps the buckets of the keys being limited
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// MemoryStore keeps the buckets in memory, each replica limits the requests it receives
+type MemoryStore struct {
+	mutex   sync.Mutex
+	buckets map[string]*memoryBucket
+	swept   time.Time
+	now     func() time.Time
+}
+
+// memoryBucket is a bucket and the limit it was last taken with
+type memoryBucket struct {
+	Bucket
+	limit Limit
+}
+
+// NewMemoryStore creates an empty memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*memoryBucket{}, now: time.Now}
+}
+
+// Take takes a token from the bucket of a key
+func (store *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	now := store.now()
+	if now.Sub(store.swept) >= sweepInterval {
+		store.sweep(now)
+	}
+	bucket, ok := store.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{}
+		store.buckets[key] = bucket
+	}
+	var result Result
+	bucket.Bucket, result = bucket.Take(limit, now)
+	bucket.limit = limit
+	return result, nil
+}
+
+// sweep removes the buckets that are full again, they are the same as missing ones
+func (store *MemoryStore) sweep(now time.Time) {
+	for key, bucket := range store.buckets {
+		if bucket.Idle(bucket.limit, now) {
+			delete(store.buckets, key)
+		}
+	}
+	store.swept = now
+}
+
+// parsePeriod parses the period of a limit, a unit is one of it
+func parsePeriod(value string) (time.Duration, error) {
+	switch value {
+	case "s":
+		return time.Second, nil
+	case "m":
+		return time.Minute, nil
+	case "h":
+		return time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// seconds converts a number of seconds into a duration
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
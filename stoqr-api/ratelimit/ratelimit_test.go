@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		value string
+		limit Limit
+		err   bool
+	}{
+		{"60/m", Limit{Requests: 60, Period: time.Minute}, false},
+		{"10/30s", Limit{Requests: 10, Period: 30 * time.Second}, false},
+		{" 5/h ", Limit{Requests: 5, Period: time.Hour}, false},
+		{"off", Limit{}, false},
+		{"0", Limit{}, false},
+		{"60", Limit{}, true},
+		{"-1/m", Limit{}, true},
+		{"60/week", Limit{}, true},
+		{"60/0s", Limit{}, true},
+	}
+
+	for _, test := range tests {
+		limit, err := ParseLimit(test.value)
+		if (err != nil) != test.err {
+			t.Errorf("wrong error for %v: got %v", test.value, err)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidLimit) {
+			t.Errorf("wrong error for %v: want %v, got %v", test.value, ErrInvalidLimit, err)
+		}
+		if limit != test.limit {
+			t.Errorf("wrong limit for %v: want %v, got %v", test.value, test.limit, limit)
+		}
+	}
+}
+
+func TestBucketTake(t *testing.T) {
+	limit := Limit{Requests: 2, Period: 2 * time.Second}
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		elapsed    time.Duration
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+	}{
+		{0, true, 1, 0},
+		{0, true, 0, 0},
+		{0, false, 0, time.Second},
+		{500 * time.Millisecond, false, 0, 500 * time.Millisecond},
+		{time.Second, true, 0, 0},
+		{10 * time.Second, true, 1, 0},
+	}
+	bucket := Bucket{}
+	now := start
+	for i, step := range steps {
+		now = now.Add(step.elapsed)
+		var result Result
+		bucket, result = bucket.Take(limit, now)
+		if result.Allowed != step.allowed || result.Remaining != step.remaining || result.RetryAfter != step.retryAfter {
+			t.Errorf("wrong result of step %d: want %v %v %v, got %v %v %v", i, step.allowed, step.remaining, step.retryAfter,
+				result.Allowed, result.Remaining, result.RetryAfter)
+		}
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limit := Limit{Requests: 1, Period: time.Minute}
+
+	first, _ := store.Take(context.Background(), "ip:10.0.0.1", limit)
+	second, _ := store.Take(context.Background(), "ip:10.0.0.1", limit)
+	other, _ := store.Take(context.Background(), "ip:10.0.0.2", limit)
+
+	if !first.Allowed || second.Allowed || !other.Allowed {
+		t.Errorf("wrong results: want true false true, got %v %v %v", first.Allowed, second.Allowed, other.Allowed)
+	}
+	if second.Reset != time.Minute {
+		t.Errorf("wrong reset: want %v, got %v", time.Minute, second.Reset)
+	}
+
+	now = now.Add(2 * time.Minute)
+	store.Take(context.Background(), "ip:10.0.0.3", limit)
+	if len(store.buckets) != 1 {
+		t.Errorf("wrong buckets after sweeping: want %v, got %v", 1, len(store.buckets))
+	}
+}
@@ -0,0 +1,286 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"gorm.io/gorm"
+)
+
+// ErrApprovalRequired is returned when a withdrawal needs the approval of an approver
+var ErrApprovalRequired = errors.New("withdrawal requires approval")
+
+// ErrInvalidPolicy is returned when a policy cannot be used
+var ErrInvalidPolicy = errors.New("invalid policy")
+
+// ErrRequestClosed is returned when deciding on a request that was already decided
+var ErrRequestClosed = errors.New("request was already decided")
+
+// RequestFilter restricts the withdrawal requests read from a repository, zero values don't filter
+type RequestFilter struct {
+	Status    string
+	Requester string
+}
+
+// ApprovalRepository interface define the methods to persist approval policies and withdrawal requests
+type ApprovalRepository interface {
+	CreatePolicy(policy *models.Policy) error
+	ReadPolicy(id int) (models.Policy, error)
+	ReadPolicies() ([]models.Policy, error)
+	UpdatePolicy(id int, policy models.Policy) error
+	DeletePolicy(id int) error
+	CreateRequest(request *models.WithdrawalRequest) error
+	ReadRequest(id int) (models.WithdrawalRequest, error)
+	ReadRequests(filter RequestFilter) ([]models.WithdrawalRequest, error)
+	ApproveRequest(id int, decision models.Decision) (models.WithdrawalRequest, error)
+	RejectRequest(id int, decision models.Decision) (models.WithdrawalRequest, error)
+}
+
+// ApprovalRepositorySQL persist approval policies and withdrawal requests into a SQL database
+type ApprovalRepositorySQL struct {
+	*gorm.DB
+}
+
+// CreatePolicy persists a policy into a database
+func (db *ApprovalRepositorySQL) CreatePolicy(policy *models.Policy) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := checkPolicy(tx, 0, *policy); err != nil {
+			return err
+		}
+		policy.ID = 0
+		return tx.Create(policy).Error
+	})
+}
+
+// ReadPolicy gets a policy from a database
+func (db *ApprovalRepositorySQL) ReadPolicy(id int) (models.Policy, error) {
+	var policy models.Policy
+	result := db.First(&policy, id)
+	return policy, result.Error
+}
+
+// ReadPolicies gets every policy from a database
+func (db *ApprovalRepositorySQL) ReadPolicies() ([]models.Policy, error) {
+	policies := []models.Policy{}
+	result := db.Order("id").Find(&policies)
+	return policies, result.Error
+}
+
+// UpdatePolicy updates a policy and persists it into a database
+func (db *ApprovalRepositorySQL) UpdatePolicy(id int, updatedPolicy models.Policy) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var policy models.Policy
+		if err := tx.First(&policy, id).Error; err != nil {
+			return err
+		}
+		if err := checkPolicy(tx, id, updatedPolicy); err != nil {
+			return err
+		}
+		policy.ItemID = updatedPolicy.ItemID
+		policy.CategoryID = updatedPolicy.CategoryID
+		policy.MaxQuantity = updatedPolicy.MaxQuantity
+		policy.Restricted = updatedPolicy.Restricted
+		return tx.Save(&policy).Error
+	})
+}
+
+// DeletePolicy removes a policy from a database
+func (db *ApprovalRepositorySQL) DeletePolicy(id int) error {
+	result := db.Delete(&models.Policy{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CreateRequest persists a pending withdrawal request into a database
+func (db *ApprovalRepositorySQL) CreateRequest(request *models.WithdrawalRequest) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.Item{}, request.ItemID).Error; err != nil {
+			return err
+		}
+		request.ID = 0
+		request.Status = models.RequestPending
+		request.Approver = ""
+		request.Comment = ""
+		request.DecidedAt = nil
+		return tx.Create(request).Error
+	})
+}
+
+// ReadRequest gets a withdrawal request from a database
+func (db *ApprovalRepositorySQL) ReadRequest(id int) (models.WithdrawalRequest, error) {
+	var request models.WithdrawalRequest
+	result := db.First(&request, id)
+	return request, result.Error
+}
+
+// ReadRequests gets withdrawal requests from a database, the most recent first
+func (db *ApprovalRepositorySQL) ReadRequests(filter RequestFilter) ([]models.WithdrawalRequest, error) {
+	requests := []models.WithdrawalRequest{}
+	query := db.Order("created_at DESC, id DESC")
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Requester != "" {
+		query = query.Where("requester = ?", filter.Requester)
+	}
+	result := query.Find(&requests)
+	return requests, result.Error
+}
+
+// ApproveRequest withdraws the requested quantity and accepts the request, the request
+// stays pending if the withdrawal fails, e.g. with ErrInsufficientStock
+func (db *ApprovalRepositorySQL) ApproveRequest(id int, decision models.Decision) (models.WithdrawalRequest, error) {
+	return decideRequest(db.DB, id, decision, models.RequestApproved, func(tx *gorm.DB, request models.WithdrawalRequest) error {
+		item, err := lockItem(tx, request.ItemID)
+		if err != nil {
+			return err
+		}
+		return withdrawStock(tx, item, request.Quantity, "")
+	})
+}
+
+// RejectRequest rejects a request leaving the stock untouched
+func (db *ApprovalRepositorySQL) RejectRequest(id int, decision models.Decision) (models.WithdrawalRequest, error) {
+	return decideRequest(db.DB, id, decision, models.RequestRejected, nil)
+}
+
+// NewApprovalRepositorySQL returns a new ApprovalRepositorySQL instance
+func NewApprovalRepositorySQL(db *gorm.DB) ApprovalRepository {
+	return &ApprovalRepositorySQL{db}
+}
+
+func decideRequest(db *gorm.DB, id int, decision models.Decision, status string, apply func(*gorm.DB, models.WithdrawalRequest) error) (models.WithdrawalRequest, error) {
+	var request models.WithdrawalRequest
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&request, id).Error; err != nil {
+			return err
+		}
+		if request.Status != models.RequestPending {
+			return fmt.Errorf("%w: %d is %s", ErrRequestClosed, id, request.Status)
+		}
+		if apply != nil {
+			if err := apply(tx, request); err != nil {
+				return err
+			}
+		}
+		now := time.Now()
+		request.Status = status
+		request.Approver = decision.Approver
+		request.Comment = decision.Comment
+		request.DecidedAt = &now
+		result := tx.Model(&models.WithdrawalRequest{}).
+			Where("id = ? AND status = ?", id, models.RequestPending).
+			Updates(map[string]interface{}{
+				"status":     request.Status,
+				"approver":   request.Approver,
+				"comment":    request.Comment,
+				"decided_at": request.DecidedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: %d", ErrRequestClosed, id)
+		}
+		return nil
+	})
+	return request, err
+}
+
+// checkPolicy validates that a policy applies to either an existing item or an
+// existing category, and that no other policy applies to it
+func checkPolicy(tx *gorm.DB, id int, policy models.Policy) error {
+	if (policy.ItemID == nil) == (policy.CategoryID == nil) {
+		return fmt.Errorf("%w: it must apply to either an item or a category", ErrInvalidPolicy)
+	}
+	if policy.MaxQuantity != nil && *policy.MaxQuantity < 0 {
+		return fmt.Errorf("%w: maximum quantity cannot be negative", ErrInvalidPolicy)
+	}
+	if !policy.Restricted && policy.MaxQuantity == nil {
+		return fmt.Errorf("%w: it must be restricted or have a maximum quantity", ErrInvalidPolicy)
+	}
+	query := tx.Model(&models.Policy{}).Where("id <> ?", id)
+	if policy.ItemID != nil {
+		if err := tx.First(&models.Item{}, *policy.ItemID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: item %d does not exist", ErrInvalidPolicy, *policy.ItemID)
+			}
+			return err
+		}
+		query = query.Where("item_id = ?", *policy.ItemID)
+	} else {
+		if err := checkCategory(tx, policy.CategoryID); err != nil {
+			return err
+		}
+		query = query.Where("category_id = ?", *policy.CategoryID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: another policy applies to the same item or category", ErrInvalidPolicy)
+	}
+	return nil
+}
+
+// checkApproval fails with ErrApprovalRequired if the policy of an item requires an approval
+// to withdraw a quantity
+func checkApproval(tx *gorm.DB, item models.Item, quantity models.Quantity) error {
+	policy, err := approvalPolicy(tx, item)
+	if err != nil {
+		return err
+	}
+	if policy != nil && policy.Requires(quantity) {
+		return fmt.Errorf("%w: withdrawing %s of item %d", ErrApprovalRequired, quantity, item.ID)
+	}
+	return nil
+}
+
+// approvalPolicy returns the policy that applies to an item: its own policy, or the
+// policy of its nearest category (or parent category) that has one
+func approvalPolicy(tx *gorm.DB, item models.Item) (*models.Policy, error) {
+	policies := []models.Policy{}
+	if err := tx.Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	if len(policies) == 0 {
+		return nil, nil
+	}
+	byCategory := map[int]*models.Policy{}
+	for i := range policies {
+		policy := &policies[i]
+		if policy.ItemID != nil && *policy.ItemID == item.ID {
+			return policy, nil
+		}
+		if policy.CategoryID != nil {
+			byCategory[*policy.CategoryID] = policy
+		}
+	}
+	if item.CategoryID == nil || len(byCategory) == 0 {
+		return nil, nil
+	}
+	categories := models.Categories{}
+	if err := tx.Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	parents := map[int]*int{}
+	for _, category := range categories {
+		parents[category.ID] = category.ParentID
+	}
+	seen := map[int]bool{}
+	for id := item.CategoryID; id != nil && !seen[*id]; id = parents[*id] {
+		if policy, ok := byCategory[*id]; ok {
+			return policy, nil
+		}
+		seen[*id] = true
+	}
+	return nil, nil
+}
@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"gorm.io/gorm"
+)
+
+// ErrSerialized is returned when an operation is not supported on serialized items
+var ErrSerialized = errors.New("operation not supported on serialized items")
+
+// ErrNotSerialized is returned when adding units to an item that is not serialized
+var ErrNotSerialized = errors.New("item is not serialized")
+
+// ErrAssetStatus is returned when an asset cannot change to the requested status
+var ErrAssetStatus = errors.New("invalid asset status change")
+
+// AssetRepository interface define the methods to persist the units of serialized items
+type AssetRepository interface {
+	CreateAsset(asset *models.Asset) error
+	ReadAsset(serial string) (models.Asset, error)
+	ReadAssets(itemID int) ([]models.Asset, error)
+	UpdateAsset(serial string, change models.AssetEvent) (models.Asset, error)
+}
+
+// AssetRepositorySQL persist assets into a SQL database
+type AssetRepositorySQL struct {
+	*gorm.DB
+}
+
+// CreateAsset adds a unit to a serialized item, in stock unless another status is given
+func (db *AssetRepositorySQL) CreateAsset(asset *models.Asset) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var item models.Item
+		if err := tx.First(&item, asset.ItemID).Error; err != nil {
+			return err
+		}
+		if !item.Serialized {
+			return fmt.Errorf("%w: %d", ErrNotSerialized, item.ID)
+		}
+		status := asset.Status
+		if status == "" {
+			status = models.AssetInStock
+		}
+		if status == models.AssetLent || !models.IsAssetStatus(status) {
+			return fmt.Errorf("%w: a new unit can't be %s", ErrAssetStatus, status)
+		}
+		asset.ID = 0
+		asset.Events = nil
+		if err := tx.Create(asset).Error; err != nil {
+			return err
+		}
+		return changeAsset(tx, asset, models.AssetEvent{Status: status, Location: asset.Location, Note: "created"})
+	})
+}
+
+// ReadAsset gets an asset and its history from a database
+func (db *AssetRepositorySQL) ReadAsset(serial string) (models.Asset, error) {
+	var asset models.Asset
+	result := db.Preload("Events", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at, id")
+	}).Where("serial = ?", serial).First(&asset)
+	return asset, result.Error
+}
+
+// ReadAssets gets the assets of an item, or of every item if itemID is zero
+func (db *AssetRepositorySQL) ReadAssets(itemID int) ([]models.Asset, error) {
+	assets := []models.Asset{}
+	query := db.Order("serial")
+	if itemID != 0 {
+		query = query.Where("item_id = ?", itemID)
+	}
+	result := query.Find(&assets)
+	return assets, result.Error
+}
+
+// UpdateAsset changes the status and location of an asset, keeping the current ones
+// when empty. Lent units change through loans and retired units can't change.
+func (db *AssetRepositorySQL) UpdateAsset(serial string, change models.AssetEvent) (models.Asset, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var asset models.Asset
+		if err := tx.Where("serial = ?", serial).First(&asset).Error; err != nil {
+			return err
+		}
+		if change.Status == "" {
+			change.Status = asset.Status
+		}
+		if change.Location == "" {
+			change.Location = asset.Location
+		}
+		switch {
+		case !models.IsAssetStatus(change.Status):
+			return fmt.Errorf("%w: unknown status %s", ErrAssetStatus, change.Status)
+		case asset.Status == models.AssetRetired:
+			return fmt.Errorf("%w: %s is retired", ErrAssetStatus, serial)
+		case asset.Status == models.AssetLent:
+			return fmt.Errorf("%w: %s is lent, return its loan first", ErrAssetStatus, serial)
+		case change.Status == models.AssetLent:
+			return fmt.Errorf("%w: lend %s with a loan", ErrAssetStatus, serial)
+		}
+		return changeAsset(tx, &asset, change)
+	})
+	if err != nil {
+		return models.Asset{}, err
+	}
+	return db.ReadAsset(serial)
+}
+
+// NewAssetRepositorySQL returns a new AssetRepositorySQL instance
+func NewAssetRepositorySQL(db *gorm.DB) AssetRepository {
+	return &AssetRepositorySQL{db}
+}
+
+// changeAsset sets the status and location of an asset recording them in its
+// history, and derives the stock of its item from the units in stock
+func changeAsset(tx *gorm.DB, asset *models.Asset, change models.AssetEvent) error {
+	asset.Status = change.Status
+	asset.Location = change.Location
+	if err := tx.Save(asset).Error; err != nil {
+		return err
+	}
+	change.ID = 0
+	change.AssetID = asset.ID
+	if err := tx.Create(&change).Error; err != nil {
+		return err
+	}
+	return syncSerializedStock(tx, asset.ItemID)
+}
+
+// syncSerializedStock sets the stock of a serialized item to the number of its
+// units in stock, recording the difference as an adjustment
+func syncSerializedStock(tx *gorm.DB, id int) error {
+	var item models.Item
+	if err := tx.First(&item, id).Error; err != nil {
+		return err
+	}
+	var units int64
+	result := tx.Model(&models.Asset{}).Where("item_id = ? AND status = ?", id, models.AssetInStock).Count(&units)
+	if result.Error != nil {
+		return result.Error
+	}
+	difference := models.NewQuantity(units) - item.Actual
+	if difference == 0 {
+		return nil
+	}
+	result = tx.Model(&models.Item{}).Where("id = ?", id).UpdateColumn("actual", models.NewQuantity(units))
+	if result.Error != nil {
+		return result.Error
+	}
+	return createMovement(tx, id, models.MovementAdjustment, difference, 0, "")
+}
@@ -0,0 +1,185 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCategory is returned when a category cannot be used
+var ErrInvalidCategory = errors.New("invalid category")
+
+// ErrInvalidTag is returned when a tag cannot be used
+var ErrInvalidTag = errors.New("invalid tag")
+
+// CategoryRepository interface define the methods to persist categories and tags
+type CategoryRepository interface {
+	CreateCategory(category *models.Category) error
+	ReadCategory(id int) (models.Category, error)
+	ReadCategories() (models.Categories, error)
+	UpdateCategory(id int, category models.Category) error
+	DeleteCategory(id int) error
+	CreateTag(tag *models.Tag) error
+	ReadTags() ([]models.Tag, error)
+	UpdateTag(name string, tag models.Tag) error
+	DeleteTag(name string) error
+}
+
+// CategoryRepositorySQL persist categories and tags into a SQL database
+type CategoryRepositorySQL struct {
+	*gorm.DB
+}
+
+// CreateCategory persists a category into a database
+func (db *CategoryRepositorySQL) CreateCategory(category *models.Category) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := checkParent(tx, 0, category); err != nil {
+			return err
+		}
+		return tx.Create(category).Error
+	})
+}
+
+// ReadCategory gets a category from a database
+func (db *CategoryRepositorySQL) ReadCategory(id int) (models.Category, error) {
+	var category models.Category
+	result := db.First(&category, id)
+	return category, result.Error
+}
+
+// ReadCategories gets every category from a database
+func (db *CategoryRepositorySQL) ReadCategories() (models.Categories, error) {
+	categories := models.Categories{}
+	result := db.Order("name").Find(&categories)
+	return categories, result.Error
+}
+
+// UpdateCategory renames or moves a category
+func (db *CategoryRepositorySQL) UpdateCategory(id int, updatedCategory models.Category) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var category models.Category
+		if err := tx.First(&category, id).Error; err != nil {
+			return err
+		}
+		if err := checkParent(tx, id, &updatedCategory); err != nil {
+			return err
+		}
+		category.Name = updatedCategory.Name
+		category.ParentID = updatedCategory.ParentID
+		return tx.Save(&category).Error
+	})
+}
+
+// DeleteCategory removes a category and its policy, its subcategories, items and channel
+// rules move to its parent
+func (db *CategoryRepositorySQL) DeleteCategory(id int) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var category models.Category
+		if err := tx.First(&category, id).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&models.Category{}).Where("parent_id = ?", id).Update("parent_id", category.ParentID)
+		if result.Error != nil {
+			return result.Error
+		}
+		result = tx.Model(&models.Item{}).Where("category_id = ?", id).Update("category_id", category.ParentID)
+		if result.Error != nil {
+			return result.Error
+		}
+		result = tx.Model(&models.ChannelRule{}).Where("category_id = ?", id).Update("category_id", category.ParentID)
+		if result.Error != nil {
+			return result.Error
+		}
+		result = tx.Where("category_id = ?", id).Delete(&models.Policy{})
+		if result.Error != nil {
+			return result.Error
+		}
+		return tx.Delete(&category).Error
+	})
+}
+
+// CreateTag persists a tag into a database
+func (db *CategoryRepositorySQL) CreateTag(tag *models.Tag) error {
+	if tag.Name == "" {
+		return ErrInvalidTag
+	}
+	return db.Where(models.Tag{Name: tag.Name}).FirstOrCreate(tag).Error
+}
+
+// ReadTags gets every tag from a database
+func (db *CategoryRepositorySQL) ReadTags() ([]models.Tag, error) {
+	tags := []models.Tag{}
+	result := db.Order("name").Find(&tags)
+	return tags, result.Error
+}
+
+// UpdateTag renames a tag
+func (db *CategoryRepositorySQL) UpdateTag(name string, updatedTag models.Tag) error {
+	if updatedTag.Name == "" {
+		return ErrInvalidTag
+	}
+	var tag models.Tag
+	if err := db.Where("name = ?", name).First(&tag).Error; err != nil {
+		return err
+	}
+	var count int64
+	if err := db.Model(&models.Tag{}).Where("name = ? AND id <> ?", updatedTag.Name, tag.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %s already exists", ErrInvalidTag, updatedTag.Name)
+	}
+	tag.Name = updatedTag.Name
+	return db.Save(&tag).Error
+}
+
+// DeleteTag removes a tag from a database and from every item
+func (db *CategoryRepositorySQL) DeleteTag(name string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var tag models.Tag
+		if err := tx.Where("name = ?", name).First(&tag).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM item_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&tag).Error
+	})
+}
+
+// NewCategoryRepositorySQL returns a new CategoryRepositorySQL instance
+func NewCategoryRepositorySQL(db *gorm.DB) CategoryRepository {
+	return &CategoryRepositorySQL{db}
+}
+
+// checkParent verifies a category has a name and its parent exists and is not the category itself or one of its descendants
+func checkParent(tx *gorm.DB, id int, category *models.Category) error {
+	if category.Name == "" {
+		return fmt.Errorf("%w: name is empty", ErrInvalidCategory)
+	}
+	if category.ParentID == nil {
+		return nil
+	}
+	categories := models.Categories{}
+	if err := tx.Find(&categories).Error; err != nil {
+		return err
+	}
+	exists := false
+	for _, c := range categories {
+		exists = exists || c.ID == *category.ParentID
+	}
+	if !exists {
+		return fmt.Errorf("%w: parent %d does not exist", ErrInvalidCategory, *category.ParentID)
+	}
+	if id == 0 {
+		return nil
+	}
+	for _, descendant := range categories.Descendants(id) {
+		if descendant == *category.ParentID {
+			return fmt.Errorf("%w: parent %d is a descendant of %d", ErrInvalidCategory, *category.ParentID, id)
+		}
+	}
+	return nil
+}
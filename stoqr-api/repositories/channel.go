@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidChannel is returned when a notification channel or one of its rules cannot be used
+var ErrInvalidChannel = errors.New("invalid channel")
+
+// ChannelRepository interface define the methods to persist notification channels
+type ChannelRepository interface {
+	CreateChannel(channel *models.Channel) error
+	ReadChannel(id int) (models.Channel, error)
+	ReadChannels() ([]models.Channel, error)
+	UpdateChannel(id int, channel models.Channel) error
+	DeleteChannel(id int) error
+}
+
+// ChannelRepositorySQL persist notification channels into a SQL database
+type ChannelRepositorySQL struct {
+	*gorm.DB
+}
+
+// CreateChannel persists a channel and its rules into a database
+func (db *ChannelRepositorySQL) CreateChannel(channel *models.Channel) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := checkChannel(tx, channel); err != nil {
+			return err
+		}
+		rules := channel.Rules
+		channel.ID, channel.Rules = 0, nil
+		if err := tx.Create(channel).Error; err != nil {
+			return err
+		}
+		if err := replaceRules(tx, channel.ID, rules); err != nil {
+			return err
+		}
+		channel.Rules = rules
+		return nil
+	})
+}
+
+// ReadChannel gets a channel and its rules from a database
+func (db *ChannelRepositorySQL) ReadChannel(id int) (models.Channel, error) {
+	var channel models.Channel
+	result := db.Preload("Rules").First(&channel, id)
+	return channel, result.Error
+}
+
+// ReadChannels gets every channel and its rules from a database
+func (db *ChannelRepositorySQL) ReadChannels() ([]models.Channel, error) {
+	channels := []models.Channel{}
+	result := db.Preload("Rules").Order("name").Find(&channels)
+	return channels, result.Error
+}
+
+// UpdateChannel updates a channel and replaces its rules. Since the secrets of a channel
+// are not readable, an empty token or the public URL keep the current ones.
+func (db *ChannelRepositorySQL) UpdateChannel(id int, updatedChannel models.Channel) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var channel models.Channel
+		if err := tx.First(&channel, id).Error; err != nil {
+			return err
+		}
+		if updatedChannel.Token == "" && updatedChannel.Kind == channel.Kind {
+			updatedChannel.Token = channel.Token
+		}
+		if updatedChannel.URL == channel.PublicURL() && updatedChannel.Kind == channel.Kind {
+			updatedChannel.URL = channel.URL
+		}
+		if err := checkChannel(tx, &updatedChannel); err != nil {
+			return err
+		}
+		channel.Name = updatedChannel.Name
+		channel.Kind = updatedChannel.Kind
+		channel.URL = updatedChannel.URL
+		channel.Format = updatedChannel.Format
+		channel.Target = updatedChannel.Target
+		channel.Token = updatedChannel.Token
+		if err := tx.Omit("Rules").Save(&channel).Error; err != nil {
+			return err
+		}
+		return replaceRules(tx, id, updatedChannel.Rules)
+	})
+}
+
+// DeleteChannel removes a channel and its rules from a database
+func (db *ChannelRepositorySQL) DeleteChannel(id int) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("channel_id = ?", id).Delete(&models.ChannelRule{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Channel{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// NewChannelRepositorySQL creates a new channel repository
+func NewChannelRepositorySQL(db *gorm.DB) ChannelRepository {
+	return &ChannelRepositorySQL{db}
+}
+
+// checkChannel validates the settings each kind of channel needs and its rules
+func checkChannel(tx *gorm.DB, channel *models.Channel) error {
+	if channel.Name == "" {
+		return fmt.Errorf("%w: name is empty", ErrInvalidChannel)
+	}
+	if channel.URL != "" && channel.Kind != models.ChannelMQTT {
+		if u, err := url.Parse(channel.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: %q is not an http url", ErrInvalidChannel, channel.URL)
+		}
+	}
+	switch channel.Kind {
+	case models.ChannelWebhook:
+		if channel.URL == "" {
+			return fmt.Errorf("%w: a webhook needs a url", ErrInvalidChannel)
+		}
+		if channel.Format == "" {
+			channel.Format = models.WebhookSlack
+		}
+		if channel.Format != models.WebhookSlack && channel.Format != models.WebhookDiscord {
+			return fmt.Errorf("%w: unknown webhook format %s", ErrInvalidChannel, channel.Format)
+		}
+	case models.ChannelNtfy:
+		if channel.Target == "" {
+			return fmt.Errorf("%w: ntfy needs a topic", ErrInvalidChannel)
+		}
+	case models.ChannelTelegram:
+		if channel.Target == "" || channel.Token == "" {
+			return fmt.Errorf("%w: telegram needs a chat and a bot token", ErrInvalidChannel)
+		}
+	case models.ChannelMQTT:
+		if u, err := url.Parse(channel.URL); err != nil || u.Scheme != "mqtt" || u.Host == "" {
+			return fmt.Errorf("%w: %q is not an mqtt url", ErrInvalidChannel, channel.URL)
+		}
+		if channel.Target == "" {
+			return fmt.Errorf("%w: mqtt needs a topic", ErrInvalidChannel)
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidChannel, channel.Kind)
+	}
+	for _, rule := range channel.Rules {
+		if rule.Event != "" && !models.IsEvent(rule.Event) {
+			return fmt.Errorf("%w: unknown event %s", ErrInvalidChannel, rule.Event)
+		}
+		if err := checkCategory(tx, rule.CategoryID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func replaceRules(tx *gorm.DB, id int, rules []models.ChannelRule) error {
+	if err := tx.Where("channel_id = ?", id).Delete(&models.ChannelRule{}).Error; err != nil {
+		return err
+	}
+	for i := range rules {
+		rules[i].ID = 0
+		rules[i].ChannelID = id
+		if err := tx.Create(&rules[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
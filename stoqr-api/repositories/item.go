@@ -1,19 +1,67 @@
 package repositories
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/leandroberetta/stoqr/stoqr-api/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrInsufficientStock is returned when there is not enough stock to withdraw
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ErrInvalidComponent is returned when a kit component cannot be used
+var ErrInvalidComponent = errors.New("invalid component")
+
+// ErrKit is returned when an operation is not supported on kits
+var ErrKit = errors.New("operation not supported on kits")
+
+// ErrInvalidConversion is returned when a unit conversion cannot be used
+var ErrInvalidConversion = errors.New("invalid conversion")
+
+// ErrUndoExpired is returned when undoing an operation older than the undo window
+var ErrUndoExpired = errors.New("undo window has passed")
+
+// ErrUndoConflict is returned when an operation cannot be undone because of later changes
+var ErrUndoConflict = errors.New("operation cannot be undone")
+
+// ErrDuplicateName is returned when another item of the same category has the same name
+var ErrDuplicateName = errors.New("name already used")
+
+// ItemFilter restricts the items read from a repository, zero values don't filter.
+// A non zero AsOf reads the items as they were at that time.
+type ItemFilter struct {
+	Name       string
+	CategoryID int
+	Tag        string
+	Location   string
+	AsOf       time.Time
+}
+
 // ItemRepository interface define the methods to persist items
 type ItemRepository interface {
 	CreateItem(item *models.Item) error
 	ReadItem(id int) (models.Item, error)
+	ReadItemAt(id int, at time.Time) (models.Item, error)
 	UpdateItem(id int, item models.Item) error
 	DeleteItem(id int) error
-	ReadItems(filter string) ([]models.Item, error)
+	ReadItems(filter ItemFilter) ([]models.Item, error)
+	WithdrawItem(id int, quantity models.Quantity) (models.Item, error)
+	RestockItem(id int, restock models.Restock) (models.Item, error)
+	UndoOperation(id string, since time.Time) (models.Item, error)
+	ReadComponents(id int) ([]models.Component, error)
+	UpdateComponents(id int, components []models.Component) error
+	ReadConversions(id int) ([]models.Conversion, error)
+	UpdateConversions(id int, conversions []models.Conversion) error
+	UpdateTags(id int, tags []models.Tag) error
+	TakeSnapshot() (int64, error)
+	ReadStockHistory(id int, from time.Time, to time.Time, step time.Duration) ([]models.StockPoint, error)
 }
 
 // ItemRepositorySQL persist items into a SQL database
@@ -23,49 +71,681 @@ type ItemRepositorySQL struct {
 
 // CreateItem persists an item into a database
 func (db *ItemRepositorySQL) CreateItem(item *models.Item) error {
-	result := db.Create(item)
-	return result.Error
+	return db.Transaction(func(tx *gorm.DB) error {
+		components, conversions, tags := item.Components, item.Conversions, item.Tags
+		item.Components, item.Conversions, item.Tags = nil, nil, nil
+		if err := checkCategory(tx, item.CategoryID); err != nil {
+			return err
+		}
+		if err := checkName(tx, 0, item.Name, item.CategoryID); err != nil {
+			return err
+		}
+		if item.Serialized {
+			if len(components) > 0 {
+				return fmt.Errorf("%w: a kit can't be serialized", ErrKit)
+			}
+			item.Actual = 0
+		}
+		if err := tx.Create(item).Error; err != nil {
+			return err
+		}
+		if err := replaceConversions(tx, item.ID, conversions); err != nil {
+			return err
+		}
+		item.Conversions = conversions
+		if err := replaceTags(tx, item, tags); err != nil {
+			return err
+		}
+		if len(components) == 0 {
+			return snapshotStock(tx, item.ID, item.Actual)
+		}
+		if err := replaceComponents(tx, item.ID, components); err != nil {
+			return err
+		}
+		item.Components = components
+		return computeStock(tx, item, nil)
+	})
+}
+
+// InContext returns the repository running its statements in a context, so they are
+// traced as part of the request of the context
+func (db *ItemRepositorySQL) InContext(ctx context.Context) ItemRepository {
+	return &ItemRepositorySQL{db.DB.WithContext(ctx)}
 }
 
 // ReadItem gets an item from a database
 func (db *ItemRepositorySQL) ReadItem(id int) (models.Item, error) {
-	var item models.Item
-	result := db.First(&item, id)
-	return item, result.Error
+	return readItem(db.DB, id)
 }
 
-// UpdateItem updates an item and persists it into a database
+// UpdateItem updates an item and persists it into a database, the stock of a
+// serialized item is derived from its units in stock instead
 func (db *ItemRepositorySQL) UpdateItem(id int, updatedItem models.Item) error {
-	var item models.Item
-	result := db.Find(&item, id)
-	if result.Error != nil {
-		return result.Error
-	}
-	item.Name = updatedItem.Name
-	item.Desired = updatedItem.Desired
-	item.Actual = updatedItem.Actual
-	db.Save(&item)
-	return nil
+	return db.Transaction(func(tx *gorm.DB) error {
+		var item models.Item
+		if err := tx.Preload("Components").First(&item, id).Error; err != nil {
+			return err
+		}
+		if err := checkCategory(tx, updatedItem.CategoryID); err != nil {
+			return err
+		}
+		if err := checkName(tx, id, updatedItem.Name, updatedItem.CategoryID); err != nil {
+			return err
+		}
+		if updatedItem.Serialized && item.IsKit() {
+			return fmt.Errorf("%w: a kit can't be serialized", ErrKit)
+		}
+		item.Name = updatedItem.Name
+		item.Desired = updatedItem.Desired
+		changed := !updatedItem.Serialized && !item.IsKit() && item.Actual != updatedItem.Actual
+		if !updatedItem.Serialized {
+			item.Actual = updatedItem.Actual
+		}
+		item.Unit = updatedItem.Unit
+		item.CategoryID = updatedItem.CategoryID
+		item.Location = updatedItem.Location
+		item.Borrowable = updatedItem.Borrowable
+		item.Serialized = updatedItem.Serialized
+		if err := tx.Omit("Components").Save(&item).Error; err != nil {
+			return err
+		}
+		if changed {
+			return snapshotStock(tx, id, item.Actual)
+		}
+		if !item.Serialized {
+			return nil
+		}
+		return syncSerializedStock(tx, id)
+	})
 }
 
-// DeleteItem removes an item from a database
+// DeleteItem removes an item from a database along with its kit relations, movements,
+// conversions, tags, units, loans, policy, withdrawal requests, operations and snapshots
 func (db *ItemRepositorySQL) DeleteItem(id int) error {
-	result := db.Delete(&models.Item{}, id)
-	return result.Error
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("item_id = ?", id).Delete(&models.Snapshot{})
+		if result.Error != nil {
+			return result.Error
+		}
+		assets := tx.Model(&models.Asset{}).Select("id").Where("item_id = ?", id)
+		result = tx.Where("asset_id IN (?)", assets).Delete(&models.AssetEvent{})
+		if result.Error != nil {
+			return result.Error
+		}
+		result = tx.Where("item_id = ?", id).Delete(&models.Asset{})
+		if result.Error != nil {
+			return result.Error
+		}
+		result = tx.Where("item_id = ?", id).Delete(&models.Loan{})
+		if result.Error != nil {
+			return result.Error
+		}
+		result = tx.Where("item_id = ?", id).Delete(&models.Policy{})
+		if result.Error != nil {
+			return result.Error
+		}
+		result = tx.Where("item_id = ?", id).Delete(&models.WithdrawalRequest{})
+		if result.Error != nil {
+			return result.Error
+		}
+		result = tx.Where("item_id = ?", id).Delete(&models.Operation{})
+		if result.Error != nil {
+			return result.Error
+		}
+		result = tx.Where("kit_id = ? OR component_id = ?", id, id).Delete(&models.Component{})
+		if result.Error != nil {
+			return result.Error
+		}
+		result = tx.Where("item_id = ?", id).Delete(&models.Movement{})
+		if result.Error != nil {
+			return result.Error
+		}
+		result = tx.Where("item_id = ?", id).Delete(&models.Conversion{})
+		if result.Error != nil {
+			return result.Error
+		}
+		result = tx.Exec("DELETE FROM item_tags WHERE item_id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		return tx.Delete(&models.Item{}, id).Error
+	})
 }
 
-// ReadItems gets items from a database optionally filtering by name
-func (db *ItemRepositorySQL) ReadItems(filter string) ([]models.Item, error) {
+// ReadItems gets items from a database optionally filtering by name, category
+// (including its subcategories), tag and location. When reading as of a past time
+// the items created later are left out and their stock is reconstructed.
+func (db *ItemRepositorySQL) ReadItems(filter ItemFilter) ([]models.Item, error) {
 	var items []models.Item
-	if filter != "" {
-		db.Where("name LIKE ?", fmt.Sprintf("%%%s%%", filter)).Find(&items)
-	} else {
-		db.Find(&items)
+	query := db.Preload("Components").Preload("Conversions").Preload("Tags")
+	if filter.Name != "" {
+		query = query.Where("name LIKE ?", fmt.Sprintf("%%%s%%", filter.Name))
+	}
+	if filter.CategoryID != 0 {
+		categories := models.Categories{}
+		if result := db.Find(&categories); result.Error != nil {
+			return nil, result.Error
+		}
+		query = query.Where("category_id IN ?", categories.Descendants(filter.CategoryID))
+	}
+	if filter.Location != "" {
+		query = query.Where("location = ?", filter.Location)
+	}
+	if filter.Tag != "" {
+		tagged := db.Table("item_tags").
+			Select("item_tags.item_id").
+			Joins("JOIN tags ON tags.id = item_tags.tag_id").
+			Where("tags.name = ?", filter.Tag)
+		query = query.Where("id IN (?)", tagged)
+	}
+	if !filter.AsOf.IsZero() {
+		query = query.Where("(created_at IS NULL OR created_at <= ?)", filter.AsOf)
+	}
+	if result := query.Find(&items); result.Error != nil {
+		return nil, result.Error
+	}
+	if !filter.AsOf.IsZero() {
+		stock, err := stockAt(db.DB, 0, filter.AsOf)
+		if err != nil {
+			return nil, err
+		}
+		for i := range items {
+			setStockAt(&items[i], stock)
+		}
+		return items, nil
+	}
+	held, err := heldQuantities(db.DB, 0)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		if err := computeStock(db.DB, &items[i], held); err != nil {
+			return nil, err
+		}
 	}
 	return items, nil
 }
 
+// WithdrawItem takes a quantity of an item out of stock, failing with
+// ErrApprovalRequired if a policy requires an approval for the withdrawal.
+// The returned item carries the id of the operation to undo it, the operation
+// is only recorded once its movements are.
+func (db *ItemRepositorySQL) WithdrawItem(id int, quantity models.Quantity) (models.Item, error) {
+	var operation models.Operation
+	err := db.Transaction(func(tx *gorm.DB) error {
+		item, err := lockItem(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkWithdrawable(item); err != nil {
+			return err
+		}
+		if err := checkApproval(tx, item, quantity); err != nil {
+			return err
+		}
+		if quantity <= 0 {
+			return fmt.Errorf("%w: %s", models.ErrInvalidQuantity, quantity)
+		}
+		if operation, err = newOperation(id, models.MovementWithdrawal); err != nil {
+			return err
+		}
+		if err := withdrawStock(tx, item, quantity, operation.ID); err != nil {
+			return err
+		}
+		return tx.Create(&operation).Error
+	})
+	if err != nil {
+		return models.Item{}, err
+	}
+	return operationItem(db.DB, operation)
+}
+
+// RestockItem puts a quantity of an item into stock recording its unit cost,
+// the returned item carries the id of the operation to undo it
+func (db *ItemRepositorySQL) RestockItem(id int, restock models.Restock) (models.Item, error) {
+	var operation models.Operation
+	err := db.Transaction(func(tx *gorm.DB) error {
+		item, err := readItem(tx, id)
+		if err != nil {
+			return err
+		}
+		if item.IsKit() {
+			return fmt.Errorf("%w: restock the components of kit %d instead", ErrKit, id)
+		}
+		if item.Serialized {
+			return fmt.Errorf("%w: add units to item %d instead", ErrSerialized, id)
+		}
+		result := tx.Model(&models.Item{}).
+			Where("id = ?", id).
+			UpdateColumn("actual", gorm.Expr("actual + ?", restock.Quantity))
+		if result.Error != nil {
+			return result.Error
+		}
+		if operation, err = newOperation(id, models.MovementReceipt); err != nil {
+			return err
+		}
+		if err := createMovement(tx, id, models.MovementReceipt, restock.Quantity, restock.Cost, operation.ID); err != nil {
+			return err
+		}
+		return tx.Create(&operation).Error
+	})
+	if err != nil {
+		return models.Item{}, err
+	}
+	return operationItem(db.DB, operation)
+}
+
+// UndoOperation reverses a withdrawal or a restock created after since, recording an undo
+// movement compensating each of its movements so that the history of the stock is kept.
+// It fails with ErrUndoConflict if the stock of an item it changed was counted afterwards
+// or, for a restock, if the received stock is no longer available.
+func (db *ItemRepositorySQL) UndoOperation(id string, since time.Time) (models.Item, error) {
+	var operation models.Operation
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&operation, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if operation.UndoneAt != nil {
+			return fmt.Errorf("%w: %s was already undone", ErrUndoConflict, id)
+		}
+		if operation.CreatedAt.Before(since) {
+			return fmt.Errorf("%w: %s", ErrUndoExpired, id)
+		}
+		movements := []models.Movement{}
+		if err := tx.Where("operation_id = ? AND kind <> ?", id, models.MovementUndo).Find(&movements).Error; err != nil {
+			return err
+		}
+		held, err := heldQuantities(tx, 0)
+		if err != nil {
+			return err
+		}
+		for _, movement := range movements {
+			var counted int64
+			result := tx.Model(&models.Movement{}).
+				Where("item_id = ? AND id > ? AND kind = ?", movement.ItemID, movement.ID, models.MovementAdjustment).
+				Count(&counted)
+			if result.Error != nil {
+				return result.Error
+			}
+			if counted > 0 {
+				return fmt.Errorf("%w: the stock of item %d was adjusted afterwards", ErrUndoConflict, movement.ItemID)
+			}
+			query := tx.Model(&models.Item{}).Where("id = ?", movement.ItemID)
+			if movement.Kind == models.MovementReceipt {
+				result = query.Where("actual >= ?", movement.Quantity+held[movement.ItemID]).
+					UpdateColumn("actual", gorm.Expr("actual - ?", movement.Quantity))
+			} else {
+				result = query.UpdateColumn("actual", gorm.Expr("actual + ?", movement.Quantity))
+			}
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("%w: the stock received of item %d was already used", ErrUndoConflict, movement.ItemID)
+			}
+			if err := createMovement(tx, movement.ItemID, models.MovementUndo, -movement.Delta(), movement.Cost, id); err != nil {
+				return err
+			}
+		}
+		now := time.Now()
+		operation.UndoneAt = &now
+		return tx.Save(&operation).Error
+	})
+	if err != nil {
+		return models.Item{}, err
+	}
+	return readItem(db.DB, operation.ItemID)
+}
+
+// ReadComponents gets the components of a kit
+func (db *ItemRepositorySQL) ReadComponents(id int) ([]models.Component, error) {
+	components := []models.Component{}
+	result := db.Where("kit_id = ?", id).Order("id").Find(&components)
+	return components, result.Error
+}
+
+// UpdateComponents replaces the components of a kit, an empty list turns the kit into a plain item
+func (db *ItemRepositorySQL) UpdateComponents(id int, components []models.Component) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.Item{}, id).Error; err != nil {
+			return err
+		}
+		return replaceComponents(tx, id, components)
+	})
+}
+
+// ReadConversions gets the unit conversions defined for an item
+func (db *ItemRepositorySQL) ReadConversions(id int) ([]models.Conversion, error) {
+	conversions := []models.Conversion{}
+	result := db.Where("item_id = ?", id).Order("id").Find(&conversions)
+	return conversions, result.Error
+}
+
+// UpdateConversions replaces the unit conversions defined for an item
+func (db *ItemRepositorySQL) UpdateConversions(id int, conversions []models.Conversion) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.Item{}, id).Error; err != nil {
+			return err
+		}
+		return replaceConversions(tx, id, conversions)
+	})
+}
+
+// UpdateTags replaces the tags of an item, creating the tags that don't exist
+func (db *ItemRepositorySQL) UpdateTags(id int, tags []models.Tag) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var item models.Item
+		if err := tx.First(&item, id).Error; err != nil {
+			return err
+		}
+		return replaceTags(tx, &item, tags)
+	})
+}
+
 // NewItemRepositorySQL returns a new ItemRepositorySQL instance
 func NewItemRepositorySQL(db *gorm.DB) ItemRepository {
 	return &ItemRepositorySQL{db}
 }
+
+func readItem(db *gorm.DB, id int) (models.Item, error) {
+	var item models.Item
+	result := db.Preload("Components").Preload("Conversions").Preload("Tags").First(&item, id)
+	if result.Error != nil {
+		return item, result.Error
+	}
+	return item, computeStock(db, &item, nil)
+}
+
+// lockItem reads an item locking its row until the end of the transaction, so that the
+// stock checked can't be reserved or withdrawn concurrently
+func lockItem(tx *gorm.DB, id int) (models.Item, error) {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Item{}, id).Error; err != nil {
+		return models.Item{}, err
+	}
+	return readItem(tx, id)
+}
+
+// withdrawStock takes a quantity of an item out of stock, failing with
+// ErrInsufficientStock if the item, or any component of a kit, is short. Only the
+// available stock can be withdrawn, the stock reserved or on loan is held.
+func withdrawStock(tx *gorm.DB, item models.Item, quantity models.Quantity, operation string) error {
+	id := item.ID
+	held, err := heldQuantities(tx, 0)
+	if err != nil {
+		return err
+	}
+	if err := checkWithdrawable(item); err != nil {
+		return err
+	}
+	if !item.IsKit() {
+		if held[id] > 0 && quantity > item.Available {
+			return fmt.Errorf("%w: %s of item %d are reserved or on loan", ErrInsufficientStock, held[id], id)
+		}
+		if quantity > item.Actual {
+			return fmt.Errorf("%w: only %s of item %d is in stock", ErrInsufficientStock, item.Actual, id)
+		}
+		return decrementStock(tx, id, quantity, held[id], operation)
+	}
+	if !quantity.IsWhole() {
+		return fmt.Errorf("%w: kits are withdrawn in whole units", models.ErrInvalidQuantity)
+	}
+	for _, component := range item.Components {
+		needed := component.Quantity * models.Quantity(quantity.Units())
+		err := decrementStock(tx, component.ComponentID, needed, held[component.ComponentID], operation)
+		if errors.Is(err, ErrInsufficientStock) {
+			return fmt.Errorf("%w: component %d of kit %d", err, component.ComponentID, id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkWithdrawable fails if an item can't be withdrawn: the stock of a serialized item
+// comes from its units and a borrowable item is checked out and returned
+func checkWithdrawable(item models.Item) error {
+	if item.Serialized {
+		return fmt.Errorf("%w: retire the units of item %d instead", ErrSerialized, item.ID)
+	}
+	if item.Borrowable {
+		return fmt.Errorf("%w: check out item %d instead", ErrBorrowable, item.ID)
+	}
+	return nil
+}
+
+// decrementStock takes a quantity out of the stock of an item, without touching
+// the held stock, recording the withdrawal as part of an operation if not empty
+func decrementStock(tx *gorm.DB, id int, quantity models.Quantity, held models.Quantity, operation string) error {
+	result := tx.Model(&models.Item{}).
+		Where("id = ? AND actual >= ?", id, quantity+held).
+		UpdateColumn("actual", gorm.Expr("actual - ?", quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInsufficientStock
+	}
+	return createMovement(tx, id, models.MovementWithdrawal, quantity, 0, operation)
+}
+
+func createMovement(tx *gorm.DB, id int, kind string, quantity models.Quantity, cost int, operation string) error {
+	movement := models.Movement{ItemID: id, OperationID: operation, Kind: kind, Quantity: quantity, Cost: cost}
+	return tx.Create(&movement).Error
+}
+
+// newOperation returns an operation of an item with a random id, to record along with
+// its movements
+func newOperation(id int, kind string) (models.Operation, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return models.Operation{}, err
+	}
+	return models.Operation{ID: hex.EncodeToString(random), ItemID: id, Kind: kind}, nil
+}
+
+// operationItem reads the item of an operation setting the operation id
+func operationItem(db *gorm.DB, operation models.Operation) (models.Item, error) {
+	item, err := readItem(db, operation.ItemID)
+	item.OperationID = operation.ID
+	return item, err
+}
+
+func replaceComponents(tx *gorm.DB, id int, components []models.Component) error {
+	if err := tx.Where("kit_id = ?", id).Delete(&models.Component{}).Error; err != nil {
+		return err
+	}
+	if len(components) == 0 {
+		return nil
+	}
+	var parents int64
+	if err := tx.Model(&models.Component{}).Where("component_id = ?", id).Count(&parents).Error; err != nil {
+		return err
+	}
+	if parents > 0 {
+		return fmt.Errorf("%w: %d is a component of another kit", ErrInvalidComponent, id)
+	}
+	var kit models.Item
+	if err := tx.First(&kit, id).Error; err != nil {
+		return err
+	}
+	if kit.Serialized {
+		return fmt.Errorf("%w: a serialized item can't be a kit", ErrKit)
+	}
+	seen := map[int]bool{}
+	for i := range components {
+		component := &components[i]
+		if component.ComponentID == id || component.Quantity <= 0 || seen[component.ComponentID] {
+			return fmt.Errorf("%w: %d", ErrInvalidComponent, component.ComponentID)
+		}
+		seen[component.ComponentID] = true
+		var count int64
+		if err := tx.Model(&models.Component{}).Where("kit_id = ?", component.ComponentID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: %d is a kit", ErrInvalidComponent, component.ComponentID)
+		}
+		var part models.Item
+		if err := tx.First(&part, component.ComponentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: %d does not exist", ErrInvalidComponent, component.ComponentID)
+			}
+			return err
+		}
+		if part.Serialized {
+			return fmt.Errorf("%w: %d is serialized", ErrInvalidComponent, component.ComponentID)
+		}
+		component.ID = 0
+		component.KitID = id
+		if err := tx.Create(component).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func replaceConversions(tx *gorm.DB, id int, conversions []models.Conversion) error {
+	if err := tx.Where("item_id = ?", id).Delete(&models.Conversion{}).Error; err != nil {
+		return err
+	}
+	for i := range conversions {
+		conversion := &conversions[i]
+		if conversion.From == "" || conversion.To == "" || conversion.From == conversion.To || conversion.Factor <= 0 {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidConversion, conversion.From, conversion.To)
+		}
+		conversion.ID = 0
+		conversion.ItemID = id
+		if err := tx.Create(conversion).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func replaceTags(tx *gorm.DB, item *models.Item, tags []models.Tag) error {
+	resolved := []models.Tag{}
+	for _, tag := range tags {
+		if tag.Name == "" {
+			return ErrInvalidTag
+		}
+		tag.ID = 0
+		if err := tx.Where(models.Tag{Name: tag.Name}).FirstOrCreate(&tag).Error; err != nil {
+			return err
+		}
+		resolved = append(resolved, tag)
+	}
+	if err := tx.Model(item).Association("Tags").Replace(resolved); err != nil {
+		return err
+	}
+	item.Tags = resolved
+	return nil
+}
+
+func checkCategory(tx *gorm.DB, id *int) error {
+	if id == nil {
+		return nil
+	}
+	err := tx.First(&models.Category{}, *id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: %d does not exist", ErrInvalidCategory, *id)
+	}
+	return err
+}
+
+// checkName checks that no other item of a category (or without a category) has a name,
+// ignoring case
+func checkName(tx *gorm.DB, id int, name string, categoryID *int) error {
+	query := tx.Model(&models.Item{}).Where("LOWER(name) = LOWER(?) AND id <> ?", name, id)
+	if categoryID == nil {
+		query = query.Where("category_id IS NULL")
+	} else {
+		query = query.Where("category_id = ?", *categoryID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %s", ErrDuplicateName, name)
+	}
+	return nil
+}
+
+// heldQuantities returns the quantity of each item held by active reservations,
+// leaving out the reservation with the given id, and by open loans. Loans of
+// serialized units aren't held since lent units are already out of stock.
+func heldQuantities(db *gorm.DB, except int) (map[int]models.Quantity, error) {
+	var rows []struct {
+		ItemID int
+		Held   models.Quantity
+	}
+	result := activeReservations(db.Model(&models.Reservation{}), time.Now()).
+		Select("item_id, SUM(remaining) AS held").
+		Where("id <> ?", except).
+		Group("item_id").
+		Scan(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	held := map[int]models.Quantity{}
+	for _, row := range rows {
+		held[row.ItemID] += row.Held
+	}
+	rows = nil
+	result = db.Model(&models.Loan{}).
+		Select("item_id, COUNT(*) AS held").
+		Where("returned_at IS NULL AND asset_id IS NULL").
+		Group("item_id").
+		Scan(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	for _, row := range rows {
+		held[row.ItemID] += models.NewQuantity(int64(row.Held))
+	}
+	return held, nil
+}
+
+// activeReservations restricts a query to the reservations that are active and not expired
+func activeReservations(query *gorm.DB, now time.Time) *gorm.DB {
+	return query.Where("status = ? AND (expires_at IS NULL OR expires_at > ?)", models.ReservationActive, now)
+}
+
+// computeStock sets the available stock of an item, the actual and available
+// stock of a kit are the number of kits that can be assembled from its components.
+// The held quantities are read from the database if nil.
+func computeStock(db *gorm.DB, item *models.Item, held map[int]models.Quantity) error {
+	if held == nil {
+		var err error
+		if held, err = heldQuantities(db, 0); err != nil {
+			return err
+		}
+	}
+	if !item.IsKit() {
+		item.Available = item.Actual - held[item.ID]
+		if item.Available < 0 {
+			item.Available = 0
+		}
+		return nil
+	}
+	actual, available := int64(-1), int64(-1)
+	for _, component := range item.Components {
+		var part models.Item
+		if err := db.First(&part, component.ComponentID).Error; err != nil {
+			return err
+		}
+		if kits := int64(part.Actual / component.Quantity); actual < 0 || kits < actual {
+			actual = kits
+		}
+		if kits := int64((part.Actual - held[part.ID]) / component.Quantity); available < 0 || kits < available {
+			available = kits
+		}
+	}
+	if available < 0 {
+		available = 0
+	}
+	item.Actual = models.NewQuantity(actual)
+	item.Available = models.NewQuantity(available)
+	return nil
+}
@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobRepository interface define the methods to persist the state of background jobs
+type JobRepository interface {
+	RegisterJob(job *models.Job) error
+	ReadJob(name string) (models.Job, error)
+	ReadJobs() ([]models.Job, error)
+	AcquireJob(name string, owner string, now time.Time, until time.Time) (bool, error)
+	CompleteJob(name string, owner string, run models.Job) error
+	TriggerJob(name string) error
+}
+
+// JobRepositorySQL persist the state of background jobs into a SQL database
+type JobRepositorySQL struct {
+	*gorm.DB
+}
+
+// RegisterJob creates a job if it doesn't exist yet, or updates its schedule and next run
+// when the schedule or the missed run policy changed. The job is set to the stored state.
+func (db *JobRepositorySQL) RegisterJob(job *models.Job) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Job{
+			Name:      job.Name,
+			Schedule:  job.Schedule,
+			Missed:    job.Missed,
+			NextRunAt: job.NextRunAt,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		var stored models.Job
+		if err := tx.First(&stored, "name = ?", job.Name).Error; err != nil {
+			return err
+		}
+		if stored.Schedule != job.Schedule || stored.Missed != job.Missed {
+			result = tx.Model(&stored).Updates(map[string]interface{}{
+				"schedule":    job.Schedule,
+				"missed":      job.Missed,
+				"next_run_at": job.NextRunAt,
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			stored.Schedule, stored.Missed, stored.NextRunAt = job.Schedule, job.Missed, job.NextRunAt
+		}
+		*job = stored
+		return nil
+	})
+}
+
+// ReadJob gets a job from a database
+func (db *JobRepositorySQL) ReadJob(name string) (models.Job, error) {
+	var job models.Job
+	result := db.First(&job, "name = ?", name)
+	return job, result.Error
+}
+
+// ReadJobs gets every job from a database
+func (db *JobRepositorySQL) ReadJobs() ([]models.Job, error) {
+	jobs := []models.Job{}
+	result := db.Order("name").Find(&jobs)
+	return jobs, result.Error
+}
+
+// AcquireJob locks a job for an owner until the given time if it is due and nobody else
+// holds the lock, the single conditional update makes it safe between replicas
+func (db *JobRepositorySQL) AcquireJob(name string, owner string, now time.Time, until time.Time) (bool, error) {
+	result := db.Model(&models.Job{}).
+		Where("name = ? AND next_run_at <= ?", name, now).
+		Where("(locked_until IS NULL OR locked_until < ? OR locked_by = ?)", now, owner).
+		Updates(map[string]interface{}{"locked_by": owner, "locked_until": until})
+	return result.RowsAffected == 1, result.Error
+}
+
+// CompleteJob records the outcome of a run of a job and its next run, and releases the
+// lock. Nothing is recorded if the owner lost the lock in the meantime.
+func (db *JobRepositorySQL) CompleteJob(name string, owner string, run models.Job) error {
+	updates := map[string]interface{}{
+		"next_run_at":  run.NextRunAt,
+		"last_run_at":  run.LastRunAt,
+		"last_status":  run.LastStatus,
+		"last_error":   run.LastError,
+		"locked_by":    "",
+		"locked_until": nil,
+	}
+	switch run.LastStatus {
+	case models.JobSucceeded:
+		updates["runs"] = gorm.Expr("runs + 1")
+	case models.JobFailed:
+		updates["runs"] = gorm.Expr("runs + 1")
+		updates["failures"] = gorm.Expr("failures + 1")
+	}
+	result := db.Model(&models.Job{}).Where("name = ? AND locked_by = ?", name, owner).Updates(updates)
+	return result.Error
+}
+
+// TriggerJob makes a job due now
+func (db *JobRepositorySQL) TriggerJob(name string) error {
+	result := db.Model(&models.Job{}).Where("name = ?", name).Update("next_run_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// NewJobRepositorySQL creates a new job repository
+func NewJobRepositorySQL(db *gorm.DB) JobRepository {
+	return &JobRepositorySQL{db}
+}
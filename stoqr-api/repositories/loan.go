@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"gorm.io/gorm"
+)
+
+// ErrNotBorrowable is returned when checking out an item that is not borrowable
+var ErrNotBorrowable = errors.New("item is not borrowable")
+
+// ErrBorrowable is returned when withdrawing a borrowable item, it is checked out instead
+var ErrBorrowable = errors.New("item is borrowable")
+
+// ErrLoanClosed is returned when returning a loan that was already returned
+var ErrLoanClosed = errors.New("loan was already returned")
+
+// LoanFilter restricts the loans read from a repository, zero values don't filter
+type LoanFilter struct {
+	ItemID   int
+	Borrower string
+	Open     bool
+	Overdue  bool
+}
+
+// LoanRepository interface define the methods to persist loans
+type LoanRepository interface {
+	CheckOut(loan *models.Loan) error
+	CheckIn(id int) (models.Loan, error)
+	ReadLoan(id int) (models.Loan, error)
+	ReadLoans(filter LoanFilter) ([]models.Loan, error)
+}
+
+// LoanRepositorySQL persist loans into a SQL database
+type LoanRepositorySQL struct {
+	*gorm.DB
+}
+
+// CheckOut lends an available unit of a borrowable item, the unit of a serialized
+// item must be given and in stock. The item is locked so that concurrent check-outs can't
+// lend more units than available, and a unit is only lent if it is still in stock.
+func (db *LoanRepositorySQL) CheckOut(loan *models.Loan) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		item, err := lockItem(tx, loan.ItemID)
+		if err != nil {
+			return err
+		}
+		if !item.Borrowable {
+			return fmt.Errorf("%w: %d", ErrNotBorrowable, item.ID)
+		}
+		if item.IsKit() {
+			return fmt.Errorf("%w: kits can't be lent, lend its components", ErrKit)
+		}
+		if item.Serialized {
+			if loan.AssetID == nil {
+				return fmt.Errorf("%w: choose the unit of item %d to lend", ErrSerialized, item.ID)
+			}
+			var asset models.Asset
+			if err := tx.First(&asset, *loan.AssetID).Error; err != nil {
+				return err
+			}
+			result := tx.Model(&models.Asset{}).
+				Where("id = ? AND item_id = ? AND status = ?", asset.ID, item.ID, models.AssetInStock).
+				Update("status", models.AssetLent)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("%w: %s is not in stock", ErrAssetStatus, asset.Serial)
+			}
+			change := models.AssetEvent{Status: models.AssetLent, Location: asset.Location, Note: "lent to " + loan.Borrower}
+			if err := changeAsset(tx, &asset, change); err != nil {
+				return err
+			}
+		} else if item.Available < models.NewQuantity(1) {
+			return fmt.Errorf("%w: no unit of item %d is available", ErrInsufficientStock, item.ID)
+		} else {
+			loan.AssetID = nil
+		}
+		loan.ID = 0
+		loan.CheckedOutAt = time.Now()
+		loan.ReturnedAt = nil
+		return tx.Create(loan).Error
+	})
+}
+
+// CheckIn returns a lent unit
+func (db *LoanRepositorySQL) CheckIn(id int) (models.Loan, error) {
+	var loan models.Loan
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&loan, id).Error; err != nil {
+			return err
+		}
+		if loan.ReturnedAt != nil {
+			return fmt.Errorf("%w: %d", ErrLoanClosed, id)
+		}
+		now := time.Now()
+		loan.ReturnedAt = &now
+		if err := tx.Save(&loan).Error; err != nil {
+			return err
+		}
+		if loan.AssetID == nil {
+			return nil
+		}
+		var asset models.Asset
+		if err := tx.First(&asset, *loan.AssetID).Error; err != nil {
+			return err
+		}
+		change := models.AssetEvent{Status: models.AssetInStock, Location: asset.Location, Note: "returned by " + loan.Borrower}
+		return changeAsset(tx, &asset, change)
+	})
+	return loan, err
+}
+
+// ReadLoan gets a loan from a database
+func (db *LoanRepositorySQL) ReadLoan(id int) (models.Loan, error) {
+	var loan models.Loan
+	result := db.First(&loan, id)
+	return loan, result.Error
+}
+
+// ReadLoans gets loans from a database, the most recent first
+func (db *LoanRepositorySQL) ReadLoans(filter LoanFilter) ([]models.Loan, error) {
+	loans := []models.Loan{}
+	query := db.Order("checked_out_at DESC, id DESC")
+	if filter.ItemID != 0 {
+		query = query.Where("item_id = ?", filter.ItemID)
+	}
+	if filter.Borrower != "" {
+		query = query.Where("borrower = ?", filter.Borrower)
+	}
+	if filter.Open || filter.Overdue {
+		query = query.Where("returned_at IS NULL")
+	}
+	if filter.Overdue {
+		query = query.Where("due_at < ?", time.Now())
+	}
+	result := query.Find(&loans)
+	return loans, result.Error
+}
+
+// NewLoanRepositorySQL returns a new LoanRepositorySQL instance
+func NewLoanRepositorySQL(db *gorm.DB) LoanRepository {
+	return &LoanRepositorySQL{db}
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"gorm.io/gorm"
+)
+
+// MovementRepository interface define the methods to read stock movements
+type MovementRepository interface {
+	ReadMovements(itemID int, to time.Time) ([]models.Movement, error)
+	ReadWithdrawn(from time.Time, to time.Time) (map[int]models.Quantity, error)
+}
+
+// MovementRepositorySQL reads stock movements from a SQL database
+type MovementRepositorySQL struct {
+	*gorm.DB
+}
+
+// ReadMovements gets the movements created before a given time in chronological order,
+// an itemID of zero returns the movements of every item
+func (db *MovementRepositorySQL) ReadMovements(itemID int, to time.Time) ([]models.Movement, error) {
+	movements := []models.Movement{}
+	query := db.Where("created_at < ?", to)
+	if itemID != 0 {
+		query = query.Where("item_id = ?", itemID)
+	}
+	result := query.Order("created_at, id").Find(&movements)
+	return movements, result.Error
+}
+
+// ReadWithdrawn gets the quantity withdrawn of each item between two times, less the
+// withdrawals undone
+func (db *MovementRepositorySQL) ReadWithdrawn(from time.Time, to time.Time) (map[int]models.Quantity, error) {
+	var rows []struct {
+		ItemID    int
+		Withdrawn models.Quantity
+	}
+	result := db.Model(&models.Movement{}).
+		Select("item_id, SUM(CASE WHEN kind = ? THEN quantity ELSE -quantity END) AS withdrawn", models.MovementWithdrawal).
+		Where("(kind = ? OR (kind = ? AND quantity > 0)) AND created_at >= ? AND created_at < ?",
+			models.MovementWithdrawal, models.MovementUndo, from, to).
+		Group("item_id").
+		Scan(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	withdrawn := map[int]models.Quantity{}
+	for _, row := range rows {
+		withdrawn[row.ItemID] = row.Withdrawn
+	}
+	return withdrawn, nil
+}
+
+// NewMovementRepositorySQL returns a new MovementRepositorySQL instance
+func NewMovementRepositorySQL(db *gorm.DB) MovementRepository {
+	return &MovementRepositorySQL{db}
+}
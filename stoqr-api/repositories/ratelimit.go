@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/ratelimit"
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// rateLimitWait bounds how long a token waits for a bucket locked by other replicas
+const rateLimitWait = time.Second
+
+// RateLimitRepository interface define the methods to persist the token buckets of the rate limits
+type RateLimitRepository interface {
+	Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error)
+	DeleteIdle(before time.Time) (int64, error)
+}
+
+// RateLimitRepositorySQL persist the token buckets of the rate limits into a SQL database
+type RateLimitRepositorySQL struct {
+	*gorm.DB
+}
+
+// Take takes a token from the bucket of a key. The row of the bucket is locked until the
+// token is taken, so the takes of other replicas wait for it, and ratelimit.ErrContention
+// is returned if it stayed locked for too long.
+func (db *RateLimitRepositorySQL) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	wait, cancel := context.WithTimeout(ctx, rateLimitWait)
+	defer cancel()
+	var result ratelimit.Result
+	err := db.WithContext(wait).Transaction(func(tx *gorm.DB) error {
+		stored := models.RateLimit{}
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&stored, "name = ?", key).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			full := models.RateLimit{Name: key, Tokens: float64(limit.Requests), Refilled: time.Now()}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&full).Error; err != nil {
+				return err
+			}
+			err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&stored, "name = ?", key).Error
+		}
+		if err != nil {
+			return err
+		}
+		var bucket ratelimit.Bucket
+		bucket, result = ratelimit.Bucket{Tokens: stored.Tokens, Refilled: stored.Refilled}.Take(limit, time.Now())
+		return tx.Model(&models.RateLimit{}).
+			Where("name = ?", key).
+			Updates(map[string]interface{}{"tokens": bucket.Tokens, "refilled": bucket.Refilled}).Error
+	})
+	if err != nil && ctx.Err() == nil && (wait.Err() != nil || isContention(err)) {
+		return ratelimit.Result{}, fmt.Errorf("%w: %v", ratelimit.ErrContention, err)
+	}
+	return result, err
+}
+
+// isContention returns true if a statement failed because of the locks of other transactions:
+// a busy SQLite database or a lock timeout, a deadlock or a serialization failure in PostgreSQL
+func isContention(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "55P03" || pgErr.Code == "40P01" || pgErr.Code == "40001"
+	}
+	return false
+}
+
+// DeleteIdle deletes the buckets not used since a time, returning how many were deleted
+func (db *RateLimitRepositorySQL) DeleteIdle(before time.Time) (int64, error) {
+	result := db.Where("refilled < ?", before).Delete(&models.RateLimit{})
+	return result.RowsAffected, result.Error
+}
+
+// NewRateLimitRepositorySQL creates a new rate limit repository
+func NewRateLimitRepositorySQL(db *gorm.DB) RateLimitRepository {
+	return &RateLimitRepositorySQL{db}
+}
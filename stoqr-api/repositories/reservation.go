@@ -0,0 +1,173 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrReservationClosed is returned when using a reservation that is no longer active
+var ErrReservationClosed = errors.New("reservation is not active")
+
+// ReservationRepository interface define the methods to persist reservations
+type ReservationRepository interface {
+	CreateReservation(reservation *models.Reservation) error
+	ReadReservation(id int) (models.Reservation, error)
+	ReadReservations(itemID int) ([]models.Reservation, error)
+	ReleaseReservation(id int) error
+	WithdrawReservation(id int, quantity models.Quantity) (models.Item, error)
+	ExpireReservations() (int64, error)
+}
+
+// ReservationRepositorySQL persist reservations into a SQL database
+type ReservationRepositorySQL struct {
+	*gorm.DB
+}
+
+// CreateReservation holds a quantity of the available stock of an item, its row is locked
+// so that concurrent reservations can't hold more than is available. It fails with
+// ErrApprovalRequired if a policy requires an approval to withdraw the quantity.
+func (db *ReservationRepositorySQL) CreateReservation(reservation *models.Reservation) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		item, err := lockItem(tx, reservation.ItemID)
+		if err != nil {
+			return err
+		}
+		if item.IsKit() {
+			return fmt.Errorf("%w: reserve the components of kit %d instead", ErrKit, item.ID)
+		}
+		if item.Serialized {
+			return fmt.Errorf("%w: lend the units of item %d instead", ErrSerialized, item.ID)
+		}
+		if err := checkApproval(tx, item, reservation.Quantity); err != nil {
+			return err
+		}
+		if reservation.Quantity > item.Available {
+			return fmt.Errorf("%w: only %s of item %d is available", ErrInsufficientStock, item.Available, item.ID)
+		}
+		reservation.ID = 0
+		reservation.Remaining = reservation.Quantity
+		reservation.Status = models.ReservationActive
+		return tx.Create(reservation).Error
+	})
+}
+
+// ReadReservation gets a reservation from a database
+func (db *ReservationRepositorySQL) ReadReservation(id int) (models.Reservation, error) {
+	if _, err := db.ExpireReservations(); err != nil {
+		return models.Reservation{}, err
+	}
+	var reservation models.Reservation
+	result := db.First(&reservation, id)
+	return reservation, result.Error
+}
+
+// ReadReservations gets the reservations of an item, or of every item if itemID is zero
+func (db *ReservationRepositorySQL) ReadReservations(itemID int) ([]models.Reservation, error) {
+	if _, err := db.ExpireReservations(); err != nil {
+		return nil, err
+	}
+	reservations := []models.Reservation{}
+	query := db.Order("created_at DESC, id DESC")
+	if itemID != 0 {
+		query = query.Where("item_id = ?", itemID)
+	}
+	result := query.Find(&reservations)
+	return reservations, result.Error
+}
+
+// ReleaseReservation gives the remaining quantity of a reservation back to the available stock
+func (db *ReservationRepositorySQL) ReleaseReservation(id int) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		_, err := activeReservation(tx, id)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Reservation{}).Where("id = ?", id).Update("status", models.ReservationReleased).Error
+	})
+}
+
+// WithdrawReservation takes a quantity of a reservation out of stock, all that
+// remains if the quantity is zero, consuming the reservation when nothing remains.
+// The policy of the item is checked again as it may have changed since reserving. The
+// reservation is locked while it is read and only updated if it still has the quantity read,
+// so concurrent withdrawals can't both use it.
+func (db *ReservationRepositorySQL) WithdrawReservation(id int, quantity models.Quantity) (models.Item, error) {
+	var reservation models.Reservation
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		reservation, err = activeReservation(tx, id)
+		if err != nil {
+			return err
+		}
+		if quantity == 0 {
+			quantity = reservation.Remaining
+		}
+		if quantity > reservation.Remaining {
+			return fmt.Errorf("%w: only %s remains in reservation %d", ErrInsufficientStock, reservation.Remaining, id)
+		}
+		item, err := lockItem(tx, reservation.ItemID)
+		if err != nil {
+			return err
+		}
+		if err := checkApproval(tx, item, quantity); err != nil {
+			return err
+		}
+		held, err := heldQuantities(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := decrementStock(tx, reservation.ItemID, quantity, held[reservation.ItemID], ""); err != nil {
+			return err
+		}
+		remaining, status := reservation.Remaining-quantity, models.ReservationActive
+		if remaining == 0 {
+			status = models.ReservationConsumed
+		}
+		result := tx.Model(&models.Reservation{}).
+			Where("id = ? AND status = ? AND remaining = ?", id, models.ReservationActive, reservation.Remaining).
+			Updates(map[string]interface{}{"remaining": remaining, "status": status})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: %d was used concurrently", ErrReservationClosed, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Item{}, err
+	}
+	return readItem(db.DB, reservation.ItemID)
+}
+
+// ExpireReservations marks the active reservations past their expiration as expired
+func (db *ReservationRepositorySQL) ExpireReservations() (int64, error) {
+	result := db.Model(&models.Reservation{}).
+		Where("status = ? AND expires_at <= ?", models.ReservationActive, time.Now()).
+		Update("status", models.ReservationExpired)
+	return result.RowsAffected, result.Error
+}
+
+// NewReservationRepositorySQL returns a new ReservationRepositorySQL instance
+func NewReservationRepositorySQL(db *gorm.DB) ReservationRepository {
+	return &ReservationRepositorySQL{db}
+}
+
+// activeReservation reads a reservation that can still be used, locking its row until the end
+// of the transaction
+func activeReservation(tx *gorm.DB, id int) (models.Reservation, error) {
+	var reservation models.Reservation
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&reservation, id).Error; err != nil {
+		return reservation, err
+	}
+	expired := reservation.ExpiresAt != nil && !reservation.ExpiresAt.After(time.Now())
+	if reservation.Status != models.ReservationActive || expired {
+		return reservation, fmt.Errorf("%w: %d", ErrReservationClosed, id)
+	}
+	return reservation, nil
+}
@@ -0,0 +1,240 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidRange is returned when a time series cannot be computed for a range
+var ErrInvalidRange = errors.New("invalid range")
+
+// maxStockPoints is the maximum number of points of a stock time series
+const maxStockPoints = 1000
+
+// TakeSnapshot records the stock of every item that is not a kit
+func (db *ItemRepositorySQL) TakeSnapshot() (int64, error) {
+	var count int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var items []models.Item
+		result := tx.Select("id, actual").
+			Where("id NOT IN (?)", tx.Model(&models.Component{}).Select("kit_id")).
+			Find(&items)
+		if result.Error != nil || len(items) == 0 {
+			return result.Error
+		}
+		now := time.Now()
+		snapshots := make([]models.Snapshot, len(items))
+		for i, item := range items {
+			snapshots[i] = models.Snapshot{ItemID: item.ID, Actual: item.Actual, TakenAt: now}
+		}
+		count = int64(len(snapshots))
+		return tx.Create(&snapshots).Error
+	})
+	return count, err
+}
+
+// ReadItemAt gets an item with the stock it had at a past time, the available stock
+// is the actual stock since past reservations and loans are not considered
+func (db *ItemRepositorySQL) ReadItemAt(id int, at time.Time) (models.Item, error) {
+	var item models.Item
+	result := db.Preload("Components").Preload("Conversions").Preload("Tags").First(&item, id)
+	if result.Error != nil {
+		return item, result.Error
+	}
+	if !item.CreatedAt.IsZero() && item.CreatedAt.After(at) {
+		return models.Item{}, gorm.ErrRecordNotFound
+	}
+	stock, err := stockAt(db.DB, 0, at)
+	if err != nil {
+		return models.Item{}, err
+	}
+	setStockAt(&item, stock)
+	return item, nil
+}
+
+// ReadStockHistory gets the stock of an item at every step between two times
+func (db *ItemRepositorySQL) ReadStockHistory(id int, from time.Time, to time.Time, step time.Duration) ([]models.StockPoint, error) {
+	if step <= 0 || to.Before(from) || int64(to.Sub(from)/step) >= maxStockPoints {
+		return nil, fmt.Errorf("%w: from %s to %s every %s", ErrInvalidRange, from, to, step)
+	}
+	item, err := readItem(db.DB, id)
+	if err != nil {
+		return nil, err
+	}
+	if item.IsKit() {
+		return nil, fmt.Errorf("%w: read the history of the components of kit %d instead", ErrKit, id)
+	}
+	stock, err := stockAt(db.DB, id, from)
+	if err != nil {
+		return nil, err
+	}
+	movements := []models.Movement{}
+	result := db.Where("item_id = ? AND created_at > ? AND created_at <= ?", id, from, to).Find(&movements)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	snapshots := []models.Snapshot{}
+	result = db.Where("item_id = ? AND taken_at > ? AND taken_at <= ?", id, from, to).Find(&snapshots)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	events := stockEvents(movements, snapshots)
+	points := []models.StockPoint{}
+	actual := stock[id]
+	for at := from; !at.After(to); at = at.Add(step) {
+		for len(events) > 0 && !events[0].at.After(at) {
+			actual = events[0].apply(actual)
+			events = events[1:]
+		}
+		points = append(points, models.StockPoint{At: at, Actual: actual})
+	}
+	return points, nil
+}
+
+// stockEvent is a change of the stock of an item, either a movement or a snapshot
+type stockEvent struct {
+	at       time.Time
+	snapshot bool
+	quantity models.Quantity
+}
+
+func (event stockEvent) apply(actual models.Quantity) models.Quantity {
+	if event.snapshot {
+		return event.quantity
+	}
+	return actual + event.quantity
+}
+
+// stockEvents merges movements and snapshots in chronological order, the movements
+// of a time go before the snapshot of the same time since it already includes them
+func stockEvents(movements []models.Movement, snapshots []models.Snapshot) []stockEvent {
+	events := []stockEvent{}
+	for _, movement := range movements {
+		events = append(events, stockEvent{at: movement.CreatedAt, quantity: movement.Delta()})
+	}
+	for _, snapshot := range snapshots {
+		events = append(events, stockEvent{at: snapshot.TakenAt, snapshot: true, quantity: snapshot.Actual})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].at.Equal(events[j].at) {
+			return !events[i].snapshot && events[j].snapshot
+		}
+		return events[i].at.Before(events[j].at)
+	})
+	return events
+}
+
+// stockAt reconstructs the stock of an item, or of every item if id is zero, at a past
+// time. It starts from the latest snapshot up to that time and applies the movements
+// since, or when there is none it goes back from the next snapshot (or the present).
+func stockAt(db *gorm.DB, id int, at time.Time) (map[int]models.Quantity, error) {
+	query := db.Select("id, actual")
+	if id != 0 {
+		query = query.Where("id = ?", id)
+	}
+	var items []models.Item
+	if err := query.Find(&items).Error; err != nil {
+		return nil, err
+	}
+	before, err := boundarySnapshots(db, id, "MAX", "<=", at)
+	if err != nil {
+		return nil, err
+	}
+	after, err := boundarySnapshots(db, id, "MIN", ">", at)
+	if err != nil {
+		return nil, err
+	}
+	since := at
+	for _, snapshot := range before {
+		if snapshot.TakenAt.Before(since) {
+			since = snapshot.TakenAt
+		}
+	}
+	query = db.Where("created_at > ?", since)
+	if id != 0 {
+		query = query.Where("item_id = ?", id)
+	}
+	movements := []models.Movement{}
+	if err := query.Find(&movements).Error; err != nil {
+		return nil, err
+	}
+	stock := map[int]models.Quantity{}
+	for _, item := range items {
+		if snapshot, ok := before[item.ID]; ok {
+			stock[item.ID] = snapshot.Actual
+		} else if snapshot, ok := after[item.ID]; ok {
+			stock[item.ID] = snapshot.Actual
+		} else {
+			stock[item.ID] = item.Actual
+		}
+	}
+	for _, movement := range movements {
+		if snapshot, ok := before[movement.ItemID]; ok {
+			if movement.CreatedAt.After(snapshot.TakenAt) && !movement.CreatedAt.After(at) {
+				stock[movement.ItemID] += movement.Delta()
+			}
+			continue
+		}
+		snapshot, ok := after[movement.ItemID]
+		if movement.CreatedAt.After(at) && (!ok || !movement.CreatedAt.After(snapshot.TakenAt)) {
+			stock[movement.ItemID] -= movement.Delta()
+		}
+	}
+	return stock, nil
+}
+
+// boundarySnapshots returns, for each item, the snapshot whose time is the aggregate
+// (MIN or MAX) of the times that compare with the given time
+func boundarySnapshots(db *gorm.DB, id int, aggregate string, comparison string, at time.Time) (map[int]models.Snapshot, error) {
+	bounds := db.Model(&models.Snapshot{}).
+		Select("item_id, "+aggregate+"(taken_at) AS taken_at").
+		Where("taken_at "+comparison+" ?", at).
+		Group("item_id")
+	if id != 0 {
+		bounds = bounds.Where("item_id = ?", id)
+	}
+	snapshots := []models.Snapshot{}
+	result := db.Model(&models.Snapshot{}).
+		Select("snapshots.*").
+		Joins("JOIN (?) AS bounds ON bounds.item_id = snapshots.item_id AND bounds.taken_at = snapshots.taken_at", bounds).
+		Find(&snapshots)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	byItem := map[int]models.Snapshot{}
+	for _, snapshot := range snapshots {
+		byItem[snapshot.ItemID] = snapshot
+	}
+	return byItem, nil
+}
+
+// setStockAt sets the past stock of an item, kits are computed from their components
+func setStockAt(item *models.Item, stock map[int]models.Quantity) {
+	if !item.IsKit() {
+		item.Actual = stock[item.ID]
+		item.Available = item.Actual
+		return
+	}
+	kits := int64(-1)
+	for _, component := range item.Components {
+		if n := int64(stock[component.ComponentID] / component.Quantity); kits < 0 || n < kits {
+			kits = n
+		}
+	}
+	if kits < 0 {
+		kits = 0
+	}
+	item.Actual = models.NewQuantity(kits)
+	item.Available = item.Actual
+}
+
+// snapshotStock records the stock of an item set directly, without a movement
+func snapshotStock(tx *gorm.DB, id int, actual models.Quantity) error {
+	snapshot := models.Snapshot{ItemID: id, Actual: actual, TakenAt: time.Now()}
+	return tx.Create(&snapshot).Error
+}
@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"gorm.io/gorm"
+)
+
+// ErrStocktakeClosed is returned when changing a stocktake that is not open
+var ErrStocktakeClosed = errors.New("stocktake is not open")
+
+// ErrStaleCount is returned when the stock of an item fell below what its count removes
+// since it was counted, the item must be counted again
+var ErrStaleCount = errors.New("count is out of date")
+
+// ErrOutOfScope is returned when counting an item outside the scope of a stocktake
+var ErrOutOfScope = errors.New("item is out of the stocktake scope")
+
+// StocktakeRepository interface define the methods to persist stocktakes
+type StocktakeRepository interface {
+	CreateStocktake(stocktake *models.Stocktake) error
+	ReadStocktake(id int) (models.Stocktake, error)
+	ReadStocktakes() ([]models.Stocktake, error)
+	RecordCount(id int, itemID int, quantity models.Quantity, add bool) (models.StocktakeCount, error)
+	CommitStocktake(id int) (models.Stocktake, error)
+	CancelStocktake(id int) error
+}
+
+// StocktakeRepositorySQL persist stocktakes into a SQL database
+type StocktakeRepositorySQL struct {
+	*gorm.DB
+}
+
+// CreateStocktake opens a stocktake
+func (db *StocktakeRepositorySQL) CreateStocktake(stocktake *models.Stocktake) error {
+	if err := checkCategory(db.DB, stocktake.CategoryID); err != nil {
+		return err
+	}
+	stocktake.Status = models.StocktakeOpen
+	stocktake.CommittedAt = nil
+	stocktake.Counts = nil
+	return db.Create(stocktake).Error
+}
+
+// ReadStocktake gets a stocktake with its counts from a database
+func (db *StocktakeRepositorySQL) ReadStocktake(id int) (models.Stocktake, error) {
+	return readStocktake(db.DB, id)
+}
+
+// ReadStocktakes gets every stocktake without its counts, the most recent first
+func (db *StocktakeRepositorySQL) ReadStocktakes() ([]models.Stocktake, error) {
+	stocktakes := []models.Stocktake{}
+	result := db.Order("created_at DESC, id DESC").Find(&stocktakes)
+	return stocktakes, result.Error
+}
+
+// RecordCount sets the counted quantity of an item, or adds to it if add is true, and
+// records the stock the item had when it was counted
+func (db *StocktakeRepositorySQL) RecordCount(id int, itemID int, quantity models.Quantity, add bool) (models.StocktakeCount, error) {
+	var count models.StocktakeCount
+	err := db.Transaction(func(tx *gorm.DB) error {
+		stocktake, err := readStocktake(tx, id)
+		if err != nil {
+			return err
+		}
+		if stocktake.Status != models.StocktakeOpen {
+			return ErrStocktakeClosed
+		}
+		item, err := readItem(tx, itemID)
+		if err != nil {
+			return err
+		}
+		if item.IsKit() {
+			return fmt.Errorf("%w: count the components of kit %d instead", ErrKit, itemID)
+		}
+		if item.Serialized {
+			return fmt.Errorf("%w: the stock of item %d comes from its units", ErrSerialized, itemID)
+		}
+		inScope, err := stocktakeScope(tx, stocktake)
+		if err != nil {
+			return err
+		}
+		if !inScope(item) {
+			return fmt.Errorf("%w: %d", ErrOutOfScope, itemID)
+		}
+		result := tx.Where("stocktake_id = ? AND item_id = ?", id, itemID).Limit(1).Find(&count)
+		if result.Error != nil {
+			return result.Error
+		}
+		count.StocktakeID = id
+		count.ItemID = itemID
+		count.Expected = item.Actual
+		if add {
+			count.Counted += quantity
+		} else {
+			count.Counted = quantity
+		}
+		if count.Counted < 0 {
+			return fmt.Errorf("%w: counted quantity cannot be negative", models.ErrInvalidQuantity)
+		}
+		return tx.Save(&count).Error
+	})
+	return count, err
+}
+
+// CommitStocktake corrects the stock of every counted item in a single transaction by its
+// variance, the difference between the counted quantity and the stock it had when counted,
+// so that the stock moved since the count is kept. An adjustment movement is recorded for
+// each variance. The stocktake is closed first, only if it is still open, so that concurrent
+// commits don't apply the variances twice.
+func (db *StocktakeRepositorySQL) CommitStocktake(id int) (models.Stocktake, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		stocktake, err := readStocktake(tx, id)
+		if err != nil {
+			return err
+		}
+		if stocktake.Status != models.StocktakeOpen {
+			return ErrStocktakeClosed
+		}
+		now := time.Now()
+		result := tx.Model(&models.Stocktake{}).
+			Where("id = ? AND status = ?", id, models.StocktakeOpen).
+			Updates(map[string]interface{}{"status": models.StocktakeCommitted, "committed_at": &now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrStocktakeClosed
+		}
+		for _, count := range stocktake.Counts {
+			count.Variance = count.Counted - count.Expected
+			if err := tx.Save(&count).Error; err != nil {
+				return err
+			}
+			if count.Variance == 0 {
+				continue
+			}
+			result := tx.Model(&models.Item{}).
+				Where("id = ? AND actual + ? >= 0", count.ItemID, count.Variance).
+				UpdateColumn("actual", gorm.Expr("actual + ?", count.Variance))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				if _, err := readItem(tx, count.ItemID); err != nil {
+					return err
+				}
+				return fmt.Errorf("%w: recount item %d", ErrStaleCount, count.ItemID)
+			}
+			if err := createMovement(tx, count.ItemID, models.MovementAdjustment, count.Variance, 0, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Stocktake{}, err
+	}
+	return readStocktake(db.DB, id)
+}
+
+// CancelStocktake closes a stocktake without changing the stock
+func (db *StocktakeRepositorySQL) CancelStocktake(id int) error {
+	result := db.Model(&models.Stocktake{}).
+		Where("id = ? AND status = ?", id, models.StocktakeOpen).
+		Update("status", models.StocktakeCancelled)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := readStocktake(db.DB, id); err != nil {
+			return err
+		}
+		return ErrStocktakeClosed
+	}
+	return nil
+}
+
+// NewStocktakeRepositorySQL returns a new StocktakeRepositorySQL instance
+func NewStocktakeRepositorySQL(db *gorm.DB) StocktakeRepository {
+	return &StocktakeRepositorySQL{db}
+}
+
+func readStocktake(db *gorm.DB, id int) (models.Stocktake, error) {
+	var stocktake models.Stocktake
+	result := db.Preload("Counts", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).First(&stocktake, id)
+	return stocktake, result.Error
+}
+
+// stocktakeScope returns a function telling whether an item is in the scope of a stocktake
+func stocktakeScope(tx *gorm.DB, stocktake models.Stocktake) (func(models.Item) bool, error) {
+	categories := map[int]bool{}
+	if stocktake.CategoryID != nil {
+		all := models.Categories{}
+		if err := tx.Find(&all).Error; err != nil {
+			return nil, err
+		}
+		for _, id := range all.Descendants(*stocktake.CategoryID) {
+			categories[id] = true
+		}
+	}
+	return func(item models.Item) bool {
+		if stocktake.Location != "" && item.Location != stocktake.Location {
+			return false
+		}
+		if stocktake.CategoryID != nil && (item.CategoryID == nil || !categories[*item.CategoryID]) {
+			return false
+		}
+		return true
+	}, nil
+}
@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidSubscription is returned when a subscription has an invalid or already subscribed email
+var ErrInvalidSubscription = errors.New("invalid subscription")
+
+// SubscriptionRepository interface define the methods to persist notification subscriptions
+type SubscriptionRepository interface {
+	CreateSubscription(subscription *models.Subscription) error
+	ReadSubscription(id int) (models.Subscription, error)
+	ReadSubscriptions(event string) ([]models.Subscription, error)
+	UpdateSubscription(id int, subscription models.Subscription) error
+	DeleteSubscription(id int) error
+}
+
+// SubscriptionRepositorySQL persist notification subscriptions into a SQL database
+type SubscriptionRepositorySQL struct {
+	*gorm.DB
+}
+
+// CreateSubscription persists a subscription into a database
+func (db *SubscriptionRepositorySQL) CreateSubscription(subscription *models.Subscription) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := checkSubscription(tx, 0, subscription); err != nil {
+			return err
+		}
+		subscription.ID = 0
+		return tx.Create(subscription).Error
+	})
+}
+
+// ReadSubscription gets a subscription from a database
+func (db *SubscriptionRepositorySQL) ReadSubscription(id int) (models.Subscription, error) {
+	var subscription models.Subscription
+	result := db.First(&subscription, id)
+	return subscription, result.Error
+}
+
+// ReadSubscriptions gets the subscriptions to an event, or every subscription if the event is empty
+func (db *SubscriptionRepositorySQL) ReadSubscriptions(event string) ([]models.Subscription, error) {
+	subscriptions := []models.Subscription{}
+	query := db.Order("email")
+	switch event {
+	case "":
+	case models.EventLowStock:
+		query = query.Where("low_stock = ?", true)
+	case models.EventDigest:
+		query = query.Where("digest = ?", true)
+	default:
+		return nil, fmt.Errorf("%w: unknown event %s", ErrInvalidSubscription, event)
+	}
+	result := query.Find(&subscriptions)
+	return subscriptions, result.Error
+}
+
+// UpdateSubscription changes the email, name and preferences of a subscription
+func (db *SubscriptionRepositorySQL) UpdateSubscription(id int, updatedSubscription models.Subscription) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var subscription models.Subscription
+		if err := tx.First(&subscription, id).Error; err != nil {
+			return err
+		}
+		if err := checkSubscription(tx, id, &updatedSubscription); err != nil {
+			return err
+		}
+		subscription.Name = updatedSubscription.Name
+		subscription.Email = updatedSubscription.Email
+		subscription.LowStock = updatedSubscription.LowStock
+		subscription.Digest = updatedSubscription.Digest
+		return tx.Save(&subscription).Error
+	})
+}
+
+// DeleteSubscription removes a subscription from a database
+func (db *SubscriptionRepositorySQL) DeleteSubscription(id int) error {
+	result := db.Delete(&models.Subscription{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// NewSubscriptionRepositorySQL creates a new subscription repository
+func NewSubscriptionRepositorySQL(db *gorm.DB) SubscriptionRepository {
+	return &SubscriptionRepositorySQL{db}
+}
+
+// checkSubscription validates the email of a subscription and that no other subscription uses it
+func checkSubscription(tx *gorm.DB, id int, subscription *models.Subscription) error {
+	address, err := mail.ParseAddress(subscription.Email)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSubscription, err)
+	}
+	subscription.Email = address.Address
+	if subscription.Name == "" {
+		subscription.Name = address.Name
+	}
+	var count int64
+	result := tx.Model(&models.Subscription{}).Where("email = ? AND id <> ?", subscription.Email, id).Count(&count)
+	if result.Error != nil {
+		return result.Error
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %s is already subscribed", ErrInvalidSubscription, subscription.Email)
+	}
+	return nil
+}
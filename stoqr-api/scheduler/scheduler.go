@@ -0,0 +1,166 @@
+// Package scheduler runs recurring background jobs on cron schedules. The state of the
+// jobs is kept in the database, which every replica shares: a replica runs a due job only
+// after taking its lock, so each run happens on a single replica.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+	"github.com/robfig/cron/v3"
+)
+
+// DefaultLease is how long a replica holds the lock of a job it is running, a run
+// taking longer is cancelled so another replica can take over
+const DefaultLease = 10 * time.Minute
+
+// DefaultInterval is how often the due jobs are checked
+const DefaultInterval = 30 * time.Second
+
+// ErrInvalidJob is returned when adding a job with an invalid schedule or missed run policy
+var ErrInvalidJob = errors.New("invalid job")
+
+// Task is the work of a job
+type Task func(ctx context.Context) error
+
+type job struct {
+	schedule cron.Schedule
+	task     Task
+}
+
+// Scheduler runs the jobs added to it when they are due
+type Scheduler struct {
+	Repository repositories.JobRepository
+	Instance   string
+	Lease      time.Duration
+	Interval   time.Duration
+	now        func() time.Time
+	mutex      sync.Mutex
+	jobs       map[string]job
+}
+
+// Add registers a job with a standard cron schedule (e.g. "0 0 * * *" or "@daily") and
+// the policy applied when runs were missed
+func (s *Scheduler) Add(name string, spec string, missed string, task Task) error {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidJob, name, err)
+	}
+	if !models.IsMissedPolicy(missed) {
+		return fmt.Errorf("%w: %s: unknown missed run policy %q", ErrInvalidJob, name, missed)
+	}
+	state := models.Job{Name: name, Schedule: spec, Missed: missed, NextRunAt: schedule.Next(s.now())}
+	if err := s.Repository.RegisterJob(&state); err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.jobs[name] = job{schedule: schedule, task: task}
+	return nil
+}
+
+// Run checks the due jobs every interval until the context is done. Cancelling the context
+// cancels the running job, Run returns once that job has completed and its outcome has been
+// recorded so the caller can wait for it before exiting.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		s.RunDue(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// RunDue runs the due jobs whose lock this replica can take, one after the other
+func (s *Scheduler) RunDue(ctx context.Context) {
+	s.mutex.Lock()
+	names := make([]string, 0, len(s.jobs))
+	for name := range s.jobs {
+		names = append(names, name)
+	}
+	s.mutex.Unlock()
+	sort.Strings(names)
+	for _, name := range names {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.runJob(ctx, name); err != nil {
+			slog.ErrorContext(ctx, "Running a job", "job", name, "error", err)
+		}
+	}
+}
+
+// runJob runs a job if it is due and the lock could be taken. A job that missed more
+// than one scheduled time is skipped or run once depending on its policy, and then
+// scheduled from now on.
+func (s *Scheduler) runJob(ctx context.Context, name string) error {
+	s.mutex.Lock()
+	j := s.jobs[name]
+	s.mutex.Unlock()
+	now := s.now()
+	acquired, err := s.Repository.AcquireJob(name, s.Instance, now, now.Add(s.Lease))
+	if err != nil || !acquired {
+		return err
+	}
+	state, err := s.Repository.ReadJob(name)
+	if err != nil {
+		return err
+	}
+	run := models.Job{LastRunAt: state.LastRunAt}
+	if state.Missed == models.MissedSkip && !j.schedule.Next(state.NextRunAt).After(now) {
+		slog.WarnContext(ctx, "Skipping the missed runs of a job", "job", name, "since", state.NextRunAt.Format(time.RFC3339))
+		run.LastStatus = models.JobSkipped
+	} else {
+		started := now
+		run.LastRunAt = &started
+		run.LastStatus = models.JobSucceeded
+		if err := s.execute(ctx, j.task); err != nil {
+			run.LastStatus = models.JobFailed
+			run.LastError = err.Error()
+			slog.ErrorContext(ctx, "Job failed", "job", name, "error", err)
+		}
+	}
+	run.NextRunAt = j.schedule.Next(s.now())
+	return s.Repository.CompleteJob(name, s.Instance, run)
+}
+
+// execute runs a task within the lease, recovering from a panic
+func (s *Scheduler) execute(ctx context.Context, task Task) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.Lease)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return task(ctx)
+}
+
+// New creates a scheduler identified by an instance name, the host name and process id
+// by default
+func New(repository repositories.JobRepository, instance string) *Scheduler {
+	if instance == "" {
+		host, _ := os.Hostname()
+		instance = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	return &Scheduler{
+		Repository: repository,
+		Instance:   instance,
+		Lease:      DefaultLease,
+		Interval:   DefaultInterval,
+		now:        time.Now,
+		jobs:       map[string]job{},
+	}
+}
@@ -0,0 +1,187 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/leandroberetta/stoqr/stoqr-api/mocks"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+)
+
+var now = time.Date(2021, time.March, 1, 10, 30, 0, 0, time.UTC)
+
+func newScheduler(ctrl *gomock.Controller) (*Scheduler, *mocks.MockJobRepository) {
+	repository := mocks.NewMockJobRepository(ctrl)
+	s := New(repository, "replica-0")
+	s.now = func() time.Time { return now }
+	return s, repository
+}
+
+func TestAddInvalidSchedule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, _ := newScheduler(ctrl)
+
+	err := s.Add("snapshots", "every day", models.MissedSkip, func(ctx context.Context) error { return nil })
+	if !errors.Is(err, ErrInvalidJob) {
+		t.Errorf("wrong error: want %v, got %v", ErrInvalidJob, err)
+	}
+}
+
+func TestAddRegistersNextRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, repository := newScheduler(ctrl)
+
+	want := time.Date(2021, time.March, 2, 0, 0, 0, 0, time.UTC)
+	repository.
+		EXPECT().
+		RegisterJob(gomock.Any()).
+		DoAndReturn(func(job *models.Job) error {
+			if !job.NextRunAt.Equal(want) {
+				t.Errorf("wrong next run: want %v, got %v", want, job.NextRunAt)
+			}
+			return nil
+		})
+
+	if err := s.Add("snapshots", "@daily", models.MissedRunOnce, func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunDueRunsJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, repository := newScheduler(ctrl)
+
+	repository.EXPECT().RegisterJob(gomock.Any()).Return(nil)
+	repository.
+		EXPECT().
+		AcquireJob("snapshots", "replica-0", now, now.Add(DefaultLease)).
+		Return(true, nil)
+	repository.
+		EXPECT().
+		ReadJob("snapshots").
+		Return(models.Job{Name: "snapshots", Missed: models.MissedSkip, NextRunAt: now.Add(-30 * time.Minute)}, nil)
+	repository.
+		EXPECT().
+		CompleteJob("snapshots", "replica-0", gomock.Any()).
+		DoAndReturn(func(name string, owner string, run models.Job) error {
+			if run.LastStatus != models.JobFailed || run.LastError != "disk full" {
+				t.Errorf("wrong outcome: want %v, got %v %v", models.JobFailed, run.LastStatus, run.LastError)
+			}
+			if want := now.Add(30 * time.Minute); !run.NextRunAt.Equal(want) {
+				t.Errorf("wrong next run: want %v, got %v", want, run.NextRunAt)
+			}
+			return nil
+		})
+
+	runs := 0
+	s.Add("snapshots", "0 * * * *", models.MissedSkip, func(ctx context.Context) error {
+		runs++
+		return errors.New("disk full")
+	})
+	s.RunDue(context.Background())
+
+	if runs != 1 {
+		t.Errorf("wrong runs: want %v, got %v", 1, runs)
+	}
+}
+
+func TestRunDueLockedElsewhere(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, repository := newScheduler(ctrl)
+
+	repository.EXPECT().RegisterJob(gomock.Any()).Return(nil)
+	repository.EXPECT().AcquireJob("snapshots", "replica-0", gomock.Any(), gomock.Any()).Return(false, nil)
+
+	s.Add("snapshots", "0 * * * *", models.MissedSkip, func(ctx context.Context) error {
+		t.Error("job run without its lock")
+		return nil
+	})
+	s.RunDue(context.Background())
+}
+
+func TestRunDueMissedRuns(t *testing.T) {
+	tests := []struct {
+		missed string
+		runs   int
+		status string
+	}{
+		{models.MissedSkip, 0, models.JobSkipped},
+		{models.MissedRunOnce, 1, models.JobSucceeded},
+	}
+	for _, test := range tests {
+		ctrl := gomock.NewController(t)
+		s, repository := newScheduler(ctrl)
+
+		repository.EXPECT().RegisterJob(gomock.Any()).Return(nil)
+		repository.EXPECT().AcquireJob("snapshots", "replica-0", gomock.Any(), gomock.Any()).Return(true, nil)
+		repository.
+			EXPECT().
+			ReadJob("snapshots").
+			Return(models.Job{Name: "snapshots", Missed: test.missed, NextRunAt: now.Add(-5 * time.Hour)}, nil)
+		repository.
+			EXPECT().
+			CompleteJob("snapshots", "replica-0", gomock.Any()).
+			DoAndReturn(func(name string, owner string, run models.Job) error {
+				if run.LastStatus != test.status {
+					t.Errorf("wrong status for %s: want %v, got %v", test.missed, test.status, run.LastStatus)
+				}
+				return nil
+			})
+
+		runs := 0
+		s.Add("snapshots", "0 * * * *", test.missed, func(ctx context.Context) error {
+			runs++
+			return nil
+		})
+		s.RunDue(context.Background())
+
+		if runs != test.runs {
+			t.Errorf("wrong runs for %s: want %v, got %v", test.missed, test.runs, runs)
+		}
+		ctrl.Finish()
+	}
+}
+
+func TestRunWaitsForRunningJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, repository := newScheduler(ctrl)
+
+	completed := false
+	repository.EXPECT().RegisterJob(gomock.Any()).Return(nil)
+	repository.EXPECT().AcquireJob("snapshots", "replica-0", gomock.Any(), gomock.Any()).Return(true, nil)
+	repository.
+		EXPECT().
+		ReadJob("snapshots").
+		Return(models.Job{Name: "snapshots", Missed: models.MissedSkip, NextRunAt: now.Add(-30 * time.Minute)}, nil)
+	repository.
+		EXPECT().
+		CompleteJob("snapshots", "replica-0", gomock.Any()).
+		DoAndReturn(func(name string, owner string, run models.Job) error {
+			completed = true
+			return nil
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	s.Add("snapshots", "0 * * * *", models.MissedSkip, func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		return ctx.Err()
+	})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		s.Run(ctx)
+	}()
+	<-started
+	cancel()
+	<-stopped
+
+	if !completed {
+		t.Error("Run returned before the running job completed")
+	}
+}
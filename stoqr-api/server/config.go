@@ -0,0 +1,131 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config is the configuration of the http server, the public url is the one the clients
+// reach the api at (e.g. behind a proxy) and the links and QR codes point to. The drain
+// delay is how long the server keeps serving once draining, so the load balancer notices
+// the failing readiness probe before the listener closes.
+type Config struct {
+	Addr              string
+	PublicURL         string
+	CertFile          string
+	KeyFile           string
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	DrainDelay        time.Duration
+	ShutdownTimeout   time.Duration
+	CORS              CORSConfig
+	RateLimit         RateLimitConfig
+}
+
+// DefaultConfig returns the configuration used when nothing is set: plain http on port 8080
+func DefaultConfig() Config {
+	return Config{
+		Addr:              ":8080",
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      60 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		ShutdownTimeout:   15 * time.Second,
+		CORS:              DefaultCORSConfig(),
+		RateLimit:         DefaultRateLimitConfig(),
+	}
+}
+
+// ConfigFromEnv reads the configuration from the environment, falling back to the defaults.
+// The address is STOQR_API_ADDR, or STOQR_API_HOST and STOQR_API_PORT, TLS is enabled
+// when both STOQR_API_TLS_CERT and STOQR_API_TLS_KEY are set and the public url is
+// STOQR_API_PUBLIC_URL.
+func ConfigFromEnv() (Config, error) {
+	config := DefaultConfig()
+	if addr := os.Getenv("STOQR_API_ADDR"); addr != "" {
+		config.Addr = addr
+	} else if host, port := os.Getenv("STOQR_API_HOST"), os.Getenv("STOQR_API_PORT"); host != "" || port != "" {
+		if port == "" {
+			port = "8080"
+		}
+		config.Addr = net.JoinHostPort(host, port)
+	}
+	if _, _, err := net.SplitHostPort(config.Addr); err != nil {
+		return config, fmt.Errorf("invalid address %q: %w", config.Addr, err)
+	}
+	config.CertFile = os.Getenv("STOQR_API_TLS_CERT")
+	config.KeyFile = os.Getenv("STOQR_API_TLS_KEY")
+	if (config.CertFile == "") != (config.KeyFile == "") {
+		return config, errors.New("tls needs both a certificate and a key")
+	}
+	if value := os.Getenv("STOQR_API_PUBLIC_URL"); value != "" {
+		public, err := url.Parse(value)
+		if err != nil || (public.Scheme != "http" && public.Scheme != "https") || public.Host == "" ||
+			public.User != nil || public.RawQuery != "" || public.Fragment != "" {
+			return config, fmt.Errorf("invalid STOQR_API_PUBLIC_URL %q", value)
+		}
+		config.PublicURL = strings.TrimSuffix(public.String(), "/")
+	}
+	durations := []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"STOQR_API_READ_HEADER_TIMEOUT", &config.ReadHeaderTimeout},
+		{"STOQR_API_READ_TIMEOUT", &config.ReadTimeout},
+		{"STOQR_API_WRITE_TIMEOUT", &config.WriteTimeout},
+		{"STOQR_API_IDLE_TIMEOUT", &config.IdleTimeout},
+		{"STOQR_API_DRAIN_DELAY", &config.DrainDelay},
+		{"STOQR_API_SHUTDOWN_TIMEOUT", &config.ShutdownTimeout},
+	}
+	for _, d := range durations {
+		value := os.Getenv(d.name)
+		if value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration < 0 {
+			return config, fmt.Errorf("invalid %s %q", d.name, value)
+		}
+		*d.value = duration
+	}
+	cors, err := CORSConfigFromEnv()
+	if err != nil {
+		return config, err
+	}
+	config.CORS = cors
+	rateLimit, err := RateLimitConfigFromEnv()
+	if err != nil {
+		return config, err
+	}
+	config.RateLimit = rateLimit
+	return config, nil
+}
+
+// BaseURL returns the public url of the api, or the url of the address it listens on if
+// none is set
+func (config Config) BaseURL() string {
+	if config.PublicURL != "" {
+		return config.PublicURL
+	}
+	scheme := "http"
+	if config.TLS() {
+		scheme = "https"
+	}
+	host, port, _ := net.SplitHostPort(config.Addr)
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+	return scheme + "://" + net.JoinHostPort(host, port)
+}
+
+// TLS returns true if the server serves https
+func (config Config) TLS() bool {
+	return config.CertFile != "" && config.KeyFile != ""
+}
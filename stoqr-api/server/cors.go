@@ -0,0 +1,207 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// CORSConfig is the cross-origin resource sharing policy of the api. An allowed origin
+// may be "*" for any origin or contain a wildcard subdomain (https://*.example.com).
+// Credentials are only allowed for the origins listed explicitly, never through "*".
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// DefaultCORSConfig returns the policy used when nothing is set, any origin without credentials
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
+		AllowedHeaders: []string{"Origin", "X-Requested-With", "Content-Type", "Accept", "Authorization"},
+		MaxAge:         10 * time.Minute,
+	}
+}
+
+// CORSConfigFromEnv reads the policy from the environment, falling back to the defaults.
+// The lists are comma separated: STOQR_API_CORS_ORIGINS, STOQR_API_CORS_METHODS,
+// STOQR_API_CORS_HEADERS and STOQR_API_CORS_EXPOSED_HEADERS. Allowing credentials with
+// the "*" origin is an error since any site could then make authenticated requests.
+func CORSConfigFromEnv() (CORSConfig, error) {
+	config := DefaultCORSConfig()
+	lists := []struct {
+		name  string
+		value *[]string
+	}{
+		{"STOQR_API_CORS_ORIGINS", &config.AllowedOrigins},
+		{"STOQR_API_CORS_METHODS", &config.AllowedMethods},
+		{"STOQR_API_CORS_HEADERS", &config.AllowedHeaders},
+		{"STOQR_API_CORS_EXPOSED_HEADERS", &config.ExposedHeaders},
+	}
+	for _, l := range lists {
+		if value := os.Getenv(l.name); value != "" {
+			*l.value = splitList(value)
+		}
+	}
+	if value := os.Getenv("STOQR_API_CORS_CREDENTIALS"); value != "" {
+		credentials, err := strconv.ParseBool(value)
+		if err != nil {
+			return config, err
+		}
+		config.AllowCredentials = credentials
+	}
+	if value := os.Getenv("STOQR_API_CORS_MAX_AGE"); value != "" {
+		maxAge, err := time.ParseDuration(value)
+		if err != nil {
+			return config, err
+		}
+		config.MaxAge = maxAge
+	}
+	if config.AllowCredentials && contains(config.AllowedOrigins, "*") {
+		return config, errors.New("cors credentials cannot be allowed for any origin, list the allowed origins instead")
+	}
+	return config, nil
+}
+
+// CORS is a middleware setting the CORS headers of the responses to the allowed origins and
+// answering their preflight requests, a route can override the policy
+type CORS struct {
+	Config CORSConfig
+	routes map[string]CORSConfig
+}
+
+// NewCORS creates a CORS middleware with a policy for every route
+func NewCORS(config CORSConfig) *CORS {
+	return &CORS{Config: config, routes: map[string]CORSConfig{}}
+}
+
+// Route overrides the policy of the routes with a path template (e.g. /api/items/{itemId})
+func (cors *CORS) Route(template string, config CORSConfig) {
+	cors.routes[template] = config
+}
+
+// Middleware sets the CORS headers, preflight requests are answered without calling the route
+func (cors *CORS) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		config := cors.config(r)
+		origin := r.Header.Get("Origin")
+		w.Header().Add("Vary", "Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		listed := origin != "" && config.listsOrigin(origin)
+		anyOrigin := contains(config.AllowedOrigins, "*")
+		if origin == "" || (!listed && !anyOrigin) {
+			if preflight {
+				WriteProblem(w, r, Problem{Status: http.StatusForbidden, Detail: "origin not allowed"})
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		if listed && (config.AllowCredentials || !anyOrigin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			if config.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+		if !preflight {
+			if len(config.ExposedHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(config.ExposedHeaders, ", "))
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		if !containsFold(config.AllowedMethods, r.Header.Get("Access-Control-Request-Method")) {
+			WriteProblem(w, r, Problem{Status: http.StatusMethodNotAllowed, Detail: "method not allowed"})
+			return
+		}
+		for _, header := range splitList(r.Header.Get("Access-Control-Request-Headers")) {
+			if !contains(config.AllowedHeaders, "*") && !containsFold(config.AllowedHeaders, header) {
+				WriteProblem(w, r, Problem{Status: http.StatusForbidden, Detail: "header not allowed: " + header})
+				return
+			}
+		}
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(config.AllowedMethods, ", "))
+		if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" && contains(config.AllowedHeaders, "*") {
+			w.Header().Set("Access-Control-Allow-Headers", requested)
+		} else {
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(config.AllowedHeaders, ", "))
+		}
+		if config.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(config.MaxAge.Seconds())))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// config returns the policy of the route of a request
+func (cors *CORS) config(r *http.Request) CORSConfig {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			if config, ok := cors.routes[template]; ok {
+				return config
+			}
+		}
+	}
+	return cors.Config
+}
+
+// listsOrigin returns true if an origin matches one of the allowed origins other than "*"
+func (config CORSConfig) listsOrigin(origin string) bool {
+	for _, allowed := range config.AllowedOrigins {
+		if allowed == "*" {
+			continue
+		}
+		if strings.EqualFold(allowed, origin) {
+			return true
+		}
+		if i := strings.Index(allowed, "*."); i >= 0 {
+			prefix, suffix := allowed[:i], allowed[i+1:]
+			if strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) && len(origin) > len(prefix)+len(suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func splitList(value string) []string {
+	list := []string{}
+	for _, element := range strings.Split(value, ",") {
+		if element = strings.TrimSpace(element); element != "" {
+			list = append(list, element)
+		}
+	}
+	return list
+}
+
+func contains(list []string, value string) bool {
+	for _, element := range list {
+		if element == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, value string) bool {
+	for _, element := range list {
+		if strings.EqualFold(element, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newCORSRouter(config CORSConfig) *Server {
+	server := NewServer(Config{CORS: config})
+	server.Router.HandleFunc("/api/items", Options).Methods(http.MethodOptions)
+	server.Router.HandleFunc("/api/items", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods(http.MethodGet)
+	server.Router.HandleFunc("/api/assets/{serial}/qr", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods(http.MethodGet)
+	return server
+}
+
+func TestCORSAllowedOrigin(t *testing.T) {
+	config := DefaultCORSConfig()
+	config.AllowedOrigins = []string{"https://stoqr.example.com", "https://*.example.org"}
+	config.AllowCredentials = true
+	config.ExposedHeaders = []string{"Location"}
+	server := newCORSRouter(config)
+
+	cases := []struct {
+		origin string
+		want   string
+	}{
+		{"https://stoqr.example.com", "https://stoqr.example.com"},
+		{"https://home.example.org", "https://home.example.org"},
+		{"https://example.org", ""},
+		{"https://evil.example.com", ""},
+	}
+	for _, c := range cases {
+		req, _ := http.NewRequest("GET", "/api/items", nil)
+		req.Header.Set("Origin", c.origin)
+		rr := httptest.NewRecorder()
+		server.Router.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		if got := rr.Header().Get("Access-Control-Allow-Origin"); got != c.want {
+			t.Errorf("wrong allowed origin for %s: want %q, got %q", c.origin, c.want, got)
+		}
+		if c.want != "" && rr.Header().Get("Access-Control-Allow-Credentials") != "true" {
+			t.Errorf("credentials not allowed for %s", c.origin)
+		}
+		if c.want != "" && rr.Header().Get("Access-Control-Expose-Headers") != "Location" {
+			t.Errorf("wrong exposed headers for %s: want %q, got %q", c.origin, "Location", rr.Header().Get("Access-Control-Expose-Headers"))
+		}
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	server := newCORSRouter(DefaultCORSConfig())
+
+	req, _ := http.NewRequest("OPTIONS", "/api/items", nil)
+	req.Header.Set("Origin", "https://stoqr.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "content-type")
+	rr := httptest.NewRecorder()
+	server.Router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusNoContent {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNoContent)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("wrong allowed origin: want %q, got %q", "*", got)
+	}
+	if got := rr.Header().Get("Access-Control-Max-Age"); got != "600" {
+		t.Errorf("wrong max age: want %q, got %q", "600", got)
+	}
+}
+
+func TestCORSPreflightRejected(t *testing.T) {
+	config := DefaultCORSConfig()
+	config.AllowedOrigins = []string{"https://stoqr.example.com"}
+	server := newCORSRouter(config)
+
+	cases := []struct {
+		name, origin, method, headers string
+		want                          int
+	}{
+		{"origin", "https://evil.example.com", "GET", "", http.StatusForbidden},
+		{"method", "https://stoqr.example.com", "PATCH", "", http.StatusMethodNotAllowed},
+		{"header", "https://stoqr.example.com", "GET", "X-Secret", http.StatusForbidden},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req, _ := http.NewRequest("OPTIONS", "/api/items", nil)
+			req.Header.Set("Origin", c.origin)
+			req.Header.Set("Access-Control-Request-Method", c.method)
+			if c.headers != "" {
+				req.Header.Set("Access-Control-Request-Headers", c.headers)
+			}
+			rr := httptest.NewRecorder()
+			server.Router.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != c.want {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, c.want)
+			}
+		})
+	}
+}
+
+func TestCORSRouteOverride(t *testing.T) {
+	config := DefaultCORSConfig()
+	config.AllowedOrigins = []string{"https://stoqr.example.com"}
+	server := newCORSRouter(config)
+	server.CORS.Route("/api/assets/{serial}/qr", DefaultCORSConfig())
+
+	req, _ := http.NewRequest("GET", "/api/assets/SN-1/qr", nil)
+	req.Header.Set("Origin", "https://labels.example.net")
+	rr := httptest.NewRecorder()
+	server.Router.ServeHTTP(rr, req)
+
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("wrong allowed origin: want %q, got %q", "*", got)
+	}
+}
+
+func TestCORSCredentialsNotForAnyOrigin(t *testing.T) {
+	config := DefaultCORSConfig()
+	config.AllowedOrigins = []string{"*", "https://stoqr.example.com"}
+	config.AllowCredentials = true
+	server := newCORSRouter(config)
+
+	cases := []struct {
+		origin      string
+		want        string
+		credentials string
+	}{
+		{"https://stoqr.example.com", "https://stoqr.example.com", "true"},
+		{"https://evil.example.com", "*", ""},
+	}
+	for _, c := range cases {
+		req, _ := http.NewRequest("GET", "/api/items", nil)
+		req.Header.Set("Origin", c.origin)
+		rr := httptest.NewRecorder()
+		server.Router.ServeHTTP(rr, req)
+
+		if got := rr.Header().Get("Access-Control-Allow-Origin"); got != c.want {
+			t.Errorf("wrong allowed origin for %s: want %q, got %q", c.origin, c.want, got)
+		}
+		if got := rr.Header().Get("Access-Control-Allow-Credentials"); got != c.credentials {
+			t.Errorf("wrong allowed credentials for %s: want %q, got %q", c.origin, c.credentials, got)
+		}
+	}
+}
+
+func TestCORSConfigFromEnvCredentialsWithAnyOrigin(t *testing.T) {
+	setenv(t, "STOQR_API_CORS_CREDENTIALS", "true")
+
+	if _, err := CORSConfigFromEnv(); err == nil {
+		t.Error("expected an error for credentials with any origin")
+	}
+
+	setenv(t, "STOQR_API_CORS_ORIGINS", "https://stoqr.example.com")
+	config, err := CORSConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.AllowCredentials {
+		t.Errorf("wrong credentials: want %v, got %v", true, config.AllowCredentials)
+	}
+}
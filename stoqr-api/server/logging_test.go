@@ -0,0 +1,72 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/logging"
+)
+
+func TestRequestIDs(t *testing.T) {
+	var got string
+	handler := RequestIDs(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = RequestID(w, r)
+	}))
+
+	tests := []struct {
+		id   string
+		kept bool
+	}{
+		{"abc-123", true},
+		{"", false},
+		{"with spaces", false},
+		{strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodGet, "/api/items", nil)
+		request.Header.Set(RequestIDHeader, test.id)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, request)
+
+		if id := rr.Header().Get(RequestIDHeader); id != got || id == "" {
+			t.Errorf("wrong response id: want %v, got %v", got, id)
+		}
+		if kept := got == test.id; kept != test.kept {
+			t.Errorf("wrong id for %q: got %v", test.id, got)
+		}
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	buffer := bytes.Buffer{}
+	handler, _ := logging.NewHandler(&buffer, slog.LevelInfo, logging.FormatJSON)
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(handler))
+	server := RequestIDs(AccessLog(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	})))
+	request := httptest.NewRequest(http.MethodPost, "/api/items", nil)
+	request.Header.Set(RequestIDHeader, "abc")
+
+	server.ServeHTTP(httptest.NewRecorder(), request)
+
+	record := map[string]interface{}{}
+	if err := json.Unmarshal(buffer.Bytes(), &record); err != nil {
+		t.Fatalf("wrong record: %v\n%s", err, buffer.String())
+	}
+	want := map[string]interface{}{"msg": "request", "method": "POST", "path": "/api/items", "status": 201.0, "size": 7.0, "request_id": "abc"}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("wrong %v: want %v, got %v", key, value, record[key])
+		}
+	}
+	if _, ok := record["latency_ms"].(float64); !ok {
+		t.Errorf("wrong latency: got %v", record["latency_ms"])
+	}
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// HTTPMetrics counts the requests and measures their latency per route
+type HTTPMetrics struct {
+	Requests *prometheus.CounterVec
+	Duration *prometheus.HistogramVec
+}
+
+// Middleware measures the requests matching a route of the router, labelled with the
+// template of the route instead of the path to keep the series bounded
+func (httpMetrics *HTTPMetrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		route := routeTemplate(r)
+		httpMetrics.Requests.WithLabelValues(r.Method, route, strconv.Itoa(recorder.status)).Inc()
+		httpMetrics.Duration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// NewHTTPMetrics registers the metrics of the requests into a registry
+func NewHTTPMetrics(registerer prometheus.Registerer) *HTTPMetrics {
+	factory := promauto.With(registerer)
+	return &HTTPMetrics{
+		Requests: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "stoqr_http_requests_total",
+			Help: "Requests served by route and status code.",
+		}, []string{"method", "route", "code"}),
+		Duration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "stoqr_http_request_duration_seconds",
+			Help:    "Latency of the requests by route.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+	}
+}
+
+// routeTemplate returns the path template of the route matched by a request, its path if
+// it didn't match any
+func routeTemplate(r *http.Request) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return r.URL.Path
+}
+
+// statusRecorder records the status code and the size of the body written to a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	wrote  bool
+	size   int
+}
+
+// WriteHeader records the status code and writes it
+func (recorder *statusRecorder) WriteHeader(status int) {
+	if !recorder.wrote {
+		recorder.status, recorder.wrote = status, true
+	}
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+// Write writes to the response, with an OK status if none was written
+func (recorder *statusRecorder) Write(data []byte) (int, error) {
+	recorder.wrote = true
+	n, err := recorder.ResponseWriter.Write(data)
+	recorder.size += n
+	return n, err
+}
+
+// Flush sends the buffered data to the client if the response supports it
+func (recorder *statusRecorder) Flush() {
+	if flusher, ok := recorder.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func TestHTTPMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	router := mux.NewRouter()
+	router.Use(NewHTTPMetrics(registry).Middleware)
+	router.HandleFunc("/api/items/{itemId}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	for _, path := range []string{"/api/items/1", "/api/items/2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	rr := httptest.NewRecorder()
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`stoqr_http_requests_total{code="404",method="GET",route="/api/items/{itemId}"} 2`,
+		`stoqr_http_request_duration_seconds_count{method="GET",route="/api/items/{itemId}"} 2`,
+	} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("wrong metrics: want %v, got\n%s", want, rr.Body.String())
+		}
+	}
+}
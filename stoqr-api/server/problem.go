@@ -0,0 +1,60 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ProblemContentType is the media type of the problem details (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// RequestIDHeader is the header carrying the ID of a request
+const RequestIDHeader = "X-Request-ID"
+
+// Problem represents the details of an error returned to the client (RFC 7807)
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestID string       `json:"requestId,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError represents a validation error of a field of the request
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// WriteProblem writes a problem as the response to a request. The type defaults to about:blank,
+// the title to the text of the status and the instance to the path of the request.
+func WriteProblem(w http.ResponseWriter, r *http.Request, problem Problem) {
+	if problem.Status == 0 {
+		problem.Status = http.StatusInternalServerError
+	}
+	if problem.Type == "" {
+		problem.Type = "about:blank"
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
+	}
+	if problem.Instance == "" {
+		problem.Instance = r.URL.Path
+	}
+	if problem.RequestID == "" {
+		problem.RequestID = RequestID(w, r)
+	}
+	w.Header().Set("content-type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// RequestID returns the ID of a request, the one set in the response if any
+func RequestID(w http.ResponseWriter, r *http.Request) string {
+	if id := w.Header().Get(RequestIDHeader); id != "" {
+		return id
+	}
+	return r.Header.Get(RequestIDHeader)
+}
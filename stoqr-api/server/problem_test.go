@@ -0,0 +1,52 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteProblem(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/items/1/restock", nil)
+	req.Header.Set(RequestIDHeader, "abc")
+	rr := httptest.NewRecorder()
+
+	WriteProblem(rr, req, Problem{Status: http.StatusBadRequest, Errors: []FieldError{{Field: "quantity", Message: "must be a positive number"}}})
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+	if contentType := rr.Header().Get("content-type"); contentType != ProblemContentType {
+		t.Errorf("wrong content type: want %v, got %v", ProblemContentType, contentType)
+	}
+	problem := Problem{}
+	if err := json.NewDecoder(rr.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	want := Problem{
+		Type:      "about:blank",
+		Title:     "Bad Request",
+		Status:    http.StatusBadRequest,
+		Instance:  "/api/items/1/restock",
+		RequestID: "abc",
+	}
+	if problem.Type != want.Type || problem.Title != want.Title || problem.Status != want.Status ||
+		problem.Instance != want.Instance || problem.RequestID != want.RequestID {
+		t.Errorf("wrong problem: want %+v, got %+v", want, problem)
+	}
+	if len(problem.Errors) != 1 || problem.Errors[0].Field != "quantity" {
+		t.Errorf("wrong field errors: want %v, got %v", "quantity", problem.Errors)
+	}
+}
+
+func TestRequestIDFromResponse(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "client")
+	rr := httptest.NewRecorder()
+	rr.Header().Set(RequestIDHeader, "server")
+
+	if id := RequestID(rr, req); id != "server" {
+		t.Errorf("wrong request id: want %v, got %v", "server", id)
+	}
+}
@@ -0,0 +1,235 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/ratelimit"
+)
+
+// APIKeyHeader is the header identifying the client of a request by an api key
+const APIKeyHeader = "X-API-Key"
+
+// Stores of the token buckets
+const (
+	RateLimitStoreMemory   = "memory"
+	RateLimitStoreDatabase = "database"
+)
+
+// DefaultRateLimitSweepSchedule is the cron schedule deleting the idle buckets of the database by default
+const DefaultRateLimitSweepSchedule = "@hourly"
+
+// RateLimitConfig are the limits of the requests to the protected routes. They apply together:
+// per client address, per api key when the request has one and per item when the route has one.
+// The api keys aren't verified, the limit per key caps a key used from many addresses.
+type RateLimitConfig struct {
+	IP     ratelimit.Limit
+	APIKey ratelimit.Limit
+	Item   ratelimit.Limit
+	// TrustProxy takes the address of the client from the last X-Forwarded-For entry, the one
+	// added by the proxy in front of the api
+	TrustProxy bool
+	// Store is where the buckets are kept, memory (per replica) or database (shared)
+	Store string
+}
+
+// DefaultRateLimitConfig returns the limits used when nothing is set, kept in memory
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		IP:     ratelimit.Limit{Requests: 120, Period: time.Minute},
+		APIKey: ratelimit.Limit{Requests: 600, Period: time.Minute},
+		Item:   ratelimit.Limit{Requests: 60, Period: time.Minute},
+		Store:  RateLimitStoreMemory,
+	}
+}
+
+// RateLimitConfigFromEnv reads the limits from the environment, falling back to the defaults.
+// The limits are STOQR_API_RATE_LIMIT_IP, STOQR_API_RATE_LIMIT_KEY and STOQR_API_RATE_LIMIT_ITEM
+// (requests/period or off), the proxy is trusted with STOQR_API_RATE_LIMIT_TRUST_PROXY and the
+// store is STOQR_API_RATE_LIMIT_STORE.
+func RateLimitConfigFromEnv() (RateLimitConfig, error) {
+	config := DefaultRateLimitConfig()
+	limits := []struct {
+		name  string
+		value *ratelimit.Limit
+	}{
+		{"STOQR_API_RATE_LIMIT_IP", &config.IP},
+		{"STOQR_API_RATE_LIMIT_KEY", &config.APIKey},
+		{"STOQR_API_RATE_LIMIT_ITEM", &config.Item},
+	}
+	for _, l := range limits {
+		if value, ok := os.LookupEnv(l.name); ok {
+			limit, err := ratelimit.ParseLimit(value)
+			if err != nil {
+				return config, fmt.Errorf("invalid %s: %w", l.name, err)
+			}
+			*l.value = limit
+		}
+	}
+	if value := os.Getenv("STOQR_API_RATE_LIMIT_TRUST_PROXY"); value != "" {
+		trust, err := strconv.ParseBool(value)
+		if err != nil {
+			return config, err
+		}
+		config.TrustProxy = trust
+	}
+	if value := os.Getenv("STOQR_API_RATE_LIMIT_STORE"); value != "" {
+		if value != RateLimitStoreMemory && value != RateLimitStoreDatabase {
+			return config, fmt.Errorf("invalid STOQR_API_RATE_LIMIT_STORE %q", value)
+		}
+		config.Store = value
+	}
+	return config, nil
+}
+
+// LongestPeriod returns the longest period of the limits, a bucket unused for that long is full
+func (config RateLimitConfig) LongestPeriod() time.Duration {
+	longest := time.Duration(0)
+	for _, limit := range []ratelimit.Limit{config.IP, config.APIKey, config.Item} {
+		if limit.Period > longest {
+			longest = limit.Period
+		}
+	}
+	return longest
+}
+
+// RateLimiter is a middleware limiting the rate of the requests to the protected routes, the
+// requests over a limit are answered with 429 Too Many Requests
+type RateLimiter struct {
+	Config RateLimitConfig
+	Store  ratelimit.Store
+	routes map[string]bool
+}
+
+// NewRateLimiter creates a rate limiter keeping its buckets in a store, protecting no route
+func NewRateLimiter(config RateLimitConfig, store ratelimit.Store) *RateLimiter {
+	return &RateLimiter{Config: config, Store: store, routes: map[string]bool{}}
+}
+
+// Route protects the routes with a path template (e.g. /api/items/withdraw/{itemId})
+func (limiter *RateLimiter) Route(template string) {
+	limiter.routes[template] = true
+}
+
+// Middleware takes a token for the request from each of its buckets and sets the RateLimit
+// headers of the most restrictive one. The requests are let through if the store fails, and
+// refused if other replicas keep a bucket busy since it is then likely exhausted.
+func (limiter *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions || !limiter.routes[routeTemplate(r)] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		result, ok, err := limiter.take(r)
+		if errors.Is(err, ratelimit.ErrContention) {
+			w.Header().Set("Retry-After", "1")
+			WriteProblem(w, r, Problem{
+				Status: http.StatusTooManyRequests,
+				Type:   "/problems/rate-limited",
+				Title:  "Too many requests",
+				Detail: "the rate limit is busy, retry later",
+			})
+			return
+		}
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit.Requests))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", result.Limit.Requests, ceilSeconds(result.Limit.Period)))
+		if !result.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			WriteProblem(w, r, Problem{
+				Status: http.StatusTooManyRequests,
+				Type:   "/problems/rate-limited",
+				Title:  "Too many requests",
+				Detail: fmt.Sprintf("the limit of %d requests every %s is exceeded", result.Limit.Requests, result.Limit.Period),
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitBucket is the key of a bucket of a request and its limit, the api keys are hashed
+// so they aren't kept in the store
+type rateLimitBucket struct {
+	key   string
+	limit ratelimit.Limit
+}
+
+// take takes a token from the buckets of the client address, the api key and the item of a
+// request, in this order, stopping at the first empty one so that a limited client doesn't
+// use up the tokens of an item. It returns the most restrictive result, false if no bucket
+// could be used, or ratelimit.ErrContention if a bucket is too busy to take a token from.
+func (limiter *RateLimiter) take(r *http.Request) (ratelimit.Result, bool, error) {
+	buckets := []rateLimitBucket{{"ip:" + limiter.clientIP(r), limiter.Config.IP}}
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		sum := sha256.Sum256([]byte(key))
+		buckets = append(buckets, rateLimitBucket{"key:" + hex.EncodeToString(sum[:16]), limiter.Config.APIKey})
+	}
+	if id, ok := mux.Vars(r)["itemId"]; ok {
+		buckets = append(buckets, rateLimitBucket{"item:" + id, limiter.Config.Item})
+	}
+	restrictive, ok := ratelimit.Result{}, false
+	for _, bucket := range buckets {
+		if !bucket.limit.Enabled() {
+			continue
+		}
+		result, err := limiter.Store.Take(r.Context(), bucket.key, bucket.limit)
+		if errors.Is(err, ratelimit.ErrContention) {
+			slog.WarnContext(r.Context(), "Taking a rate limit token", "error", err)
+			return ratelimit.Result{}, false, err
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Taking a rate limit token", "error", err)
+			continue
+		}
+		if !ok || !result.Allowed || result.Remaining < restrictive.Remaining {
+			restrictive, ok = result, true
+		}
+		if !result.Allowed {
+			break
+		}
+	}
+	return restrictive, ok, nil
+}
+
+// clientIP returns the address of the client of a request, the IPv6 addresses are grouped by
+// their /64 prefix as a client usually has all of them
+func (limiter *RateLimiter) clientIP(r *http.Request) string {
+	address := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		address = host
+	}
+	if forwarded := r.Header.Values("X-Forwarded-For"); limiter.Config.TrustProxy && len(forwarded) > 0 {
+		if entries := splitList(forwarded[len(forwarded)-1]); len(entries) > 0 {
+			address = entries[len(entries)-1]
+		}
+	}
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return address
+	}
+	if ip.To4() == nil {
+		return ip.Mask(net.CIDRMask(64, 128)).String() + "/64"
+	}
+	return ip.String()
+}
+
+// ceilSeconds rounds a duration up to whole seconds
+func ceilSeconds(duration time.Duration) int {
+	return int(math.Ceil(duration.Seconds()))
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/ratelimit"
+)
+
+// failingStore is a store that is unavailable
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+// contendedStore is a store whose buckets are kept busy by other replicas
+type contendedStore struct{}
+
+func (contendedStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, ratelimit.ErrContention
+}
+
+func rateLimitedRouter(config RateLimitConfig, store ratelimit.Store) *mux.Router {
+	limiter := NewRateLimiter(config, store)
+	limiter.Route("/api/items/withdraw/{itemId}")
+	router := mux.NewRouter()
+	router.Use(limiter.Middleware)
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	router.HandleFunc("/api/items/withdraw/{itemId}", ok)
+	router.HandleFunc("/api/items/{itemId}", ok)
+	return router
+}
+
+func withdraw(router http.Handler, path string, remoteAddr string, headers map[string]string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	request.RemoteAddr = remoteAddr
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, request)
+	return rr
+}
+
+func TestRateLimiter(t *testing.T) {
+	config := DefaultRateLimitConfig()
+	config.IP = ratelimit.Limit{Requests: 2, Period: time.Minute}
+	router := rateLimitedRouter(config, ratelimit.NewMemoryStore())
+
+	first := withdraw(router, "/api/items/withdraw/1", "10.0.0.1:1234", nil)
+	withdraw(router, "/api/items/withdraw/2", "10.0.0.1:1234", nil)
+	limited := withdraw(router, "/api/items/withdraw/3", "10.0.0.1:1234", nil)
+	other := withdraw(router, "/api/items/withdraw/1", "10.0.0.2:1234", nil)
+	unprotected := withdraw(router, "/api/items/1", "10.0.0.1:1234", nil)
+
+	if first.Code != http.StatusOK || first.Header().Get("RateLimit-Remaining") != "1" {
+		t.Errorf("wrong first response: %v remaining %v", first.Code, first.Header().Get("RateLimit-Remaining"))
+	}
+	if first.Header().Get("RateLimit-Limit") != "2" || first.Header().Get("RateLimit-Policy") != "2;w=60" {
+		t.Errorf("wrong policy: got %v %v", first.Header().Get("RateLimit-Limit"), first.Header().Get("RateLimit-Policy"))
+	}
+	if limited.Code != http.StatusTooManyRequests {
+		t.Errorf("handler returned wrong status code: got %v want %v", limited.Code, http.StatusTooManyRequests)
+	}
+	if limited.Header().Get("Retry-After") != "30" || limited.Header().Get("content-type") != ProblemContentType {
+		t.Errorf("wrong limited response: retry after %v, content type %v", limited.Header().Get("Retry-After"), limited.Header().Get("content-type"))
+	}
+	if other.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", other.Code, http.StatusOK)
+	}
+	if unprotected.Header().Get("RateLimit-Limit") != "" {
+		t.Errorf("wrong unprotected route: got limit %v", unprotected.Header().Get("RateLimit-Limit"))
+	}
+}
+
+func TestRateLimiterItem(t *testing.T) {
+	config := DefaultRateLimitConfig()
+	config.Item = ratelimit.Limit{Requests: 1, Period: time.Minute}
+	router := rateLimitedRouter(config, ratelimit.NewMemoryStore())
+
+	first := withdraw(router, "/api/items/withdraw/1", "10.0.0.1:1234", nil)
+	second := withdraw(router, "/api/items/withdraw/1", "10.0.0.2:1234", nil)
+	other := withdraw(router, "/api/items/withdraw/2", "10.0.0.2:1234", nil)
+
+	if first.Code != http.StatusOK || second.Code != http.StatusTooManyRequests || other.Code != http.StatusOK {
+		t.Errorf("wrong status codes: want 200 429 200, got %v %v %v", first.Code, second.Code, other.Code)
+	}
+	if second.Header().Get("RateLimit-Limit") != "1" {
+		t.Errorf("wrong limit: want the one of the item, got %v", second.Header().Get("RateLimit-Limit"))
+	}
+}
+
+func TestRateLimiterAPIKey(t *testing.T) {
+	config := DefaultRateLimitConfig()
+	config.APIKey = ratelimit.Limit{Requests: 1, Period: time.Minute}
+	router := rateLimitedRouter(config, ratelimit.NewMemoryStore())
+	key := map[string]string{APIKeyHeader: "secret"}
+
+	first := withdraw(router, "/api/items/withdraw/1", "10.0.0.1:1234", key)
+	second := withdraw(router, "/api/items/withdraw/2", "10.0.0.2:1234", key)
+	anonymous := withdraw(router, "/api/items/withdraw/2", "10.0.0.2:1234", nil)
+
+	if first.Code != http.StatusOK || second.Code != http.StatusTooManyRequests || anonymous.Code != http.StatusOK {
+		t.Errorf("wrong status codes: want 200 429 200, got %v %v %v", first.Code, second.Code, anonymous.Code)
+	}
+}
+
+func TestRateLimiterFailingStore(t *testing.T) {
+	router := rateLimitedRouter(DefaultRateLimitConfig(), failingStore{})
+
+	rr := withdraw(router, "/api/items/withdraw/1", "10.0.0.1:1234", nil)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+}
+
+func TestRateLimiterContendedStore(t *testing.T) {
+	router := rateLimitedRouter(DefaultRateLimitConfig(), contendedStore{})
+
+	rr := withdraw(router, "/api/items/withdraw/1", "10.0.0.1:1234", nil)
+
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusTooManyRequests)
+	}
+	if retry := rr.Header().Get("Retry-After"); retry != "1" {
+		t.Errorf("wrong retry after: want %v, got %v", "1", retry)
+	}
+}
+
+func TestRateLimiterClientIP(t *testing.T) {
+	tests := []struct {
+		remoteAddr string
+		forwarded  string
+		trust      bool
+		ip         string
+	}{
+		{"10.0.0.1:1234", "", false, "10.0.0.1"},
+		{"10.0.0.1:1234", "203.0.113.7", false, "10.0.0.1"},
+		{"10.0.0.1:1234", "198.51.100.1, 203.0.113.7", true, "203.0.113.7"},
+		{"[2001:db8:1:2:3:4:5:6]:1234", "", false, "2001:db8:1:2::/64"},
+	}
+
+	for _, test := range tests {
+		limiter := NewRateLimiter(RateLimitConfig{TrustProxy: test.trust}, nil)
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.RemoteAddr = test.remoteAddr
+		if test.forwarded != "" {
+			request.Header.Set("X-Forwarded-For", test.forwarded)
+		}
+		if ip := limiter.clientIP(request); ip != test.ip {
+			t.Errorf("wrong client ip: want %v, got %v", test.ip, ip)
+		}
+	}
+}
+
+func TestRateLimitConfigFromEnv(t *testing.T) {
+	os.Setenv("STOQR_API_RATE_LIMIT_IP", "10/s")
+	os.Setenv("STOQR_API_RATE_LIMIT_ITEM", "off")
+	os.Setenv("STOQR_API_RATE_LIMIT_STORE", "database")
+	defer os.Unsetenv("STOQR_API_RATE_LIMIT_IP")
+	defer os.Unsetenv("STOQR_API_RATE_LIMIT_ITEM")
+	defer os.Unsetenv("STOQR_API_RATE_LIMIT_STORE")
+
+	config, err := RateLimitConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.IP != (ratelimit.Limit{Requests: 10, Period: time.Second}) || config.Item.Enabled() || config.Store != RateLimitStoreDatabase {
+		t.Errorf("wrong config: %+v", config)
+	}
+
+	os.Setenv("STOQR_API_RATE_LIMIT_STORE", "redis")
+	if _, err := RateLimitConfigFromEnv(); err == nil {
+		t.Errorf("wrong error: want an invalid store, got nil")
+	}
+}
@@ -2,8 +2,12 @@ package server
 
 import (
 	"context"
-	"log"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -13,32 +17,92 @@ import (
 type Server struct {
 	Server *http.Server
 	Router *mux.Router
+	Config Config
+	CORS   *CORS
+	errors chan error
+	// draining is set to 1 once the server starts shutting down
+	draining int32
 }
 
-// NewServer creates a Server instance
-func NewServer() *Server {
-	server := &Server{}
+// NewServer creates a Server instance whose router applies the CORS policy of the configuration,
+// every request gets an ID and is logged
+func NewServer(config Config) *Server {
+	server := &Server{Config: config, CORS: NewCORS(config.CORS), errors: make(chan error, 1)}
 	server.Router = mux.NewRouter()
-	server.Server = &http.Server{Addr: ":8080", Handler: server.Router}
+	server.Router.Use(server.CORS.Middleware)
+	server.Server = &http.Server{
+		Addr:              config.Addr,
+		Handler:           RequestIDs(AccessLog(server.Router)),
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		ReadTimeout:       config.ReadTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+	}
 	return server
 }
 
-// Start starts the server
-func (server *Server) Start() {
-	log.Println("Serving at :8080")
-	go server.Server.ListenAndServe()
+// Start listens on the address of the server and serves in the background, failing if it
+// can't listen or load the TLS certificate. Errors while serving are sent to Errors.
+func (server *Server) Start() error {
+	listener, err := net.Listen("tcp", server.Config.Addr)
+	if err != nil {
+		return err
+	}
+	if server.Config.TLS() {
+		cert, err := newCertificate(server.Config.CertFile, server.Config.KeyFile)
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		server.Server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: cert.GetCertificate}
+	}
+	slog.Info("Serving", "addr", listener.Addr().String(), "tls", server.Config.TLS())
+	server.Config.Addr = listener.Addr().String()
+	go func() {
+		var err error
+		if server.Config.TLS() {
+			err = server.Server.ServeTLS(listener, "", "")
+		} else {
+			err = server.Server.Serve(listener)
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			server.errors <- err
+		}
+	}()
+	return nil
 }
 
-// Stop stops the server
-func (server *Server) Stop() {
-	log.Println("Shutting down")
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+// Errors returns the channel receiving the error that stopped the server from serving
+func (server *Server) Errors() <-chan error {
+	return server.errors
+}
+
+// Stop reports the server as draining, keeps serving for the drain delay, then stops
+// accepting connections and waits for the requests in flight up to the shutdown timeout,
+// closing the remaining connections after it
+func (server *Server) Stop() error {
+	atomic.StoreInt32(&server.draining, 1)
+	if server.Config.DrainDelay > 0 {
+		slog.Info("Draining", "delay", server.Config.DrainDelay.String())
+		time.Sleep(server.Config.DrainDelay)
+	}
+	slog.Info("Shutting down")
+	ctx, cancel := context.WithTimeout(context.Background(), server.Config.ShutdownTimeout)
 	defer cancel()
-	server.Server.Shutdown(ctx)
+	if err := server.Server.Shutdown(ctx); err != nil {
+		server.Server.Close()
+		return err
+	}
+	return nil
+}
+
+// Draining returns true once the server started shutting down
+func (server *Server) Draining() bool {
+	return atomic.LoadInt32(&server.draining) == 1
 }
 
-// Options is a handler for the OPTIONS method used for CORS
+// Options is a handler for the OPTIONS method, the CORS preflight requests are answered by
+// the CORS middleware before reaching it
 func Options(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Origin, X-Requested-With, Content-Type, Accept")
+	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// certificate loads a TLS certificate and reloads it when its files change, so a renewed
+// certificate (e.g. by cert-manager) is used without restarting
+type certificate struct {
+	certFile string
+	keyFile  string
+	mutex    sync.Mutex
+	cert     *tls.Certificate
+	modified time.Time
+}
+
+func newCertificate(certFile string, keyFile string) (*certificate, error) {
+	c := &certificate{certFile: certFile, keyFile: keyFile}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetCertificate returns the current certificate, reloading it if its files were modified.
+// The previous certificate is kept if the new one can't be loaded.
+func (c *certificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.lastModified().After(c.modified) {
+		if err := c.load(); err != nil {
+			slog.Error("Reloading the TLS certificate", "error", err)
+		} else {
+			slog.Info("Reloaded the TLS certificate")
+		}
+	}
+	return c.cert, nil
+}
+
+func (c *certificate) load() error {
+	modified := c.lastModified()
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+	c.cert, c.modified = &cert, modified
+	return nil
+}
+
+// lastModified returns the latest modification time of the certificate and key files
+func (c *certificate) lastModified() time.Time {
+	var latest time.Time
+	for _, file := range []string{c.certFile, c.keyFile} {
+		if info, err := os.Stat(file); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing records a server span for each request matching a route of the router, continuing
+// the trace of the traceparent header of the request if any. The span is named after the
+// template of the route instead of the path to keep the names bounded.
+func Tracing(provider trace.TracerProvider) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		annotated := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			span := trace.SpanFromContext(r.Context())
+			span.SetAttributes(semconv.HTTPRoute(routeTemplate(r)))
+			if id := RequestID(w, r); id != "" {
+				span.SetAttributes(attribute.String("http.request_id", id))
+			}
+			next.ServeHTTP(w, r)
+		})
+		return otelhttp.NewHandler(annotated, "",
+			otelhttp.WithTracerProvider(provider),
+			otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
+				return r.Method + " " + routeTemplate(r)
+			}))
+	}
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	router := mux.NewRouter()
+	router.Use(Tracing(provider))
+	var handled trace.SpanContext
+	router.HandleFunc("/api/items/{itemId}", func(w http.ResponseWriter, r *http.Request) {
+		handled = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	request := httptest.NewRequest(http.MethodGet, "/api/items/1", nil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	request.Header.Set(RequestIDHeader, "abc")
+
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].SpanContext().SpanID() != handled.SpanID() {
+		t.Fatalf("wrong spans: want the span of the handler, got %v", spans)
+	}
+	span := spans[0]
+	if span.Name() != "GET /api/items/{itemId}" {
+		t.Errorf("wrong name: want %v, got %v", "GET /api/items/{itemId}", span.Name())
+	}
+	if span.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || span.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("wrong parent: want %v, got %v", "00f067aa0ba902b7", span.Parent().SpanID())
+	}
+	if span.Status().Code != codes.Error {
+		t.Errorf("wrong status: want %v, got %v", codes.Error, span.Status().Code)
+	}
+	attributes := map[string]string{}
+	for _, attribute := range span.Attributes() {
+		attributes[string(attribute.Key)] = attribute.Value.Emit()
+	}
+	if attributes["http.route"] != "/api/items/{itemId}" || attributes["http.request_id"] != "abc" {
+		t.Errorf("wrong attributes: %v", attributes)
+	}
+}
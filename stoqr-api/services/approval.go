@@ -0,0 +1,220 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+	"github.com/leandroberetta/stoqr/stoqr-api/server"
+	"gorm.io/gorm"
+)
+
+// ApprovalService contains the business logic of approval policies and withdrawal requests
+type ApprovalService struct {
+	Repository repositories.ApprovalRepository
+	Notify     func(request models.WithdrawalRequest)
+}
+
+// CreatePolicy is the api method to create an approval policy
+func (svc *ApprovalService) CreatePolicy(w http.ResponseWriter, r *http.Request) {
+	policy := models.Policy{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&policy)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	defer r.Body.Close()
+	err = svc.Repository.CreatePolicy(&policy)
+	if err != nil {
+		writeProblem(w, r, statusForApprovalError(err), err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(policy)
+}
+
+// ReadPolicy is the api method to get an approval policy
+func (svc *ApprovalService) ReadPolicy(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["policyId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	policy, err := svc.Repository.ReadPolicy(id)
+	if err != nil {
+		writeProblem(w, r, statusForApprovalError(err), err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(policy)
+}
+
+// ReadPolicies is the api method to get every approval policy
+func (svc *ApprovalService) ReadPolicies(w http.ResponseWriter, r *http.Request) {
+	policies, err := svc.Repository.ReadPolicies()
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(policies)
+}
+
+// UpdatePolicy is the api method to update an approval policy
+func (svc *ApprovalService) UpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["policyId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	policy := models.Policy{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&policy)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	defer r.Body.Close()
+	err = svc.Repository.UpdatePolicy(id, policy)
+	if err != nil {
+		writeProblem(w, r, statusForApprovalError(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeletePolicy is the api method to delete an approval policy
+func (svc *ApprovalService) DeletePolicy(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["policyId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	err = svc.Repository.DeletePolicy(id)
+	if err != nil {
+		writeProblem(w, r, statusForApprovalError(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReadRequest is the api method to get a withdrawal request
+func (svc *ApprovalService) ReadRequest(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["requestId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	request, err := svc.Repository.ReadRequest(id)
+	if err != nil {
+		writeProblem(w, r, statusForApprovalError(err), err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(request)
+}
+
+// ReadRequests is the api method to get the withdrawal requests, optionally filtered
+// by status and requester
+func (svc *ApprovalService) ReadRequests(w http.ResponseWriter, r *http.Request) {
+	filter := repositories.RequestFilter{Status: r.FormValue("status"), Requester: r.FormValue("requester")}
+	requests, err := svc.Repository.ReadRequests(filter)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(requests)
+}
+
+// ApproveRequest is the api method to accept a withdrawal request, taking its quantity out of stock
+func (svc *ApprovalService) ApproveRequest(w http.ResponseWriter, r *http.Request) {
+	svc.decide(w, r, svc.Repository.ApproveRequest)
+}
+
+// RejectRequest is the api method to reject a withdrawal request
+func (svc *ApprovalService) RejectRequest(w http.ResponseWriter, r *http.Request) {
+	svc.decide(w, r, svc.Repository.RejectRequest)
+}
+
+func (svc *ApprovalService) decide(w http.ResponseWriter, r *http.Request, decide func(int, models.Decision) (models.WithdrawalRequest, error)) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["requestId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	decision := models.Decision{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&decision)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if decision.Approver == "" {
+		writeProblem(w, r, http.StatusBadRequest, errInvalidRequest, server.FieldError{Field: "approver", Message: "is required"})
+		return
+	}
+	defer r.Body.Close()
+	request, err := decide(id, decision)
+	if err != nil {
+		writeProblem(w, r, statusForApprovalError(err), err)
+		return
+	}
+	if svc.Notify != nil {
+		go svc.Notify(request)
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(request)
+}
+
+// AddRoutes configures the approval routes into a given router
+func (svc *ApprovalService) AddRoutes(r *mux.Router) {
+	r.HandleFunc("/api/policies", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/policies", svc.CreatePolicy).Methods(http.MethodPost)
+	r.HandleFunc("/api/policies", svc.ReadPolicies).Methods(http.MethodGet)
+	r.HandleFunc("/api/policies/{policyId}", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/policies/{policyId}", svc.ReadPolicy).Methods(http.MethodGet)
+	r.HandleFunc("/api/policies/{policyId}", svc.UpdatePolicy).Methods(http.MethodPut)
+	r.HandleFunc("/api/policies/{policyId}", svc.DeletePolicy).Methods(http.MethodDelete)
+	r.HandleFunc("/api/withdrawal-requests", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/withdrawal-requests", svc.ReadRequests).Methods(http.MethodGet)
+	r.HandleFunc("/api/withdrawal-requests/{requestId}", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/withdrawal-requests/{requestId}", svc.ReadRequest).Methods(http.MethodGet)
+	r.HandleFunc("/api/withdrawal-requests/{requestId}/approve", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/withdrawal-requests/{requestId}/approve", svc.ApproveRequest).Methods(http.MethodPost)
+	r.HandleFunc("/api/withdrawal-requests/{requestId}/reject", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/withdrawal-requests/{requestId}/reject", svc.RejectRequest).Methods(http.MethodPost)
+}
+
+// NewApprovalService creates a new approval service, the decisions are notified once Notify is set
+func NewApprovalService(repository repositories.ApprovalRepository) *ApprovalService {
+	return &ApprovalService{Repository: repository}
+}
+
+func statusForApprovalError(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repositories.ErrRequestClosed),
+		errors.Is(err, repositories.ErrInsufficientStock),
+		errors.Is(err, repositories.ErrSerialized),
+		errors.Is(err, repositories.ErrBorrowable):
+		return http.StatusConflict
+	case errors.Is(err, repositories.ErrInvalidPolicy),
+		errors.Is(err, repositories.ErrInvalidCategory),
+		errors.Is(err, models.ErrInvalidQuantity):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/mocks"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+)
+
+func TestCreatePolicyBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockApprovalRepository := mocks.NewMockApprovalRepository(ctrl)
+
+	mockApprovalRepository.
+		EXPECT().
+		CreatePolicy(gomock.Any()).
+		Return(repositories.ErrInvalidPolicy)
+
+	approvalService := NewApprovalService(mockApprovalRepository)
+
+	req, err := http.NewRequest("POST", "/api/policies", bytes.NewReader([]byte(`{"itemId":1,"categoryId":2,"restricted":true}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(approvalService.CreatePolicy)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusBadRequest)
+	}
+}
+
+func TestApproveRequestNotifiesRequester(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockApprovalRepository := mocks.NewMockApprovalRepository(ctrl)
+
+	decided := models.WithdrawalRequest{ID: 1, ItemID: 1, Quantity: models.NewQuantity(10), Requester: "Alice", Status: models.RequestApproved, Approver: "Bob"}
+	mockApprovalRepository.
+		EXPECT().
+		ApproveRequest(1, models.Decision{Approver: "Bob", Comment: "Go ahead"}).
+		Return(decided, nil)
+
+	notified := make(chan models.WithdrawalRequest, 1)
+	approvalService := NewApprovalService(mockApprovalRepository)
+	approvalService.Notify = func(request models.WithdrawalRequest) {
+		notified <- request
+	}
+
+	req, err := http.NewRequest("POST", "/api/withdrawal-requests/1/approve", bytes.NewReader([]byte(`{"approver":"Bob","comment":"Go ahead"}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/withdrawal-requests/{requestId}/approve", approvalService.ApproveRequest)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+	if request := <-notified; request.Status != models.RequestApproved {
+		t.Errorf("wrong notified status: got %v want %v", request.Status, models.RequestApproved)
+	}
+}
+
+func TestRejectRequestConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockApprovalRepository := mocks.NewMockApprovalRepository(ctrl)
+
+	mockApprovalRepository.
+		EXPECT().
+		RejectRequest(1, models.Decision{Approver: "Bob"}).
+		Return(models.WithdrawalRequest{}, repositories.ErrRequestClosed)
+
+	approvalService := NewApprovalService(mockApprovalRepository)
+
+	req, err := http.NewRequest("POST", "/api/withdrawal-requests/1/reject", bytes.NewReader([]byte(`{"approver":"Bob"}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/withdrawal-requests/{requestId}/reject", approvalService.RejectRequest)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusConflict {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusConflict)
+	}
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+	"github.com/leandroberetta/stoqr/stoqr-api/server"
+	qrcode "github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+)
+
+// maxQRSize is the largest size in pixels of the QR codes
+const maxQRSize = 1024
+
+// AssetService contains the business logic of the units of serialized items, the QR codes
+// point to the public url of the api (a path relative to it if empty)
+type AssetService struct {
+	Repository repositories.AssetRepository
+	PublicURL  string
+}
+
+// CreateAsset is the api method to add a unit to a serialized item
+func (svc *AssetService) CreateAsset(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	itemID, err := strconv.Atoi(params["itemId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	asset := models.Asset{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&asset)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if asset.Serial == "" {
+		writeProblem(w, r, http.StatusBadRequest, errInvalidRequest, server.FieldError{Field: "serial", Message: "is required"})
+		return
+	}
+	defer r.Body.Close()
+	asset.ItemID = itemID
+	err = svc.Repository.CreateAsset(&asset)
+	if err != nil {
+		writeProblem(w, r, statusForAssetError(err), err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(asset)
+}
+
+// ReadAsset is the api method to get a unit and its history
+func (svc *AssetService) ReadAsset(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	asset, err := svc.Repository.ReadAsset(params["serial"])
+	if err != nil {
+		writeProblem(w, r, statusForAssetError(err), err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(asset)
+}
+
+// ReadAssets is the api method to get the units of every serialized item
+func (svc *AssetService) ReadAssets(w http.ResponseWriter, r *http.Request) {
+	svc.readAssets(w, r, 0)
+}
+
+// ReadItemAssets is the api method to get the units of a serialized item
+func (svc *AssetService) ReadItemAssets(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	itemID, err := strconv.Atoi(params["itemId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	svc.readAssets(w, r, itemID)
+}
+
+// UpdateAsset is the api method to change the status or location of a unit
+func (svc *AssetService) UpdateAsset(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	change := models.AssetEvent{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&change)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	defer r.Body.Close()
+	asset, err := svc.Repository.UpdateAsset(params["serial"], change)
+	if err != nil {
+		writeProblem(w, r, statusForAssetError(err), err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(asset)
+}
+
+// ReadAssetQR is the api method to get a PNG QR code pointing to a unit
+func (svc *AssetService) ReadAssetQR(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	asset, err := svc.Repository.ReadAsset(params["serial"])
+	if err != nil {
+		writeProblem(w, r, statusForAssetError(err), err)
+		return
+	}
+	size := 256
+	if value := r.FormValue("size"); value != "" {
+		if size, err = strconv.Atoi(value); err != nil || size <= 0 || size > maxQRSize {
+			writeProblem(w, r, http.StatusBadRequest, errInvalidRequest, server.FieldError{Field: "size", Message: fmt.Sprintf("must be between 1 and %d", maxQRSize)})
+			return
+		}
+	}
+	png, err := qrcode.Encode(svc.assetURL(asset), qrcode.Medium, size)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("content-type", "image/png")
+	w.Write(png)
+}
+
+func (svc *AssetService) readAssets(w http.ResponseWriter, r *http.Request, itemID int) {
+	assets, err := svc.Repository.ReadAssets(itemID)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(assets)
+}
+
+// AddRoutes configures the assets routes into a given router
+func (svc *AssetService) AddRoutes(r *mux.Router) {
+	r.HandleFunc("/api/assets", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/assets", svc.ReadAssets).Methods(http.MethodGet)
+	r.HandleFunc("/api/assets/{serial}", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/assets/{serial}", svc.ReadAsset).Methods(http.MethodGet)
+	r.HandleFunc("/api/assets/{serial}", svc.UpdateAsset).Methods(http.MethodPut)
+	r.HandleFunc("/api/assets/{serial}/qr", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/assets/{serial}/qr", svc.ReadAssetQR).Methods(http.MethodGet)
+	r.HandleFunc("/api/items/{itemId}/assets", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/items/{itemId}/assets", svc.CreateAsset).Methods(http.MethodPost)
+	r.HandleFunc("/api/items/{itemId}/assets", svc.ReadItemAssets).Methods(http.MethodGet)
+}
+
+// NewAssetService creates a new asset service
+func NewAssetService(repository repositories.AssetRepository) *AssetService {
+	return &AssetService{Repository: repository}
+}
+
+// assetURL returns the url of a unit under the public url of the api
+func (svc *AssetService) assetURL(asset models.Asset) string {
+	return strings.TrimSuffix(svc.PublicURL, "/") + "/api/assets/" + url.PathEscape(asset.Serial)
+}
+
+func statusForAssetError(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repositories.ErrAssetStatus):
+		return http.StatusConflict
+	case errors.Is(err, repositories.ErrNotSerialized):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
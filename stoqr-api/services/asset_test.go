@@ -0,0 +1,160 @@
+package services
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/mocks"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+)
+
+func TestCreateAssetOK(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAssetRepository := mocks.NewMockAssetRepository(ctrl)
+
+	mockAssetRepository.
+		EXPECT().
+		CreateAsset(&models.Asset{ItemID: 1, Serial: "SN-001", Location: "Shelf A"}).
+		Return(nil)
+
+	assetService := NewAssetService(mockAssetRepository)
+
+	req, err := http.NewRequest("POST", "/api/items/1/assets", bytes.NewReader([]byte(`{"serial":"SN-001","location":"Shelf A"}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/{itemId}/assets", assetService.CreateAsset)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusCreated {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusCreated)
+	}
+}
+
+func TestCreateAssetNotSerialized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAssetRepository := mocks.NewMockAssetRepository(ctrl)
+
+	mockAssetRepository.
+		EXPECT().
+		CreateAsset(gomock.Any()).
+		Return(repositories.ErrNotSerialized)
+
+	assetService := NewAssetService(mockAssetRepository)
+
+	req, err := http.NewRequest("POST", "/api/items/1/assets", bytes.NewReader([]byte(`{"serial":"SN-001"}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/{itemId}/assets", assetService.CreateAsset)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusBadRequest)
+	}
+}
+
+func TestUpdateAssetLent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAssetRepository := mocks.NewMockAssetRepository(ctrl)
+
+	mockAssetRepository.
+		EXPECT().
+		UpdateAsset("SN-001", models.AssetEvent{Status: models.AssetInRepair}).
+		Return(models.Asset{}, repositories.ErrAssetStatus)
+
+	assetService := NewAssetService(mockAssetRepository)
+
+	req, err := http.NewRequest("PUT", "/api/assets/SN-001", bytes.NewReader([]byte(`{"status":"in_repair"}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/assets/{serial}", assetService.UpdateAsset)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusConflict {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusConflict)
+	}
+}
+
+func TestReadAssetQR(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAssetRepository := mocks.NewMockAssetRepository(ctrl)
+
+	mockAssetRepository.
+		EXPECT().
+		ReadAsset("SN-001").
+		Return(models.Asset{ID: 1, ItemID: 1, Serial: "SN-001", Status: models.AssetInStock}, nil)
+
+	assetService := NewAssetService(mockAssetRepository)
+
+	req, err := http.NewRequest("GET", "/api/assets/SN-001/qr", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/assets/{serial}/qr", assetService.ReadAssetQR)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+	if contentType := rr.Header().Get("content-type"); contentType != "image/png" {
+		t.Errorf("wrong content type: got %v want %v", contentType, "image/png")
+	}
+}
+
+func TestReadAssetQRTooLarge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAssetRepository := mocks.NewMockAssetRepository(ctrl)
+
+	mockAssetRepository.
+		EXPECT().
+		ReadAsset("SN-001").
+		Return(models.Asset{ID: 1, ItemID: 1, Serial: "SN-001", Status: models.AssetInStock}, nil)
+
+	assetService := NewAssetService(mockAssetRepository)
+
+	req, err := http.NewRequest("GET", "/api/assets/SN-001/qr?size=100000", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/assets/{serial}/qr", assetService.ReadAssetQR)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusBadRequest)
+	}
+}
+
+func TestAssetURL(t *testing.T) {
+	assetService := &AssetService{PublicURL: "https://stoqr.example.com/"}
+
+	if url := assetService.assetURL(models.Asset{Serial: "SN 001"}); url != "https://stoqr.example.com/api/assets/SN%20001" {
+		t.Errorf("wrong url: want %v, got %v", "https://stoqr.example.com/api/assets/SN%20001", url)
+	}
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+	"github.com/leandroberetta/stoqr/stoqr-api/server"
+	"gorm.io/gorm"
+)
+
+// CategoryService contains the business logic of categories and tags
+type CategoryService struct {
+	Repository repositories.CategoryRepository
+}
+
+// CreateCategory is the api method to create a category
+func (svc *CategoryService) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	category := models.Category{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&category)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	defer r.Body.Close()
+	err = svc.Repository.CreateCategory(&category)
+	if err != nil {
+		writeProblem(w, r, statusForCategoryError(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(category)
+}
+
+// ReadCategory is the api method to get a category
+func (svc *CategoryService) ReadCategory(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["categoryId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	category, err := svc.Repository.ReadCategory(id)
+	if err != nil {
+		writeProblem(w, r, statusForCategoryError(err), err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(category)
+}
+
+// ReadCategories is the api method to get every category
+func (svc *CategoryService) ReadCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := svc.Repository.ReadCategories()
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(categories)
+}
+
+// UpdateCategory is the api method to rename or move a category
+func (svc *CategoryService) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["categoryId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	category := models.Category{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&category)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	defer r.Body.Close()
+	err = svc.Repository.UpdateCategory(id, category)
+	if err != nil {
+		writeProblem(w, r, statusForCategoryError(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteCategory is the api method to delete a category
+func (svc *CategoryService) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["categoryId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	err = svc.Repository.DeleteCategory(id)
+	if err != nil {
+		writeProblem(w, r, statusForCategoryError(err), err)
+		return
+	}
+}
+
+// CreateTag is the api method to create a tag
+func (svc *CategoryService) CreateTag(w http.ResponseWriter, r *http.Request) {
+	tag := models.Tag{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&tag)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	defer r.Body.Close()
+	err = svc.Repository.CreateTag(&tag)
+	if err != nil {
+		writeProblem(w, r, statusForCategoryError(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tag)
+}
+
+// ReadTags is the api method to get every tag
+func (svc *CategoryService) ReadTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := svc.Repository.ReadTags()
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+// UpdateTag is the api method to rename a tag
+func (svc *CategoryService) UpdateTag(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	tag := models.Tag{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&tag)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	defer r.Body.Close()
+	err = svc.Repository.UpdateTag(params["tag"], tag)
+	if err != nil {
+		writeProblem(w, r, statusForCategoryError(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteTag is the api method to delete a tag
+func (svc *CategoryService) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	err := svc.Repository.DeleteTag(params["tag"])
+	if err != nil {
+		writeProblem(w, r, statusForCategoryError(err), err)
+		return
+	}
+}
+
+// AddRoutes configures the categories and tags routes into a given router
+func (svc *CategoryService) AddRoutes(r *mux.Router) {
+	r.HandleFunc("/api/categories", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/categories", svc.CreateCategory).Methods(http.MethodPost)
+	r.HandleFunc("/api/categories", svc.ReadCategories).Methods(http.MethodGet)
+	r.HandleFunc("/api/categories/{categoryId}", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/categories/{categoryId}", svc.ReadCategory).Methods(http.MethodGet)
+	r.HandleFunc("/api/categories/{categoryId}", svc.UpdateCategory).Methods(http.MethodPut)
+	r.HandleFunc("/api/categories/{categoryId}", svc.DeleteCategory).Methods(http.MethodDelete)
+	r.HandleFunc("/api/tags", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/tags", svc.CreateTag).Methods(http.MethodPost)
+	r.HandleFunc("/api/tags", svc.ReadTags).Methods(http.MethodGet)
+	r.HandleFunc("/api/tags/{tag}", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/tags/{tag}", svc.UpdateTag).Methods(http.MethodPut)
+	r.HandleFunc("/api/tags/{tag}", svc.DeleteTag).Methods(http.MethodDelete)
+}
+
+// NewCategoryService creates a new category service
+func NewCategoryService(repository repositories.CategoryRepository) *CategoryService {
+	return &CategoryService{Repository: repository}
+}
+
+func statusForCategoryError(err error) int {
+	if errors.Is(err, repositories.ErrInvalidCategory) || errors.Is(err, repositories.ErrInvalidTag) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
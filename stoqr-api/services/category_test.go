@@ -0,0 +1,94 @@
+package services
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/mocks"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+	"gorm.io/gorm"
+)
+
+func TestCreateCategoryOK(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCategoryRepository := mocks.NewMockCategoryRepository(ctrl)
+
+	mockCategoryRepository.
+		EXPECT().
+		CreateCategory(gomock.AssignableToTypeOf(&models.Category{})).
+		Return(nil)
+
+	categoryService := NewCategoryService(mockCategoryRepository)
+
+	req, err := http.NewRequest("POST", "/api/categories", bytes.NewReader([]byte(`{"name":"Cleaning"}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(categoryService.CreateCategory)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusCreated {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusCreated)
+	}
+}
+
+func TestUpdateCategoryBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCategoryRepository := mocks.NewMockCategoryRepository(ctrl)
+
+	mockCategoryRepository.
+		EXPECT().
+		UpdateCategory(1, gomock.Any()).
+		Return(repositories.ErrInvalidCategory)
+
+	categoryService := NewCategoryService(mockCategoryRepository)
+
+	req, err := http.NewRequest("PUT", "/api/categories/1", bytes.NewReader([]byte(`{"name":"Home","parentId":1}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/categories/{categoryId}", categoryService.UpdateCategory)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusBadRequest)
+	}
+}
+
+func TestDeleteTagNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCategoryRepository := mocks.NewMockCategoryRepository(ctrl)
+
+	mockCategoryRepository.
+		EXPECT().
+		DeleteTag("bulk").
+		Return(gorm.ErrRecordNotFound)
+
+	categoryService := NewCategoryService(mockCategoryRepository)
+
+	req, err := http.NewRequest("DELETE", "/api/tags/bulk", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/tags/{tag}", categoryService.DeleteTag)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusNotFound)
+	}
+}
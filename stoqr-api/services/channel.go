@@ -0,0 +1,120 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/notify"
+)
+
+// CreateChannel is the api method to add a notification channel with its routing rules
+func (svc *NotificationService) CreateChannel(w http.ResponseWriter, r *http.Request) {
+	channel := models.Channel{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&channel)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	defer r.Body.Close()
+	err = svc.Channels.CreateChannel(&channel)
+	if err != nil {
+		writeProblem(w, r, statusForNotificationError(err), err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(channel)
+}
+
+// ReadChannel is the api method to get a notification channel
+func (svc *NotificationService) ReadChannel(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["channelId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	channel, err := svc.Channels.ReadChannel(id)
+	if err != nil {
+		writeProblem(w, r, statusForNotificationError(err), err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(channel)
+}
+
+// ReadChannels is the api method to get every notification channel
+func (svc *NotificationService) ReadChannels(w http.ResponseWriter, r *http.Request) {
+	channels, err := svc.Channels.ReadChannels()
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(channels)
+}
+
+// UpdateChannel is the api method to change a notification channel and its routing rules
+func (svc *NotificationService) UpdateChannel(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["channelId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	channel := models.Channel{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&channel)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	defer r.Body.Close()
+	err = svc.Channels.UpdateChannel(id, channel)
+	if err != nil {
+		writeProblem(w, r, statusForNotificationError(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteChannel is the api method to remove a notification channel
+func (svc *NotificationService) DeleteChannel(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["channelId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	err = svc.Channels.DeleteChannel(id)
+	if err != nil {
+		writeProblem(w, r, statusForNotificationError(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TestChannel is the api method to send a test message to a notification channel, ignoring its rules
+func (svc *NotificationService) TestChannel(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["channelId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	channel, err := svc.Channels.ReadChannel(id)
+	if err != nil {
+		writeProblem(w, r, statusForNotificationError(err), err)
+		return
+	}
+	message := notify.Message{Subject: "STOQR test", Text: "Notifications from STOQR will arrive here."}
+	if err := svc.deliver(channel, message); err != nil {
+		writeProblem(w, r, http.StatusBadGateway, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
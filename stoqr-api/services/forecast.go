@@ -0,0 +1,165 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/forecast"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+)
+
+// Forecast defaults
+const (
+	DefaultLeadTime     = 7
+	DefaultReviewPeriod = 7
+	DefaultServiceLevel = 0.95
+	DefaultHistory      = 90
+)
+
+// ReadForecast is the api method to forecast the demand of an item from its withdrawals
+// and suggest a par level and order quantity
+func (svc *ItemService) ReadForecast(w http.ResponseWriter, r *http.Request) {
+	svc.forecast(w, r, false)
+}
+
+// ApplyForecast is the api method to set the desired quantity of an item to the
+// par level suggested by its forecast (adaptive par level)
+func (svc *ItemService) ApplyForecast(w http.ResponseWriter, r *http.Request) {
+	svc.forecast(w, r, true)
+}
+
+func (svc *ItemService) forecast(w http.ResponseWriter, r *http.Request, apply bool) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["itemId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	forecastParams, policy, history, err := forecastOptions(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	item, err := svc.repository(r).ReadItem(id)
+	if err != nil {
+		writeProblem(w, r, http.StatusNotFound, err)
+		return
+	}
+	now := time.Now()
+	movements, err := svc.Movements.ReadMovements(id, now)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	series := dailyWithdrawals(movements, history, now)
+	available, _ := item.Available.Rat().Float64()
+	suggestion, err := forecast.Suggest(series, available, forecastParams, policy)
+	if err != nil {
+		if errors.Is(err, forecast.ErrNotEnoughData) {
+			writeProblem(w, r, http.StatusConflict, err)
+			return
+		}
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	whole := item.UnitOfMeasure() == models.UnitEach
+	report := models.Forecast{
+		ItemID:        item.ID,
+		Name:          item.Name,
+		Method:        suggestion.Forecast.Method,
+		LeadTime:      policy.LeadTime,
+		ReviewPeriod:  policy.ReviewPeriod,
+		ServiceLevel:  policy.ServiceLevel,
+		Daily:         suggestion.Forecast.Daily,
+		Available:     item.Available,
+		Desired:       item.Desired,
+		ParLevel:      ceilQuantity(suggestion.ParLevel, whole),
+		OrderQuantity: ceilQuantity(suggestion.OrderQuantity, whole),
+		Explanation:   suggestion.Explanation,
+	}
+	if apply && report.ParLevel != item.Desired {
+		item.Desired = report.ParLevel
+		if err := svc.repository(r).UpdateItem(id, item); err != nil {
+			writeProblem(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		report.Applied = true
+		report.Explanation = append(report.Explanation, fmt.Sprintf("desired changed from %s to the par level %s", report.Desired, report.ParLevel))
+		report.Desired = report.ParLevel
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// forecastOptions reads the forecast parameters, replenishment policy and days of
+// history from the query parameters of a request
+func forecastOptions(r *http.Request) (forecast.Params, forecast.Policy, int, error) {
+	params := forecast.Params{Method: r.FormValue("method")}
+	policy := forecast.Policy{LeadTime: DefaultLeadTime, ReviewPeriod: DefaultReviewPeriod, ServiceLevel: DefaultServiceLevel}
+	history := DefaultHistory
+	ints := map[string]*int{
+		"window": &params.Window,
+		"season": &params.Season,
+		"lead":   &policy.LeadTime,
+		"review": &policy.ReviewPeriod,
+		"days":   &history,
+	}
+	for name, value := range ints {
+		if field := r.FormValue(name); field != "" {
+			parsed, err := strconv.Atoi(field)
+			if err != nil || parsed < 0 || parsed > maxStatsWindow {
+				return params, policy, history, fmt.Errorf("%w: %s", forecast.ErrInvalidParams, name)
+			}
+			*value = parsed
+		}
+	}
+	floats := map[string]*float64{
+		"alpha":   &params.Alpha,
+		"beta":    &params.Beta,
+		"gamma":   &params.Gamma,
+		"service": &policy.ServiceLevel,
+	}
+	for name, value := range floats {
+		if field := r.FormValue(name); field != "" {
+			parsed, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return params, policy, history, fmt.Errorf("%w: %s", forecast.ErrInvalidParams, name)
+			}
+			*value = parsed
+		}
+	}
+	return params, policy, history, nil
+}
+
+// dailyWithdrawals returns the quantity withdrawn each of the last days, oldest first
+func dailyWithdrawals(movements []models.Movement, days int, now time.Time) []float64 {
+	series := make([]float64, days)
+	start := now.AddDate(0, 0, -days)
+	for _, movement := range movements {
+		withdrawn := movement.Withdrawn()
+		if withdrawn == 0 || movement.CreatedAt.Before(start) {
+			continue
+		}
+		day := int(movement.CreatedAt.Sub(start) / (24 * time.Hour))
+		if day >= days {
+			day = days - 1
+		}
+		quantity, _ := withdrawn.Rat().Float64()
+		series[day] += quantity
+	}
+	return series
+}
+
+// ceilQuantity rounds a value up to a quantity, in whole units if whole is true
+func ceilQuantity(value float64, whole bool) models.Quantity {
+	if whole {
+		return models.NewQuantity(int64(math.Ceil(value - 1e-9)))
+	}
+	return models.Quantity(math.Ceil(value*float64(models.NewQuantity(1)) - 1e-6))
+}
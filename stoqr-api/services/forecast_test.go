@@ -0,0 +1,114 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/mocks"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+)
+
+func createFakeWithdrawals(days int, quantity models.Quantity) []models.Movement {
+	movements := []models.Movement{}
+	now := time.Now()
+	for day := days; day > 0; day-- {
+		createdAt := now.AddDate(0, 0, -day).Add(time.Hour)
+		movements = append(movements, models.Movement{ItemID: 1, Kind: models.MovementWithdrawal, Quantity: quantity, CreatedAt: createdAt})
+	}
+	return movements
+}
+
+func TestReadForecastOK(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+	mockMovementRepository := mocks.NewMockMovementRepository(ctrl)
+
+	mockItemRepository.
+		EXPECT().
+		ReadItem(1).
+		Return(models.Item{ID: 1, Name: "Milk", Desired: models.NewQuantity(6), Actual: models.NewQuantity(4), Available: models.NewQuantity(4)}, nil)
+
+	mockMovementRepository.
+		EXPECT().
+		ReadMovements(1, gomock.Any()).
+		Return(createFakeWithdrawals(30, models.NewQuantity(2)), nil)
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mockMovementRepository)
+
+	req, err := http.NewRequest("GET", "/api/items/1/forecast?window=7&lead=5&review=2&days=30", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/{itemId}/forecast", itemService.ReadForecast)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+
+	report := models.Forecast{}
+	json.Unmarshal(rr.Body.Bytes(), &report)
+
+	if want := models.NewQuantity(14); report.ParLevel != want {
+		t.Errorf("wrong par level: got %v want %v", report.ParLevel, want)
+	}
+	if want := models.NewQuantity(10); report.OrderQuantity != want {
+		t.Errorf("wrong order quantity: got %v want %v", report.OrderQuantity, want)
+	}
+}
+
+func TestApplyForecastUpdatesDesired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+	mockMovementRepository := mocks.NewMockMovementRepository(ctrl)
+
+	item := models.Item{ID: 1, Name: "Milk", Desired: models.NewQuantity(6), Actual: models.NewQuantity(4), Available: models.NewQuantity(4)}
+	mockItemRepository.
+		EXPECT().
+		ReadItem(1).
+		Return(item, nil)
+
+	mockMovementRepository.
+		EXPECT().
+		ReadMovements(1, gomock.Any()).
+		Return(createFakeWithdrawals(30, models.NewQuantity(2)), nil)
+
+	item.Desired = models.NewQuantity(14)
+	mockItemRepository.
+		EXPECT().
+		UpdateItem(1, item).
+		Return(nil)
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mockMovementRepository)
+
+	req, err := http.NewRequest("POST", "/api/items/1/forecast?window=7&lead=5&review=2&days=30", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/{itemId}/forecast", itemService.ApplyForecast)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+
+	report := models.Forecast{}
+	json.Unmarshal(rr.Body.Bytes(), &report)
+
+	if !report.Applied || report.Desired != models.NewQuantity(14) {
+		t.Errorf("wrong desired: got %v want %v", report.Desired, models.NewQuantity(14))
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/server"
+)
+
+// DefaultCheckTimeout is how long the checks of the dependencies can take by default
+const DefaultCheckTimeout = 2 * time.Second
+
+// Check is a named check of a dependency needed to serve requests
+type Check struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// Build identifies the build of the api
+type Build struct {
+	Version string
+	Commit  string
+	Date    string
+}
+
+// HealthService contains the liveness, readiness and status of the api
+type HealthService struct {
+	Checks   []Check
+	Draining func() bool
+	Build    Build
+	Database string
+	Started  time.Time
+	Timeout  time.Duration
+}
+
+// Healthz is the api method telling the process is alive
+func (svc *HealthService) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(models.Status{Status: models.StatusOK})
+}
+
+// Readyz is the api method telling the api can serve requests: its dependencies pass
+// their checks and it isn't draining requests to shut down
+func (svc *HealthService) Readyz(w http.ResponseWriter, r *http.Request) {
+	status := models.Status{}
+	svc.check(r.Context(), &status)
+	w.Header().Set("content-type", "application/json")
+	if status.Status != models.StatusOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(status)
+}
+
+// ReadStatus is the api method to get the build, uptime, database and checks of the api
+func (svc *HealthService) ReadStatus(w http.ResponseWriter, r *http.Request) {
+	started := svc.Started.UTC()
+	status := models.Status{
+		Version:   svc.Build.Version,
+		Commit:    svc.Build.Commit,
+		BuildDate: svc.Build.Date,
+		GoVersion: runtime.Version(),
+		StartedAt: &started,
+		Uptime:    time.Since(svc.Started).Round(time.Second).String(),
+		Database:  svc.Database,
+	}
+	svc.check(r.Context(), &status)
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// AddRoutes configures the health routes into a given router
+func (svc *HealthService) AddRoutes(r *mux.Router) {
+	r.HandleFunc("/healthz", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/healthz", svc.Healthz).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/readyz", svc.Readyz).Methods(http.MethodGet)
+	r.HandleFunc("/status", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/status", svc.ReadStatus).Methods(http.MethodGet)
+}
+
+// NewHealthService creates a new health service of a build using a database, started now
+func NewHealthService(build Build, database string, checks ...Check) *HealthService {
+	return &HealthService{Checks: checks, Build: build, Database: database, Started: time.Now(), Timeout: DefaultCheckTimeout}
+}
+
+// check runs the checks setting their results and the overall status
+func (svc *HealthService) check(ctx context.Context, status *models.Status) {
+	ctx, cancel := context.WithTimeout(ctx, svc.Timeout)
+	defer cancel()
+	status.Status = models.StatusOK
+	status.Checks = map[string]string{}
+	for _, check := range svc.Checks {
+		status.Checks[check.Name] = models.StatusOK
+		if err := check.Check(ctx); err != nil {
+			status.Checks[check.Name] = err.Error()
+			status.Status = models.StatusUnavailable
+		}
+	}
+	if svc.Draining != nil && svc.Draining() {
+		status.Checks["draining"] = "shutting down"
+		status.Status = models.StatusUnavailable
+	}
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+)
+
+func healthRequest(t *testing.T, handler http.HandlerFunc, path string) (int, models.Status) {
+	req, err := http.NewRequest("GET", path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	status := models.Status{}
+	if err := json.NewDecoder(rr.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	return rr.Code, status
+}
+
+func TestHealthz(t *testing.T) {
+	failing := Check{Name: "database", Check: func(ctx context.Context) error { return errors.New("connection refused") }}
+	healthService := NewHealthService(Build{Version: "1.0.0"}, "sqlite", failing)
+
+	code, status := healthRequest(t, healthService.Healthz, "/healthz")
+
+	if code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", code, http.StatusOK)
+	}
+	if status.Status != models.StatusOK {
+		t.Errorf("wrong status: want %v, got %v", models.StatusOK, status.Status)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	var err error
+	database := Check{Name: "database", Check: func(ctx context.Context) error { return err }}
+	draining := false
+	healthService := NewHealthService(Build{}, "sqlite", database)
+	healthService.Draining = func() bool { return draining }
+
+	cases := []struct {
+		err      error
+		draining bool
+		code     int
+	}{
+		{nil, false, http.StatusOK},
+		{errors.New("connection refused"), false, http.StatusServiceUnavailable},
+		{nil, true, http.StatusServiceUnavailable},
+	}
+	for _, c := range cases {
+		err, draining = c.err, c.draining
+
+		code, status := healthRequest(t, healthService.Readyz, "/readyz")
+
+		if code != c.code {
+			t.Errorf("handler returned wrong status code: got %v want %v", code, c.code)
+		}
+		if c.err != nil && status.Checks["database"] != c.err.Error() {
+			t.Errorf("wrong database check: want %v, got %v", c.err, status.Checks["database"])
+		}
+		if _, ok := status.Checks["draining"]; ok != c.draining {
+			t.Errorf("wrong draining check: want %v, got %v", c.draining, ok)
+		}
+	}
+}
+
+func TestReadStatus(t *testing.T) {
+	healthService := NewHealthService(Build{Version: "1.0.0", Commit: "abc123"}, "postgres",
+		Check{Name: "database", Check: func(ctx context.Context) error { return nil }})
+
+	code, status := healthRequest(t, healthService.ReadStatus, "/status")
+
+	if code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", code, http.StatusOK)
+	}
+	if status.Version != "1.0.0" || status.Commit != "abc123" {
+		t.Errorf("wrong build: want %v, got %v/%v", "1.0.0/abc123", status.Version, status.Commit)
+	}
+	if status.Database != "postgres" {
+		t.Errorf("wrong database: want %v, got %v", "postgres", status.Database)
+	}
+	if status.StartedAt == nil || status.Uptime == "" || status.GoVersion == "" {
+		t.Errorf("wrong status: want start, uptime and go version, got %+v", status)
+	}
+	if status.Checks["database"] != models.StatusOK {
+		t.Errorf("wrong database check: want %v, got %v", models.StatusOK, status.Checks["database"])
+	}
+}
@@ -2,70 +2,133 @@ package services
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/mail"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/leandroberetta/stoqr/stoqr-api/models"
 	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
 	"github.com/leandroberetta/stoqr/stoqr-api/server"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
 )
 
+// DefaultUndoWindow is how long a withdrawal or a restock can be undone by default
+const DefaultUndoWindow = 5 * time.Minute
+
 // ItemService contains the business logic of items
 type ItemService struct {
 	Repository repositories.ItemRepository
+	Approvals  repositories.ApprovalRepository
+	Movements  repositories.MovementRepository
+	UndoWindow time.Duration
+	LowStock   func(item models.Item)
+	Metrics    *ItemMetrics
+	Tracer     trace.Tracer
 }
 
 // CreateItem is the api method for create an item
 func (svc *ItemService) CreateItem(w http.ResponseWriter, r *http.Request) {
 	item := models.Item{}
-	decoder := json.NewDecoder(r.Body)
-	err := decoder.Decode(&item)
+	err := decodeJSON(r, &item)
 	if err != nil {
-		log.Println(err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeDecodeProblem(w, r, err)
+		return
+	}
+	if fields := itemErrors(&item); len(fields) > 0 {
+		writeProblem(w, r, http.StatusUnprocessableEntity, errInvalidRequest, fields...)
 		return
 	}
-	defer r.Body.Close()
-	err = svc.Repository.CreateItem(&item)
+	err = svc.repository(r).CreateItem(&item)
 	if err != nil {
-		log.Println(err)
-		w.WriteHeader(http.StatusInternalServerError)
+		if errors.Is(err, repositories.ErrDuplicateName) {
+			writeProblem(w, r, http.StatusUnprocessableEntity, err, duplicateName)
+			return
+		}
+		if isInvalid(err) {
+			writeProblem(w, r, http.StatusBadRequest, err)
+			return
+		}
+		writeProblem(w, r, http.StatusInternalServerError, err)
 		return
 	}
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(item)
 }
 
-// ReadItem is the api method for get an item
+// ReadItem is the api method for get an item, optionally as it was at a past time (as_of)
 func (svc *ItemService) ReadItem(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id, err := strconv.Atoi(params["itemId"])
 	if err != nil {
-		log.Println(err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, err)
 		return
 	}
-	item, err := svc.Repository.ReadItem(id)
+	asOf, err := parseTime(r.FormValue("as_of"), time.Time{})
 	if err != nil {
-		log.Println(err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	var item models.Item
+	if asOf.IsZero() {
+		item, err = svc.repository(r).ReadItem(id)
+	} else {
+		item, err = svc.repository(r).ReadItemAt(id, asOf)
+	}
+	if err != nil {
+		if !asOf.IsZero() && errors.Is(err, gorm.ErrRecordNotFound) {
+			writeProblem(w, r, http.StatusNotFound, err)
+			return
+		}
+		writeProblem(w, r, http.StatusInternalServerError, err)
 		return
 	}
 	w.Header().Set("content-type", "application/json")
 	json.NewEncoder(w).Encode(item)
 }
 
-// ReadItems is the api method to get items, optionally filtered by name
+// ReadItems is the api method to get items, optionally filtered by name, category, tag and
+// location, or as they were at a past time (as_of)
 func (svc *ItemService) ReadItems(w http.ResponseWriter, r *http.Request) {
-	filter := r.FormValue("filter")
-	items, err := svc.Repository.ReadItems(filter)
+	filter, err := itemFilter(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	windows, err := statsWindows(r)
+	if err == nil && len(windows) > 1 {
+		err = fmt.Errorf("%w: items are sorted by a single window", errInvalidWindow)
+	}
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	field := r.FormValue("sort")
+	items, err := svc.repository(r).ReadItems(filter)
 	if err != nil {
-		log.Println(err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeProblem(w, r, http.StatusInternalServerError, err)
 		return
 	}
+	if len(windows) > 0 || field != "" {
+		window := DefaultSortWindow
+		if len(windows) > 0 {
+			window = windows[0]
+		}
+		if err := svc.itemStats(items, window); err != nil {
+			writeProblem(w, r, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	if field != "" {
+		if err := sortItems(items, field); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, err)
+			return
+		}
+	}
 	w.Header().Set("content-type", "application/json")
 	json.NewEncoder(w).Encode(items)
 }
@@ -75,23 +138,34 @@ func (svc *ItemService) UpdateItem(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id, err := strconv.Atoi(params["itemId"])
 	if err != nil {
-		log.Println(err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, err)
 		return
 	}
 	item := models.Item{}
-	decoder := json.NewDecoder(r.Body)
-	err = decoder.Decode(&item)
+	err = decodeJSON(r, &item)
 	if err != nil {
-		log.Println(err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeDecodeProblem(w, r, err)
+		return
+	}
+	if fields := itemErrors(&item); len(fields) > 0 {
+		writeProblem(w, r, http.StatusUnprocessableEntity, errInvalidRequest, fields...)
 		return
 	}
-	defer r.Body.Close()
-	err = svc.Repository.UpdateItem(id, item)
+	err = svc.repository(r).UpdateItem(id, item)
 	if err != nil {
-		log.Println(err)
-		w.WriteHeader(http.StatusInternalServerError)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeProblem(w, r, http.StatusNotFound, err)
+			return
+		}
+		if errors.Is(err, repositories.ErrDuplicateName) {
+			writeProblem(w, r, http.StatusUnprocessableEntity, err, duplicateName)
+			return
+		}
+		if isInvalid(err) {
+			writeProblem(w, r, http.StatusBadRequest, err)
+			return
+		}
+		writeProblem(w, r, http.StatusInternalServerError, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -102,40 +176,270 @@ func (svc *ItemService) DeleteItem(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id, err := strconv.Atoi(params["itemId"])
 	if err != nil {
-		log.Println(err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, err)
 		return
 	}
-	err = svc.Repository.DeleteItem(id)
+	err = svc.repository(r).DeleteItem(id)
 	if err != nil {
-		log.Println(err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeProblem(w, r, http.StatusInternalServerError, err)
 		return
 	}
 }
 
-// WithdrawItem is the api method for withdraw an item
+// WithdrawItem is the api method for withdraw an item, by default one unit
+// unless the quantity (and optionally a compatible unit) is given. When a policy
+// requires an approval a pending request is created for the requester instead.
+// Borrowable items are refused, they are checked out as loans. Falling below the
+// desired stock triggers a low stock notification.
 func (svc *ItemService) WithdrawItem(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id, err := strconv.Atoi(params["itemId"])
 	if err != nil {
-		log.Println(err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	quantity := models.NewQuantity(1)
+	if value := r.FormValue("quantity"); value != "" {
+		quantity, err = models.ParseQuantity(value)
+		if err == nil && quantity <= 0 {
+			err = fmt.Errorf("%w: %q", models.ErrInvalidQuantity, value)
+		}
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, err, server.FieldError{Field: "quantity", Message: "must be a positive number"})
+			return
+		}
+	}
+	item, err := svc.repository(r).ReadItem(id)
+	if err != nil {
+		writeProblem(w, r, http.StatusNotFound, err)
+		return
+	}
+	quantity, err = item.Convert(quantity, r.FormValue("unit"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	item, err = svc.repository(r).WithdrawItem(id, quantity)
+	if errors.Is(err, repositories.ErrApprovalRequired) {
+		svc.requestWithdrawal(w, r, id, quantity)
+		return
+	}
+	if err != nil {
+		if errors.Is(err, repositories.ErrInsufficientStock) || errors.Is(err, repositories.ErrSerialized) ||
+			errors.Is(err, repositories.ErrBorrowable) {
+			writeProblem(w, r, http.StatusConflict, err)
+			return
+		}
+		if errors.Is(err, models.ErrInvalidQuantity) {
+			writeProblem(w, r, http.StatusBadRequest, err)
+			return
+		}
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	svc.Metrics.withdrawn(item, quantity)
+	if svc.LowStock != nil && item.Actual < item.Desired && item.Actual+quantity >= item.Desired {
+		go svc.LowStock(item)
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// RestockItem is the api method to put a quantity of an item into stock,
+// optionally expressed in a unit compatible with the unit of the item
+func (svc *ItemService) RestockItem(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["itemId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	restock := models.Restock{}
+	err = decodeJSON(r, &restock)
+	if err != nil {
+		writeDecodeProblem(w, r, err)
+		return
+	}
+	if fields := restockErrors(restock); len(fields) > 0 {
+		writeProblem(w, r, http.StatusBadRequest, errInvalidRequest, fields...)
+		return
+	}
+	item, err := svc.repository(r).ReadItem(id)
+	if err != nil {
+		writeProblem(w, r, http.StatusNotFound, err)
+		return
+	}
+	quantity, err := item.Convert(restock.Quantity, restock.Unit)
+	if err == nil && quantity <= 0 {
+		err = fmt.Errorf("%w: %s %s", models.ErrInvalidQuantity, restock.Quantity, restock.Unit)
+	}
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err, server.FieldError{Field: "unit", Message: err.Error()})
+		return
+	}
+	restock = models.Restock{Quantity: quantity, Unit: item.UnitOfMeasure(), Cost: restock.TotalCost()}
+	item, err = svc.repository(r).RestockItem(id, restock)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeProblem(w, r, http.StatusNotFound, err)
+			return
+		}
+		if errors.Is(err, repositories.ErrKit) || errors.Is(err, repositories.ErrSerialized) {
+			writeProblem(w, r, http.StatusConflict, err)
+			return
+		}
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	svc.Metrics.restocked(item, quantity)
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// ReadComponents is the api method to get the components of a kit
+func (svc *ItemService) ReadComponents(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["itemId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	components, err := svc.repository(r).ReadComponents(id)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(components)
+}
+
+// UpdateComponents is the api method to replace the components of a kit
+func (svc *ItemService) UpdateComponents(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["itemId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	components := []models.Component{}
+	err = decodeJSON(r, &components)
+	if err != nil {
+		writeDecodeProblem(w, r, err)
+		return
+	}
+	err = svc.repository(r).UpdateComponents(id, components)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInvalidComponent) {
+			writeProblem(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeProblem(w, r, http.StatusNotFound, err)
+			return
+		}
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReadConversions is the api method to get the unit conversions defined for an item
+func (svc *ItemService) ReadConversions(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["itemId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	conversions, err := svc.repository(r).ReadConversions(id)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(conversions)
+}
+
+// UpdateConversions is the api method to replace the unit conversions defined for an item
+func (svc *ItemService) UpdateConversions(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["itemId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	conversions := []models.Conversion{}
+	err = decodeJSON(r, &conversions)
+	if err != nil {
+		writeDecodeProblem(w, r, err)
+		return
+	}
+	err = svc.repository(r).UpdateConversions(id, conversions)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInvalidConversion) {
+			writeProblem(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeProblem(w, r, http.StatusNotFound, err)
+			return
+		}
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UpdateTags is the api method to replace the tags of an item
+func (svc *ItemService) UpdateTags(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["itemId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
 		return
 	}
-	item, err := svc.Repository.ReadItem(id)
+	tags := []models.Tag{}
+	err = decodeJSON(r, &tags)
 	if err != nil {
-		log.Println(err)
-		w.WriteHeader(http.StatusNotFound)
+		writeDecodeProblem(w, r, err)
 		return
 	}
-	if item.Actual != 0 {
-		item.Actual = item.Actual - 1
+	err = svc.repository(r).UpdateTags(id, tags)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInvalidTag) {
+			writeProblem(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeProblem(w, r, http.StatusNotFound, err)
+			return
+		}
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
 	}
-	err = svc.Repository.UpdateItem(id, item)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReadUnits is the api method to get the unit conversions valid for every item
+func (svc *ItemService) ReadUnits(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(models.Conversions)
+}
+
+// UndoOperation is the api method to reverse a recent withdrawal or restock
+func (svc *ItemService) UndoOperation(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	item, err := svc.repository(r).UndoOperation(params["operationId"], time.Now().Add(-svc.UndoWindow))
 	if err != nil {
-		log.Println(err)
-		w.WriteHeader(http.StatusInternalServerError)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeProblem(w, r, http.StatusNotFound, err)
+			return
+		}
+		if errors.Is(err, repositories.ErrUndoExpired) || errors.Is(err, repositories.ErrUndoConflict) {
+			writeProblem(w, r, http.StatusConflict, err)
+			return
+		}
+		writeProblem(w, r, http.StatusInternalServerError, err)
 		return
 	}
 	w.Header().Set("content-type", "application/json")
@@ -145,18 +449,103 @@ func (svc *ItemService) WithdrawItem(w http.ResponseWriter, r *http.Request) {
 // AddRoutes configures the items routes into a given router
 func (svc *ItemService) AddRoutes(r *mux.Router) {
 	r.HandleFunc("/api/items", server.Options).Methods(http.MethodOptions)
-	r.HandleFunc("/api/items", svc.CreateItem).Methods(http.MethodPost)
-	r.HandleFunc("/api/items", svc.ReadItems).Methods(http.MethodGet)
-	r.HandleFunc("/api/items", svc.ReadItems).Methods(http.MethodGet).Queries("filter", "{filter}")
+	r.HandleFunc("/api/items", svc.operation("CreateItem", svc.CreateItem)).Methods(http.MethodPost)
+	r.HandleFunc("/api/items", svc.operation("ReadItems", svc.ReadItems)).Methods(http.MethodGet)
+	r.HandleFunc("/api/items", svc.operation("ReadItems", svc.ReadItems)).Methods(http.MethodGet).Queries("filter", "{filter}")
 	r.HandleFunc("/api/items/{itemId}", server.Options).Methods(http.MethodOptions)
-	r.HandleFunc("/api/items/{itemId}", svc.ReadItem).Methods((http.MethodGet))
-	r.HandleFunc("/api/items/{itemId}", svc.DeleteItem).Methods(http.MethodDelete)
-	r.HandleFunc("/api/items/{itemId}", svc.UpdateItem).Methods(http.MethodPut)
+	r.HandleFunc("/api/items/{itemId}", svc.operation("ReadItem", svc.ReadItem)).Methods((http.MethodGet))
+	r.HandleFunc("/api/items/{itemId}", svc.operation("DeleteItem", svc.DeleteItem)).Methods(http.MethodDelete)
+	r.HandleFunc("/api/items/{itemId}", svc.operation("UpdateItem", svc.UpdateItem)).Methods(http.MethodPut)
 	r.HandleFunc("/api/items/withdraw/{itemId}", server.Options).Methods(http.MethodOptions)
-	r.HandleFunc("/api/items/withdraw/{itemId}", svc.WithdrawItem).Methods(http.MethodGet)
+	r.HandleFunc("/api/items/withdraw/{itemId}", svc.operation("WithdrawItem", svc.WithdrawItem)).Methods(http.MethodGet)
+	r.HandleFunc("/api/items/{itemId}/restock", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/items/{itemId}/restock", svc.operation("RestockItem", svc.RestockItem)).Methods(http.MethodPost)
+	r.HandleFunc("/api/items/{itemId}/components", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/items/{itemId}/components", svc.operation("ReadComponents", svc.ReadComponents)).Methods(http.MethodGet)
+	r.HandleFunc("/api/items/{itemId}/components", svc.operation("UpdateComponents", svc.UpdateComponents)).Methods(http.MethodPut)
+	r.HandleFunc("/api/items/{itemId}/conversions", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/items/{itemId}/conversions", svc.operation("ReadConversions", svc.ReadConversions)).Methods(http.MethodGet)
+	r.HandleFunc("/api/items/{itemId}/conversions", svc.operation("UpdateConversions", svc.UpdateConversions)).Methods(http.MethodPut)
+	r.HandleFunc("/api/items/{itemId}/tags", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/items/{itemId}/tags", svc.operation("UpdateTags", svc.UpdateTags)).Methods(http.MethodPut)
+	r.HandleFunc("/api/items/{itemId}/stats", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/items/{itemId}/stats", svc.operation("ReadItemStats", svc.ReadItemStats)).Methods(http.MethodGet)
+	r.HandleFunc("/api/items/{itemId}/forecast", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/items/{itemId}/forecast", svc.operation("ReadForecast", svc.ReadForecast)).Methods(http.MethodGet)
+	r.HandleFunc("/api/items/{itemId}/forecast", svc.operation("ApplyForecast", svc.ApplyForecast)).Methods(http.MethodPost)
+	r.HandleFunc("/api/items/{itemId}/history", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/items/{itemId}/history", svc.operation("ReadStockHistory", svc.ReadStockHistory)).Methods(http.MethodGet)
+	r.HandleFunc("/api/snapshots", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/snapshots", svc.operation("TakeSnapshot", svc.TakeSnapshot)).Methods(http.MethodPost)
+	r.HandleFunc("/api/units", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/units", svc.operation("ReadUnits", svc.ReadUnits)).Methods(http.MethodGet)
+	r.HandleFunc("/api/operations/{operationId}/undo", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/operations/{operationId}/undo", svc.operation("UndoOperation", svc.UndoOperation)).Methods(http.MethodPost)
 }
 
 // NewItemService creates a new item service
-func NewItemService(repository repositories.ItemRepository) *ItemService {
-	return &ItemService{Repository: repository}
+func NewItemService(repository repositories.ItemRepository, approvals repositories.ApprovalRepository, movements repositories.MovementRepository) *ItemService {
+	return &ItemService{Repository: repository, Approvals: approvals, Movements: movements, UndoWindow: DefaultUndoWindow}
+}
+
+// requestWithdrawal creates a pending withdrawal request from the query parameters, the
+// decision is emailed to the contact when one is given
+func (svc *ItemService) requestWithdrawal(w http.ResponseWriter, r *http.Request, id int, quantity models.Quantity) {
+	request := models.WithdrawalRequest{
+		ItemID:    id,
+		Quantity:  quantity,
+		Requester: r.FormValue("requester"),
+		Reason:    r.FormValue("reason"),
+	}
+	if request.Requester == "" {
+		writeProblem(w, r, http.StatusBadRequest, errInvalidRequest, server.FieldError{Field: "requester", Message: "is required for withdrawals that need approval"})
+		return
+	}
+	if contact := r.FormValue("contact"); contact != "" {
+		address, err := mail.ParseAddress(contact)
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, errInvalidRequest, server.FieldError{Field: "contact", Message: "must be an email address"})
+			return
+		}
+		request.Contact = address.Address
+	}
+	err := svc.Approvals.CreateRequest(&request)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeProblem(w, r, http.StatusNotFound, err)
+			return
+		}
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(request)
+}
+
+// itemFilter reads the filters of items from the query parameters of a request
+func itemFilter(r *http.Request) (repositories.ItemFilter, error) {
+	filter := repositories.ItemFilter{Name: r.FormValue("filter"), Tag: r.FormValue("tag"), Location: r.FormValue("location")}
+	if category := r.FormValue("category"); category != "" {
+		id, err := strconv.Atoi(category)
+		if err != nil {
+			return filter, err
+		}
+		filter.CategoryID = id
+	}
+	asOf, err := parseTime(r.FormValue("as_of"), time.Time{})
+	if err != nil {
+		return filter, err
+	}
+	filter.AsOf = asOf
+	return filter, nil
+}
+
+// isInvalid returns true if the error was caused by invalid data sent by the client
+func isInvalid(err error) bool {
+	return errors.Is(err, repositories.ErrInvalidComponent) ||
+		errors.Is(err, repositories.ErrInvalidConversion) ||
+		errors.Is(err, repositories.ErrInvalidCategory) ||
+		errors.Is(err, repositories.ErrInvalidTag) ||
+		errors.Is(err, repositories.ErrKit)
 }
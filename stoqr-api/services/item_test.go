@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -12,6 +13,9 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/leandroberetta/stoqr/stoqr-api/mocks"
 	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+	"github.com/leandroberetta/stoqr/stoqr-api/server"
+	"gorm.io/gorm"
 )
 
 func TestCreateItemOK(t *testing.T) {
@@ -26,7 +30,7 @@ func TestCreateItemOK(t *testing.T) {
 		}).
 		Return(nil)
 
-	itemService := NewItemService(mockItemRepository)
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
 
 	req, err := http.NewRequest("POST", "/api/items", bytes.NewReader(createFakeJSONItem()))
 	if err != nil {
@@ -46,7 +50,7 @@ func TestCreateItemBadRequest(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	mockItemRepository := mocks.NewMockItemRepository(ctrl)
 
-	itemService := NewItemService(mockItemRepository)
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
 
 	req, err := http.NewRequest("POST", "/api/items", bytes.NewReader([]byte{}))
 	if err != nil {
@@ -71,7 +75,7 @@ func TestCreateItemInternalServerError(t *testing.T) {
 		CreateItem(gomock.AssignableToTypeOf(&models.Item{})).
 		Return(errors.New("error"))
 
-	itemService := NewItemService(mockItemRepository)
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
 
 	req, err := http.NewRequest("POST", "/api/items", bytes.NewReader(createFakeJSONItem()))
 	if err != nil {
@@ -92,8 +96,8 @@ func TestWithdrawItemOK(t *testing.T) {
 		name string
 		item models.Item
 	}{
-		{name: "actual1", item: models.Item{ID: 1, Name: "Test", Desired: 1, Actual: 1}},
-		{name: "actual0", item: models.Item{ID: 1, Name: "Test", Desired: 1, Actual: 0}},
+		{name: "actual1", item: models.Item{ID: 1, Name: "Test", Desired: models.NewQuantity(1), Actual: models.NewQuantity(1)}},
+		{name: "actual0", item: models.Item{ID: 1, Name: "Test", Desired: models.NewQuantity(1), Actual: 0}},
 	}
 
 	for _, c := range cases {
@@ -106,12 +110,15 @@ func TestWithdrawItemOK(t *testing.T) {
 				ReadItem(gomock.Any()).
 				Return(c.item, nil)
 
+			withdrawnItem := c.item
+			withdrawnItem.Actual = 0
+
 			mockItemRepository.
 				EXPECT().
-				UpdateItem(gomock.Any(), gomock.Any()).
-				Return(nil)
+				WithdrawItem(gomock.Any(), gomock.Any()).
+				Return(withdrawnItem, nil)
 
-			itemService := NewItemService(mockItemRepository)
+			itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
 
 			req, err := http.NewRequest("GET", "/api/items/withdraw/1", nil)
 			if err != nil {
@@ -148,7 +155,7 @@ func TestWithdrawItemNotFound(t *testing.T) {
 		ReadItem(gomock.Any()).
 		Return(models.Item{}, errors.New("error"))
 
-	itemService := NewItemService(mockItemRepository)
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
 
 	req, err := http.NewRequest("GET", "/api/items/withdraw/1", nil)
 	if err != nil {
@@ -170,7 +177,7 @@ func TestWithdrawItemNotFound(t *testing.T) {
 func TestWithdrawItemBadRequest(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	mockItemRepository := mocks.NewMockItemRepository(ctrl)
-	itemService := NewItemService(mockItemRepository)
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
 
 	req, err := http.NewRequest("GET", "/api/items/withdraw/wrong", nil)
 	if err != nil {
@@ -202,10 +209,10 @@ func TestWithdrawItemInternalServerError(t *testing.T) {
 
 	mockItemRepository.
 		EXPECT().
-		UpdateItem(gomock.Any(), gomock.Any()).
-		Return(errors.New("error"))
+		WithdrawItem(gomock.Any(), gomock.Any()).
+		Return(models.Item{}, errors.New("error"))
 
-	itemService := NewItemService(mockItemRepository)
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
 
 	req, err := http.NewRequest("GET", "/api/items/withdraw/1", nil)
 	if err != nil {
@@ -224,11 +231,340 @@ func TestWithdrawItemInternalServerError(t *testing.T) {
 	}
 }
 
+func TestWithdrawKitConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+	kit := models.Item{ID: 1, Name: "Kit", Desired: models.NewQuantity(1), Components: []models.Component{{ComponentID: 2, Quantity: models.NewQuantity(5)}}}
+
+	mockItemRepository.
+		EXPECT().
+		ReadItem(gomock.Any()).
+		Return(kit, nil)
+
+	mockItemRepository.
+		EXPECT().
+		WithdrawItem(1, models.NewQuantity(1)).
+		Return(models.Item{}, repositories.ErrInsufficientStock)
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+
+	req, err := http.NewRequest("GET", "/api/items/withdraw/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/withdraw/{itemId}", itemService.WithdrawItem)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusConflict {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusConflict)
+	}
+}
+
+func TestWithdrawBorrowableItemConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+	drill := models.Item{ID: 1, Name: "Drill", Desired: models.NewQuantity(1), Actual: models.NewQuantity(1), Borrowable: true}
+
+	mockItemRepository.
+		EXPECT().
+		ReadItem(gomock.Any()).
+		Return(drill, nil)
+
+	mockItemRepository.
+		EXPECT().
+		WithdrawItem(1, models.NewQuantity(1)).
+		Return(models.Item{}, fmt.Errorf("%w: check out item 1 instead", repositories.ErrBorrowable))
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+
+	req, err := http.NewRequest("GET", "/api/items/withdraw/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/withdraw/{itemId}", itemService.WithdrawItem)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusConflict {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusConflict)
+	}
+	problem := server.Problem{}
+	json.Unmarshal(rr.Body.Bytes(), &problem)
+	if problem.Type != "/problems/borrowable-item" {
+		t.Errorf("wrong problem type: want %v, got %v", "/problems/borrowable-item", problem.Type)
+	}
+}
+
+func TestWithdrawItemRequiresApproval(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+	mockApprovalRepository := mocks.NewMockApprovalRepository(ctrl)
+
+	mockItemRepository.
+		EXPECT().
+		ReadItem(gomock.Any()).
+		Return(models.Item{ID: 1, Name: "Solvent", Actual: models.NewQuantity(20)}, nil)
+
+	mockItemRepository.
+		EXPECT().
+		WithdrawItem(1, models.NewQuantity(10)).
+		Return(models.Item{}, repositories.ErrApprovalRequired)
+
+	mockApprovalRepository.
+		EXPECT().
+		CreateRequest(&models.WithdrawalRequest{ItemID: 1, Quantity: models.NewQuantity(10), Requester: "Alice", Contact: "alice@example.com", Reason: "Cleaning"}).
+		Return(nil)
+
+	itemService := NewItemService(mockItemRepository, mockApprovalRepository, mocks.NewMockMovementRepository(ctrl))
+
+	req, err := http.NewRequest("GET", "/api/items/withdraw/1?quantity=10&requester=Alice&contact=Alice+%3Calice@example.com%3E&reason=Cleaning", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/withdraw/{itemId}", itemService.WithdrawItem)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusAccepted {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusAccepted)
+	}
+}
+
+func TestWithdrawItemRequestNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+	mockApprovalRepository := mocks.NewMockApprovalRepository(ctrl)
+
+	mockItemRepository.
+		EXPECT().
+		ReadItem(gomock.Any()).
+		Return(models.Item{ID: 1, Name: "Solvent", Actual: models.NewQuantity(20)}, nil)
+
+	mockItemRepository.
+		EXPECT().
+		WithdrawItem(1, models.NewQuantity(10)).
+		Return(models.Item{}, repositories.ErrApprovalRequired)
+
+	mockApprovalRepository.
+		EXPECT().
+		CreateRequest(gomock.Any()).
+		Return(gorm.ErrRecordNotFound)
+
+	itemService := NewItemService(mockItemRepository, mockApprovalRepository, mocks.NewMockMovementRepository(ctrl))
+
+	req, err := http.NewRequest("GET", "/api/items/withdraw/1?quantity=10&requester=Alice", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/withdraw/{itemId}", itemService.WithdrawItem)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusNotFound)
+	}
+}
+
+func TestRestockItemOK(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+	pack := models.Conversion{From: "pack", To: models.UnitEach, Factor: models.NewQuantity(6)}
+	item := models.Item{ID: 1, Name: "Test", Desired: models.NewQuantity(12), Conversions: []models.Conversion{pack}}
+
+	mockItemRepository.
+		EXPECT().
+		ReadItem(1).
+		Return(item, nil)
+
+	mockItemRepository.
+		EXPECT().
+		RestockItem(1, models.Restock{Quantity: models.NewQuantity(12), Unit: models.UnitEach, Cost: 1200}).
+		Return(models.Item{ID: 1, Name: "Test", Desired: models.NewQuantity(12), Actual: models.NewQuantity(12)}, nil)
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+
+	body, _ := json.Marshal(models.Restock{Quantity: models.NewQuantity(2), Unit: "pack", UnitCost: 600})
+	req, err := http.NewRequest("POST", "/api/items/1/restock", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/{itemId}/restock", itemService.RestockItem)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+}
+
+func TestRestockItemBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+
+	req, err := http.NewRequest("POST", "/api/items/1/restock", bytes.NewReader([]byte(`{"quantity":0}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/{itemId}/restock", itemService.RestockItem)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusBadRequest)
+	}
+}
+
+func TestWithdrawItemConvertsUnit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+	item := models.Item{ID: 1, Name: "Flour", Desired: models.NewQuantity(2), Actual: models.NewQuantity(2), Unit: "kg"}
+
+	mockItemRepository.
+		EXPECT().
+		ReadItem(1).
+		Return(item, nil)
+
+	mockItemRepository.
+		EXPECT().
+		WithdrawItem(1, models.Quantity(250)).
+		Return(item, nil)
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+
+	req, err := http.NewRequest("GET", "/api/items/withdraw/1?quantity=250&unit=g", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/withdraw/{itemId}", itemService.WithdrawItem)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+}
+
+func TestWithdrawItemIncompatibleUnit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+
+	mockItemRepository.
+		EXPECT().
+		ReadItem(1).
+		Return(models.Item{ID: 1, Name: "Flour", Unit: "kg"}, nil)
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+
+	req, err := http.NewRequest("GET", "/api/items/withdraw/1?quantity=1&unit=l", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/withdraw/{itemId}", itemService.WithdrawItem)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusBadRequest)
+	}
+}
+
+func TestUpdateComponentsOK(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+	components := []models.Component{{ComponentID: 2, Quantity: models.NewQuantity(5)}, {ComponentID: 3, Quantity: models.NewQuantity(1)}}
+
+	mockItemRepository.
+		EXPECT().
+		UpdateComponents(1, components).
+		Return(nil)
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+
+	body, _ := json.Marshal(components)
+	req, err := http.NewRequest("PUT", "/api/items/1/components", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/{itemId}/components", itemService.UpdateComponents)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusNoContent {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusNoContent)
+	}
+}
+
+func TestUpdateComponentsBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+
+	mockItemRepository.
+		EXPECT().
+		UpdateComponents(gomock.Any(), gomock.Any()).
+		Return(repositories.ErrInvalidComponent)
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+
+	req, err := http.NewRequest("PUT", "/api/items/1/components", bytes.NewReader([]byte(`[{"componentId":1,"quantity":1}]`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/{itemId}/components", itemService.UpdateComponents)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusBadRequest)
+	}
+}
+
 func createFakeItem(item *models.Item) {
 	item.ID = 1
 	item.Name = "Test"
-	item.Desired = 1
-	item.Actual = 1
+	item.Desired = models.NewQuantity(1)
+	item.Actual = models.NewQuantity(1)
 }
 
 func createFakeJSONItem() []byte {
@@ -237,3 +573,59 @@ func createFakeJSONItem() []byte {
 	bytes, _ := json.Marshal(item)
 	return bytes
 }
+
+func TestUndoOperationOK(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+
+	mockItemRepository.
+		EXPECT().
+		UndoOperation("0a1b2c", gomock.Any()).
+		Return(models.Item{ID: 1, Name: "Screws", Actual: models.NewQuantity(10)}, nil)
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+
+	req, err := http.NewRequest("POST", "/api/operations/0a1b2c/undo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/operations/{operationId}/undo", itemService.UndoOperation)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+}
+
+func TestUndoOperationExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+
+	mockItemRepository.
+		EXPECT().
+		UndoOperation("0a1b2c", gomock.Any()).
+		Return(models.Item{}, repositories.ErrUndoExpired)
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+
+	req, err := http.NewRequest("POST", "/api/operations/0a1b2c/undo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/operations/{operationId}/undo", itemService.UndoOperation)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusConflict {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusConflict)
+	}
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+	"github.com/leandroberetta/stoqr/stoqr-api/server"
+	"gorm.io/gorm"
+)
+
+// JobService contains the business logic of background jobs
+type JobService struct {
+	Repository repositories.JobRepository
+}
+
+// ReadJobs is the api method to get the state of every background job
+func (svc *JobService) ReadJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := svc.Repository.ReadJobs()
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(jobs)
+}
+
+// ReadJob is the api method to get the state of a background job
+func (svc *JobService) ReadJob(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	job, err := svc.Repository.ReadJob(params["name"])
+	if err != nil {
+		writeProblem(w, r, statusForJobError(err), err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// RunJob is the api method to make a background job due now, a replica runs it on its
+// next check
+func (svc *JobService) RunJob(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	if err := svc.Repository.TriggerJob(params["name"]); err != nil {
+		writeProblem(w, r, statusForJobError(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// AddRoutes configures the job routes into a given router
+func (svc *JobService) AddRoutes(r *mux.Router) {
+	r.HandleFunc("/api/jobs", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/jobs", svc.ReadJobs).Methods(http.MethodGet)
+	r.HandleFunc("/api/jobs/{name}", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/jobs/{name}", svc.ReadJob).Methods(http.MethodGet)
+	r.HandleFunc("/api/jobs/{name}/run", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/jobs/{name}/run", svc.RunJob).Methods(http.MethodPost)
+}
+
+// NewJobService creates a new job service
+func NewJobService(repository repositories.JobRepository) *JobService {
+	return &JobService{Repository: repository}
+}
+
+// statusForJobError maps a job error to an http status code
+func statusForJobError(err error) int {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
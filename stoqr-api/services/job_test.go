@@ -0,0 +1,92 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/mocks"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"gorm.io/gorm"
+)
+
+func TestReadJobs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockJobRepository := mocks.NewMockJobRepository(ctrl)
+
+	mockJobRepository.
+		EXPECT().
+		ReadJobs().
+		Return([]models.Job{{Name: "snapshots", Schedule: "@daily"}}, nil)
+
+	jobService := NewJobService(mockJobRepository)
+
+	req, err := http.NewRequest("GET", "/api/jobs", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(jobService.ReadJobs)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+}
+
+func TestRunJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockJobRepository := mocks.NewMockJobRepository(ctrl)
+
+	mockJobRepository.
+		EXPECT().
+		TriggerJob("snapshots").
+		Return(nil)
+
+	jobService := NewJobService(mockJobRepository)
+
+	req, err := http.NewRequest("POST", "/api/jobs/snapshots/run", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/jobs/{name}/run", jobService.RunJob)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusAccepted {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusAccepted)
+	}
+}
+
+func TestRunJobNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockJobRepository := mocks.NewMockJobRepository(ctrl)
+
+	mockJobRepository.
+		EXPECT().
+		TriggerJob("purge").
+		Return(gorm.ErrRecordNotFound)
+
+	jobService := NewJobService(mockJobRepository)
+
+	req, err := http.NewRequest("POST", "/api/jobs/purge/run", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/jobs/{name}/run", jobService.RunJob)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusNotFound)
+	}
+}
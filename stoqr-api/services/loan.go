@@ -0,0 +1,230 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+	"github.com/leandroberetta/stoqr/stoqr-api/server"
+	"gorm.io/gorm"
+)
+
+// DefaultLoanPeriod is how long an item is lent when no due date is given
+const DefaultLoanPeriod = 7 * 24 * time.Hour
+
+// LoanService contains the business logic of lending borrowable items
+type LoanService struct {
+	Repository repositories.LoanRepository
+}
+
+// ScanItem is the api method behind an item's QR code, it checks the item back in
+// if the borrower (or the only borrower when none is given) has it checked out,
+// otherwise it checks a unit out to the borrower until the due date
+func (svc *LoanService) ScanItem(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	itemID, err := strconv.Atoi(params["itemId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	borrower := r.FormValue("borrower")
+	open, err := svc.Repository.ReadLoans(repositories.LoanFilter{ItemID: itemID, Borrower: borrower, Open: true})
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if len(open) == 1 || (borrower != "" && len(open) > 0) {
+		loan, err := svc.Repository.CheckIn(open[0].ID)
+		if err != nil {
+			writeProblem(w, r, statusForLoanError(err), err)
+			return
+		}
+		w.Header().Set("content-type", "application/json")
+		json.NewEncoder(w).Encode(loan)
+		return
+	}
+	if borrower == "" {
+		writeProblem(w, r, http.StatusBadRequest, errInvalidRequest, server.FieldError{Field: "borrower", Message: "is required to check out an item"})
+		return
+	}
+	loan := models.Loan{ItemID: itemID, Borrower: borrower}
+	if loan.DueAt, err = dueDate(r.FormValue("due")); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	svc.checkOut(w, r, &loan)
+}
+
+// CreateLoan is the api method to check out a unit of an item to a borrower
+func (svc *LoanService) CreateLoan(w http.ResponseWriter, r *http.Request) {
+	loan := models.Loan{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&loan)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	defer r.Body.Close()
+	fields := []server.FieldError{}
+	if loan.ItemID == 0 {
+		fields = append(fields, server.FieldError{Field: "itemId", Message: "is required"})
+	}
+	if loan.Borrower == "" {
+		fields = append(fields, server.FieldError{Field: "borrower", Message: "is required"})
+	}
+	if !loan.DueAt.IsZero() && loan.DueAt.Before(time.Now()) {
+		fields = append(fields, server.FieldError{Field: "dueAt", Message: "must not be in the past"})
+	}
+	if len(fields) > 0 {
+		writeProblem(w, r, http.StatusBadRequest, errInvalidRequest, fields...)
+		return
+	}
+	if loan.DueAt.IsZero() {
+		loan.DueAt = time.Now().Add(DefaultLoanPeriod)
+	}
+	svc.checkOut(w, r, &loan)
+}
+
+// ReturnLoan is the api method to check a lent item back in
+func (svc *LoanService) ReturnLoan(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["loanId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	loan, err := svc.Repository.CheckIn(id)
+	if err != nil {
+		writeProblem(w, r, statusForLoanError(err), err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(loan)
+}
+
+// ReadLoan is the api method to get a loan
+func (svc *LoanService) ReadLoan(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["loanId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	loan, err := svc.Repository.ReadLoan(id)
+	if err != nil {
+		writeProblem(w, r, statusForLoanError(err), err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(loan)
+}
+
+// ReadLoans is the api method to get the loans, optionally filtered by item, borrower,
+// only the open ones or only the overdue ones
+func (svc *LoanService) ReadLoans(w http.ResponseWriter, r *http.Request) {
+	filter := repositories.LoanFilter{Borrower: r.FormValue("borrower")}
+	var err error
+	if value := r.FormValue("item"); value != "" {
+		if filter.ItemID, err = strconv.Atoi(value); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if value := r.FormValue("open"); value != "" {
+		if filter.Open, err = strconv.ParseBool(value); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if value := r.FormValue("overdue"); value != "" {
+		if filter.Overdue, err = strconv.ParseBool(value); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, err)
+			return
+		}
+	}
+	svc.readLoans(w, r, filter)
+}
+
+// ReadItemLoans is the api method to get the loan history of an item
+func (svc *LoanService) ReadItemLoans(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	itemID, err := strconv.Atoi(params["itemId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	svc.readLoans(w, r, repositories.LoanFilter{ItemID: itemID})
+}
+
+func (svc *LoanService) checkOut(w http.ResponseWriter, r *http.Request, loan *models.Loan) {
+	err := svc.Repository.CheckOut(loan)
+	if err != nil {
+		writeProblem(w, r, statusForLoanError(err), err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(loan)
+}
+
+func (svc *LoanService) readLoans(w http.ResponseWriter, r *http.Request, filter repositories.LoanFilter) {
+	loans, err := svc.Repository.ReadLoans(filter)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(loans)
+}
+
+// AddRoutes configures the loans routes into a given router
+func (svc *LoanService) AddRoutes(r *mux.Router) {
+	r.HandleFunc("/api/loans", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/loans", svc.CreateLoan).Methods(http.MethodPost)
+	r.HandleFunc("/api/loans", svc.ReadLoans).Methods(http.MethodGet)
+	r.HandleFunc("/api/loans/scan/{itemId}", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/loans/scan/{itemId}", svc.ScanItem).Methods(http.MethodGet)
+	r.HandleFunc("/api/loans/{loanId}", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/loans/{loanId}", svc.ReadLoan).Methods(http.MethodGet)
+	r.HandleFunc("/api/loans/{loanId}/return", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/loans/{loanId}/return", svc.ReturnLoan).Methods(http.MethodPost)
+	r.HandleFunc("/api/items/{itemId}/loans", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/items/{itemId}/loans", svc.ReadItemLoans).Methods(http.MethodGet)
+}
+
+// NewLoanService creates a new loan service
+func NewLoanService(repository repositories.LoanRepository) *LoanService {
+	return &LoanService{Repository: repository}
+}
+
+func dueDate(value string) (time.Time, error) {
+	due, err := parseTime(value, time.Now().Add(DefaultLoanPeriod))
+	if err != nil {
+		return due, err
+	}
+	if due.Before(time.Now()) {
+		return due, errors.New("due date is in the past")
+	}
+	return due, nil
+}
+
+func statusForLoanError(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repositories.ErrLoanClosed),
+		errors.Is(err, repositories.ErrInsufficientStock),
+		errors.Is(err, repositories.ErrAssetStatus):
+		return http.StatusConflict
+	case errors.Is(err, repositories.ErrNotBorrowable),
+		errors.Is(err, repositories.ErrKit),
+		errors.Is(err, repositories.ErrSerialized):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
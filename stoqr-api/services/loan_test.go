@@ -0,0 +1,128 @@
+package services
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/mocks"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+)
+
+func TestScanItemCheckOut(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockLoanRepository := mocks.NewMockLoanRepository(ctrl)
+
+	mockLoanRepository.
+		EXPECT().
+		ReadLoans(repositories.LoanFilter{ItemID: 1, Borrower: "Alice", Open: true}).
+		Return([]models.Loan{}, nil)
+	mockLoanRepository.
+		EXPECT().
+		CheckOut(gomock.AssignableToTypeOf(&models.Loan{})).
+		Return(nil)
+
+	loanService := NewLoanService(mockLoanRepository)
+
+	req, err := http.NewRequest("GET", "/api/loans/scan/1?borrower=Alice", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/loans/scan/{itemId}", loanService.ScanItem)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusCreated {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusCreated)
+	}
+}
+
+func TestScanItemCheckIn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockLoanRepository := mocks.NewMockLoanRepository(ctrl)
+
+	mockLoanRepository.
+		EXPECT().
+		ReadLoans(repositories.LoanFilter{ItemID: 1, Open: true}).
+		Return([]models.Loan{{ID: 3, ItemID: 1, Borrower: "Alice"}}, nil)
+	mockLoanRepository.
+		EXPECT().
+		CheckIn(3).
+		Return(models.Loan{ID: 3, ItemID: 1, Borrower: "Alice"}, nil)
+
+	loanService := NewLoanService(mockLoanRepository)
+
+	req, err := http.NewRequest("GET", "/api/loans/scan/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/loans/scan/{itemId}", loanService.ScanItem)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+}
+
+func TestCreateLoanNotBorrowable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockLoanRepository := mocks.NewMockLoanRepository(ctrl)
+
+	mockLoanRepository.
+		EXPECT().
+		CheckOut(gomock.Any()).
+		Return(repositories.ErrNotBorrowable)
+
+	loanService := NewLoanService(mockLoanRepository)
+
+	req, err := http.NewRequest("POST", "/api/loans", bytes.NewReader([]byte(`{"itemId":1,"borrower":"Alice"}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(loanService.CreateLoan)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusBadRequest)
+	}
+}
+
+func TestReadLoansOverdue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockLoanRepository := mocks.NewMockLoanRepository(ctrl)
+
+	due := time.Now().Add(-time.Hour)
+	mockLoanRepository.
+		EXPECT().
+		ReadLoans(repositories.LoanFilter{Overdue: true}).
+		Return([]models.Loan{{ID: 3, ItemID: 1, Borrower: "Alice", DueAt: due}}, nil)
+
+	loanService := NewLoanService(mockLoanRepository)
+
+	req, err := http.NewRequest("GET", "/api/loans?overdue=true", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(loanService.ReadLoans)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+}
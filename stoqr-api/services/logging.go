@@ -0,0 +1,78 @@
+package services
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/logging"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/server"
+)
+
+// LoggingService contains the level and format of the logs, which can be changed while the api runs
+type LoggingService struct {
+	Handler *logging.Handler
+}
+
+// ReadLogSettings is the api method to get the level and format of the logs
+func (svc *LoggingService) ReadLogSettings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(svc.settings())
+}
+
+// UpdateLogSettings is the api method to change the level or the format of the logs, the
+// settings left empty are kept
+func (svc *LoggingService) UpdateLogSettings(w http.ResponseWriter, r *http.Request) {
+	settings := models.LogSettings{}
+	if err := decodeJSON(r, &settings); err != nil {
+		writeDecodeProblem(w, r, err)
+		return
+	}
+	level, err := logging.ParseLevel(settings.Level)
+	if err != nil {
+		writeProblem(w, r, http.StatusUnprocessableEntity, errInvalidRequest, server.FieldError{Field: "level", Message: "must be debug, info, warn or error"})
+		return
+	}
+	if settings.Format != "" {
+		if err := svc.Handler.SetFormat(settings.Format); err != nil {
+			writeProblem(w, r, http.StatusUnprocessableEntity, errInvalidRequest, server.FieldError{Field: "format", Message: "must be json or text"})
+			return
+		}
+	}
+	if settings.Level != "" {
+		svc.Handler.SetLevel(level)
+	}
+	slog.InfoContext(r.Context(), "Changed the log settings", "log_level", svc.Handler.Level().String(), "log_format", svc.Handler.Format())
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(svc.settings())
+}
+
+// AddRoutes configures the logging routes into a given router
+func (svc *LoggingService) AddRoutes(r *mux.Router) {
+	r.HandleFunc("/api/logging", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/logging", svc.ReadLogSettings).Methods(http.MethodGet)
+	r.HandleFunc("/api/logging", svc.UpdateLogSettings).Methods(http.MethodPut)
+}
+
+// NewLoggingService creates a new logging service changing the settings of a handler
+func NewLoggingService(handler *logging.Handler) *LoggingService {
+	return &LoggingService{Handler: handler}
+}
+
+// settings returns the current level and format of the logs
+func (svc *LoggingService) settings() models.LogSettings {
+	return models.LogSettings{Level: strings.ToLower(svc.Handler.Level().String()), Format: svc.Handler.Format()}
+}
+
+// logError logs the error of a request with the attributes of its context, at the error level
+// if the server failed and at the warn level if the request was wrong
+func logError(r *http.Request, status int, err error) {
+	level := slog.LevelWarn
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	slog.LogAttrs(r.Context(), level, "Request failed", slog.Int("status", status), slog.Any("error", err))
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/logging"
+	"github.com/leandroberetta/stoqr/stoqr-api/mocks"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/server"
+)
+
+func TestUpdateLogSettings(t *testing.T) {
+	handler, _ := logging.NewHandler(&bytes.Buffer{}, slog.LevelInfo, logging.FormatJSON)
+	loggingService := NewLoggingService(handler)
+	router := mux.NewRouter()
+	loggingService.AddRoutes(router)
+
+	cases := []struct {
+		body     string
+		code     int
+		settings models.LogSettings
+	}{
+		{`{"level":"debug"}`, http.StatusOK, models.LogSettings{Level: "debug", Format: "json"}},
+		{`{"format":"text"}`, http.StatusOK, models.LogSettings{Level: "debug", Format: "text"}},
+		{`{"level":"verbose","format":"json"}`, http.StatusUnprocessableEntity, models.LogSettings{Level: "debug", Format: "text"}},
+		{`{"format":"xml"}`, http.StatusUnprocessableEntity, models.LogSettings{Level: "debug", Format: "text"}},
+	}
+	for _, c := range cases {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/api/logging", strings.NewReader(c.body)))
+
+		if status := rr.Code; status != c.code {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, c.code)
+		}
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/logging", nil))
+		settings := models.LogSettings{}
+		json.NewDecoder(rr.Body).Decode(&settings)
+		if settings != c.settings {
+			t.Errorf("wrong settings after %v: want %v, got %v", c.body, c.settings, settings)
+		}
+	}
+}
+
+func TestItemErrorLog(t *testing.T) {
+	buffer := bytes.Buffer{}
+	handler, _ := logging.NewHandler(&buffer, slog.LevelInfo, logging.FormatJSON)
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(handler))
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+
+	mockItemRepository.
+		EXPECT().
+		ReadItem(1).
+		Return(models.Item{}, errors.New("connection refused"))
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+	router := mux.NewRouter()
+	itemService.AddRoutes(router)
+	req := httptest.NewRequest(http.MethodGet, "/api/items/1", nil)
+	req.Header.Set(server.RequestIDHeader, "req-1")
+	rr := httptest.NewRecorder()
+	server.RequestIDs(router).ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusInternalServerError {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusInternalServerError)
+	}
+	record := map[string]interface{}{}
+	if err := json.Unmarshal(buffer.Bytes(), &record); err != nil {
+		t.Fatalf("wrong record: %v\n%s", err, buffer.String())
+	}
+	want := map[string]interface{}{"level": "ERROR", "error": "connection refused", "operation": "ReadItem", "item_id": "1", "request_id": "req-1"}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("wrong %v: want %v, got %v", key, value, record[key])
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"log/slog"
+	"strconv"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// ItemMetrics counts the withdrawals and restocks of each item. The series are labelled
+// by the id of the item only, its name can change and would split them.
+type ItemMetrics struct {
+	Withdrawals *prometheus.CounterVec
+	Withdrawn   *prometheus.CounterVec
+	Restocks    *prometheus.CounterVec
+	Restocked   *prometheus.CounterVec
+}
+
+// NewItemMetrics registers the metrics of the items into a registry, the gauges of the
+// inventory are read from a repository on each scrape
+func NewItemMetrics(registerer prometheus.Registerer, repository repositories.ItemRepository) *ItemMetrics {
+	registerer.MustRegister(&inventoryCollector{
+		repository: repository,
+		total:      prometheus.NewDesc("stoqr_items", "Items in the inventory.", nil, nil),
+		below:      prometheus.NewDesc("stoqr_items_below_desired", "Items whose stock is below the desired quantity.", nil, nil),
+		empty:      prometheus.NewDesc("stoqr_items_out_of_stock", "Items without stock.", nil, nil),
+	})
+	factory := promauto.With(registerer)
+	return &ItemMetrics{
+		Withdrawals: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "stoqr_item_withdrawals_total",
+			Help: "Withdrawals of each item.",
+		}, []string{"item"}),
+		Withdrawn: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "stoqr_item_withdrawn_total",
+			Help: "Quantity withdrawn of each item, in its unit.",
+		}, []string{"item", "unit"}),
+		Restocks: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "stoqr_item_restocks_total",
+			Help: "Restocks of each item.",
+		}, []string{"item"}),
+		Restocked: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "stoqr_item_restocked_total",
+			Help: "Quantity restocked of each item, in its unit.",
+		}, []string{"item", "unit"}),
+	}
+}
+
+// withdrawn counts a withdrawal of a quantity of an item, nothing if there are no metrics
+func (itemMetrics *ItemMetrics) withdrawn(item models.Item, quantity models.Quantity) {
+	if itemMetrics == nil {
+		return
+	}
+	id := strconv.Itoa(item.ID)
+	value, _ := quantity.Rat().Float64()
+	itemMetrics.Withdrawals.WithLabelValues(id).Inc()
+	itemMetrics.Withdrawn.WithLabelValues(id, item.UnitOfMeasure()).Add(value)
+}
+
+// restocked counts a restock of a quantity of an item, nothing if there are no metrics
+func (itemMetrics *ItemMetrics) restocked(item models.Item, quantity models.Quantity) {
+	if itemMetrics == nil {
+		return
+	}
+	id := strconv.Itoa(item.ID)
+	value, _ := quantity.Rat().Float64()
+	itemMetrics.Restocks.WithLabelValues(id).Inc()
+	itemMetrics.Restocked.WithLabelValues(id, item.UnitOfMeasure()).Add(value)
+}
+
+// inventoryCollector reads the gauges of the inventory from a repository on each scrape,
+// the gauges are left out of the scrape if the items can't be read
+type inventoryCollector struct {
+	repository repositories.ItemRepository
+	total      *prometheus.Desc
+	below      *prometheus.Desc
+	empty      *prometheus.Desc
+}
+
+// Describe sends the descriptions of the gauges
+func (collector *inventoryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.total
+	ch <- collector.below
+	ch <- collector.empty
+}
+
+// Collect reads the items and sends the gauges
+func (collector *inventoryCollector) Collect(ch chan<- prometheus.Metric) {
+	items, err := collector.repository.ReadItems(repositories.ItemFilter{})
+	if err != nil {
+		slog.Error("Updating the metrics", "error", err)
+		return
+	}
+	var belowDesired, outOfStock int
+	for _, item := range items {
+		if item.Actual < item.Desired {
+			belowDesired++
+		}
+		if item.Actual <= 0 {
+			outOfStock++
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(collector.total, prometheus.GaugeValue, float64(len(items)))
+	ch <- prometheus.MustNewConstMetric(collector.below, prometheus.GaugeValue, float64(belowDesired))
+	ch <- prometheus.MustNewConstMetric(collector.empty, prometheus.GaugeValue, float64(outOfStock))
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/mocks"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func TestItemMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+	milk := models.Item{ID: 1, Name: "Milk", Unit: "l", Desired: models.NewQuantity(2), Actual: models.NewQuantity(3)}
+
+	mockItemRepository.
+		EXPECT().
+		ReadItem(1).
+		Return(milk, nil)
+
+	withdrawn := milk
+	withdrawn.Actual = models.NewQuantity(1)
+	mockItemRepository.
+		EXPECT().
+		WithdrawItem(1, models.NewQuantity(2)).
+		Return(withdrawn, nil)
+
+	mockItemRepository.
+		EXPECT().
+		ReadItems(gomock.Any()).
+		Return([]models.Item{withdrawn, {ID: 2, Name: "Eggs", Desired: models.NewQuantity(6)}, {ID: 3, Name: "Salt"}}, nil)
+
+	registry := prometheus.NewRegistry()
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+	itemService.Metrics = NewItemMetrics(registry, mockItemRepository)
+
+	req, err := http.NewRequest("GET", "/api/items/withdraw/1?quantity=2", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/withdraw/{itemId}", itemService.WithdrawItem)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	rr := httptest.NewRecorder()
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+
+	for _, want := range []string{
+		"stoqr_items 3\n",
+		"stoqr_items_below_desired 2\n",
+		"stoqr_items_out_of_stock 2\n",
+		`stoqr_item_withdrawals_total{item="1"} 1`,
+		`stoqr_item_withdrawn_total{item="1",unit="l"} 2`,
+	} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("wrong metrics: want %v, got\n%s", want, rr.Body.String())
+		}
+	}
+}
+
+func TestItemMetricsNil(t *testing.T) {
+	var itemMetrics *ItemMetrics
+
+	itemMetrics.withdrawn(models.Item{ID: 1}, models.NewQuantity(1))
+	itemMetrics.restocked(models.Item{ID: 1}, models.NewQuantity(1))
+}
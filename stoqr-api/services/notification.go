@@ -0,0 +1,342 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/notify"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+	"github.com/leandroberetta/stoqr/stoqr-api/server"
+	"gorm.io/gorm"
+)
+
+// DefaultDigestSchedule is the cron schedule of the daily digest of the shopping list by default
+const DefaultDigestSchedule = "0 8 * * *"
+
+// NotificationService contains the business logic of subscriptions, channels and notifications,
+// no email is sent without a mailer
+type NotificationService struct {
+	Repository repositories.SubscriptionRepository
+	Channels   repositories.ChannelRepository
+	Items      repositories.ItemRepository
+	Categories repositories.CategoryRepository
+	Mailer     notify.Mailer
+	Client     *http.Client
+}
+
+// CreateSubscription is the api method to subscribe an email to notifications
+func (svc *NotificationService) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	subscription := models.Subscription{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&subscription)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	defer r.Body.Close()
+	err = svc.Repository.CreateSubscription(&subscription)
+	if err != nil {
+		writeProblem(w, r, statusForNotificationError(err), err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(subscription)
+}
+
+// ReadSubscription is the api method to get a subscription
+func (svc *NotificationService) ReadSubscription(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["subscriptionId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	subscription, err := svc.Repository.ReadSubscription(id)
+	if err != nil {
+		writeProblem(w, r, statusForNotificationError(err), err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(subscription)
+}
+
+// ReadSubscriptions is the api method to get the subscriptions, optionally to an event
+func (svc *NotificationService) ReadSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := svc.Repository.ReadSubscriptions(r.FormValue("event"))
+	if err != nil {
+		writeProblem(w, r, statusForNotificationError(err), err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(subscriptions)
+}
+
+// UpdateSubscription is the api method to change the email or preferences of a subscription
+func (svc *NotificationService) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["subscriptionId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	subscription := models.Subscription{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&subscription)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	defer r.Body.Close()
+	err = svc.Repository.UpdateSubscription(id, subscription)
+	if err != nil {
+		writeProblem(w, r, statusForNotificationError(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteSubscription is the api method to unsubscribe an email from every notification
+func (svc *NotificationService) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["subscriptionId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	err = svc.Repository.DeleteSubscription(id)
+	if err != nil {
+		writeProblem(w, r, statusForNotificationError(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PreviewNotification is the api method to render a notification with the current data
+// without sending it, as html (the default), text or json (format). The low stock
+// notification is rendered for an item (item).
+func (svc *NotificationService) PreviewNotification(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	var data interface{}
+	switch params["template"] {
+	case notify.TemplateLowStock:
+		id, err := strconv.Atoi(r.FormValue("item"))
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, err)
+			return
+		}
+		item, err := svc.Items.ReadItem(id)
+		if err != nil {
+			writeProblem(w, r, statusForNotificationError(err), err)
+			return
+		}
+		data = notify.LowStock{Item: item}
+	case notify.TemplateDigest:
+		digest, err := svc.digest(time.Now())
+		if err != nil {
+			writeProblem(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		data = digest
+	default:
+		writeProblem(w, r, http.StatusNotFound, fmt.Errorf("%w: %s", notify.ErrUnknownTemplate, params["template"]))
+		return
+	}
+	message, err := notify.Render(params["template"], data)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	switch r.FormValue("format") {
+	case "", "html":
+		w.Header().Set("content-type", "text/html; charset=utf-8")
+		w.Write([]byte(message.HTML))
+	case "text":
+		w.Header().Set("content-type", "text/plain; charset=utf-8")
+		w.Write([]byte(message.Text))
+	case "json":
+		w.Header().Set("content-type", "application/json")
+		json.NewEncoder(w).Encode(message)
+	default:
+		writeProblem(w, r, http.StatusBadRequest, errInvalidRequest, server.FieldError{Field: "format", Message: "must be html, text or json"})
+	}
+}
+
+// NotifyLowStock notifies the subscribers and channels of low stock alerts that an item fell
+// below its desired stock
+func (svc *NotificationService) NotifyLowStock(item models.Item) {
+	if err := svc.send(models.EventLowStock, item.CategoryID, notify.TemplateLowStock, notify.LowStock{Item: item}); err != nil {
+		slog.Error("Notifying low stock", "item_id", item.ID, "error", err)
+	}
+}
+
+// NotifyDecision emails the requester that a withdrawal request was approved or rejected,
+// nothing is sent when the request has no contact
+func (svc *NotificationService) NotifyDecision(request models.WithdrawalRequest) {
+	if svc.Mailer == nil || request.Contact == "" {
+		return
+	}
+	item, err := svc.Items.ReadItem(request.ItemID)
+	if err != nil {
+		slog.Error("Notifying a decision", "withdrawal_request_id", request.ID, "error", err)
+		return
+	}
+	message, err := notify.Render(notify.TemplateRequestDecision, notify.RequestDecision{Request: request, Item: item})
+	if err != nil {
+		slog.Error("Notifying a decision", "withdrawal_request_id", request.ID, "error", err)
+		return
+	}
+	message.To = []string{request.Contact}
+	if err := svc.Mailer.Send(message); err != nil {
+		slog.Error("Notifying a decision", "withdrawal_request_id", request.ID, "error", err)
+	}
+}
+
+// SendDigest sends the shopping list to the subscribers and channels of the digest, nothing
+// is sent when every item is at its desired stock
+func (svc *NotificationService) SendDigest(ctx context.Context) error {
+	digest, err := svc.digest(time.Now())
+	if err != nil || len(digest.Groups) == 0 {
+		return err
+	}
+	return svc.send(models.EventDigest, nil, notify.TemplateDigest, digest)
+}
+
+// AddRoutes configures the notification routes into a given router
+func (svc *NotificationService) AddRoutes(r *mux.Router) {
+	r.HandleFunc("/api/subscriptions", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/subscriptions", svc.CreateSubscription).Methods(http.MethodPost)
+	r.HandleFunc("/api/subscriptions", svc.ReadSubscriptions).Methods(http.MethodGet)
+	r.HandleFunc("/api/subscriptions/{subscriptionId}", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/subscriptions/{subscriptionId}", svc.ReadSubscription).Methods(http.MethodGet)
+	r.HandleFunc("/api/subscriptions/{subscriptionId}", svc.UpdateSubscription).Methods(http.MethodPut)
+	r.HandleFunc("/api/subscriptions/{subscriptionId}", svc.DeleteSubscription).Methods(http.MethodDelete)
+	r.HandleFunc("/api/channels", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/channels", svc.CreateChannel).Methods(http.MethodPost)
+	r.HandleFunc("/api/channels", svc.ReadChannels).Methods(http.MethodGet)
+	r.HandleFunc("/api/channels/{channelId}", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/channels/{channelId}", svc.ReadChannel).Methods(http.MethodGet)
+	r.HandleFunc("/api/channels/{channelId}", svc.UpdateChannel).Methods(http.MethodPut)
+	r.HandleFunc("/api/channels/{channelId}", svc.DeleteChannel).Methods(http.MethodDelete)
+	r.HandleFunc("/api/channels/{channelId}/test", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/channels/{channelId}/test", svc.TestChannel).Methods(http.MethodPost)
+	r.HandleFunc("/api/notifications/preview/{template}", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/notifications/preview/{template}", svc.PreviewNotification).Methods(http.MethodGet)
+}
+
+// NewNotificationService creates a new notification service sending emails with a mailer
+func NewNotificationService(repository repositories.SubscriptionRepository, channels repositories.ChannelRepository, items repositories.ItemRepository, categories repositories.CategoryRepository, mailer notify.Mailer) *NotificationService {
+	return &NotificationService{
+		Repository: repository,
+		Channels:   channels,
+		Items:      items,
+		Categories: categories,
+		Mailer:     mailer,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// digest gets the shopping list grouped by category
+func (svc *NotificationService) digest(date time.Time) (notify.Digest, error) {
+	items, err := svc.Items.ReadItems(repositories.ItemFilter{})
+	if err != nil {
+		return notify.Digest{}, err
+	}
+	categories, err := svc.Categories.ReadCategories()
+	if err != nil {
+		return notify.Digest{}, err
+	}
+	return notify.Digest{Date: date, Groups: shoppingList(items, categories, GroupByCategory)}, nil
+}
+
+// send renders a notification and delivers it to the subscribers and channels of an event,
+// a failed delivery doesn't stop the others
+func (svc *NotificationService) send(event string, categoryID *int, template string, data interface{}) error {
+	message, err := notify.Render(template, data)
+	if err != nil {
+		return err
+	}
+	var failed error
+	if err := svc.email(event, message); err != nil {
+		failed = err
+	}
+	if err := svc.dispatch(event, categoryID, message); err != nil {
+		failed = err
+	}
+	return failed
+}
+
+// email sends a message to each subscriber to an event
+func (svc *NotificationService) email(event string, message notify.Message) error {
+	if svc.Mailer == nil {
+		return nil
+	}
+	subscriptions, err := svc.Repository.ReadSubscriptions(event)
+	if err != nil {
+		return err
+	}
+	var failed error
+	for _, subscription := range subscriptions {
+		message.To = []string{subscription.Email}
+		if err := svc.Mailer.Send(message); err != nil {
+			slog.Error("Sending an email", "event", event, "error", err)
+			failed = err
+		}
+	}
+	return failed
+}
+
+// dispatch sends a message to each channel whose rules route an event of an item in a category
+func (svc *NotificationService) dispatch(event string, categoryID *int, message notify.Message) error {
+	channels, err := svc.Channels.ReadChannels()
+	if err != nil || len(channels) == 0 {
+		return err
+	}
+	categories, err := svc.Categories.ReadCategories()
+	if err != nil {
+		return err
+	}
+	var failed error
+	for _, channel := range channels {
+		if !channel.Routes(event, categoryID, categories) {
+			continue
+		}
+		if err := svc.deliver(channel, message); err != nil {
+			slog.Error("Delivering to a channel", "event", event, "channel", channel.Name, "error", err)
+			failed = err
+		}
+	}
+	return failed
+}
+
+// deliver sends a message to a channel
+func (svc *NotificationService) deliver(channel models.Channel, message notify.Message) error {
+	notifier, err := notify.NewNotifier(channel, svc.Client)
+	if err != nil {
+		return err
+	}
+	if err := notifier.Notify(message); err != nil {
+		return fmt.Errorf("channel %s: %w", channel.Name, err)
+	}
+	return nil
+}
+
+func statusForNotificationError(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repositories.ErrInvalidSubscription),
+		errors.Is(err, repositories.ErrInvalidChannel),
+		errors.Is(err, repositories.ErrInvalidCategory):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/mocks"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/notify"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+)
+
+// fakeMailer records the messages sent
+type fakeMailer struct {
+	messages []notify.Message
+}
+
+func (mailer *fakeMailer) Send(message notify.Message) error {
+	mailer.messages = append(mailer.messages, message)
+	return nil
+}
+
+func TestCreateSubscriptionBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockSubscriptionRepository := mocks.NewMockSubscriptionRepository(ctrl)
+
+	mockSubscriptionRepository.
+		EXPECT().
+		CreateSubscription(gomock.Any()).
+		Return(repositories.ErrInvalidSubscription)
+
+	notificationService := NewNotificationService(mockSubscriptionRepository, mocks.NewMockChannelRepository(ctrl), mocks.NewMockItemRepository(ctrl), mocks.NewMockCategoryRepository(ctrl), nil)
+
+	req, err := http.NewRequest("POST", "/api/subscriptions", strings.NewReader(`{"email": "ana", "digest": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(notificationService.CreateSubscription)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusBadRequest)
+	}
+}
+
+func TestPreviewDigest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+	mockCategoryRepository := mocks.NewMockCategoryRepository(ctrl)
+
+	mockItemRepository.
+		EXPECT().
+		ReadItems(repositories.ItemFilter{}).
+		Return([]models.Item{{ID: 1, Name: "Milk", Desired: models.NewQuantity(4), Actual: models.NewQuantity(1)}}, nil)
+	mockCategoryRepository.
+		EXPECT().
+		ReadCategories().
+		Return(models.Categories{}, nil)
+
+	notificationService := NewNotificationService(mocks.NewMockSubscriptionRepository(ctrl), mocks.NewMockChannelRepository(ctrl), mockItemRepository, mockCategoryRepository, nil)
+
+	req, err := http.NewRequest("GET", "/api/notifications/preview/digest?format=text", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/notifications/preview/{template}", notificationService.PreviewNotification)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+	if !strings.Contains(rr.Body.String(), "- Milk: 3") {
+		t.Errorf("preview doesn't contain the missing item:\n%s", rr.Body.String())
+	}
+}
+
+func TestPreviewUnknownTemplate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	notificationService := NewNotificationService(mocks.NewMockSubscriptionRepository(ctrl), mocks.NewMockChannelRepository(ctrl), mocks.NewMockItemRepository(ctrl), mocks.NewMockCategoryRepository(ctrl), nil)
+
+	req, err := http.NewRequest("GET", "/api/notifications/preview/expiring-lots", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/notifications/preview/{template}", notificationService.PreviewNotification)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusNotFound)
+	}
+}
+
+func TestSendDigest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockSubscriptionRepository := mocks.NewMockSubscriptionRepository(ctrl)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+	mockCategoryRepository := mocks.NewMockCategoryRepository(ctrl)
+
+	mockItemRepository.
+		EXPECT().
+		ReadItems(repositories.ItemFilter{}).
+		Return([]models.Item{{ID: 1, Name: "Milk", Desired: models.NewQuantity(4), Actual: models.NewQuantity(1)}}, nil)
+	mockCategoryRepository.
+		EXPECT().
+		ReadCategories().
+		Return(models.Categories{}, nil)
+	mockSubscriptionRepository.
+		EXPECT().
+		ReadSubscriptions(models.EventDigest).
+		Return([]models.Subscription{{ID: 1, Email: "ana@example.com", Digest: true}, {ID: 2, Email: "leo@example.com", Digest: true}}, nil)
+
+	mockChannelRepository := mocks.NewMockChannelRepository(ctrl)
+	mockChannelRepository.
+		EXPECT().
+		ReadChannels().
+		Return([]models.Channel{}, nil)
+
+	mailer := &fakeMailer{}
+	notificationService := NewNotificationService(mockSubscriptionRepository, mockChannelRepository, mockItemRepository, mockCategoryRepository, mailer)
+
+	if err := notificationService.SendDigest(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mailer.messages) != 2 {
+		t.Fatalf("wrong number of emails: got %v want %v", len(mailer.messages), 2)
+	}
+	if to := mailer.messages[1].To; len(to) != 1 || to[0] != "leo@example.com" {
+		t.Errorf("wrong recipients: got %v want %v", to, []string{"leo@example.com"})
+	}
+}
+
+func TestSendDigestNothingMissing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+	mockCategoryRepository := mocks.NewMockCategoryRepository(ctrl)
+
+	mockItemRepository.
+		EXPECT().
+		ReadItems(repositories.ItemFilter{}).
+		Return([]models.Item{{ID: 1, Name: "Milk", Desired: models.NewQuantity(4), Actual: models.NewQuantity(4)}}, nil)
+	mockCategoryRepository.
+		EXPECT().
+		ReadCategories().
+		Return(models.Categories{}, nil)
+
+	mailer := &fakeMailer{}
+	notificationService := NewNotificationService(mocks.NewMockSubscriptionRepository(ctrl), mocks.NewMockChannelRepository(ctrl), mockItemRepository, mockCategoryRepository, mailer)
+
+	if err := notificationService.SendDigest(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mailer.messages) != 0 {
+		t.Errorf("wrong number of emails: got %v want %v", len(mailer.messages), 0)
+	}
+}
+
+func TestWithdrawItemNotifiesLowStock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+
+	item := models.Item{ID: 1, Name: "Milk", Desired: models.NewQuantity(2), Actual: models.NewQuantity(2)}
+	mockItemRepository.
+		EXPECT().
+		ReadItem(1).
+		Return(item, nil)
+	withdrawn := item
+	withdrawn.Actual = models.NewQuantity(1)
+	mockItemRepository.
+		EXPECT().
+		WithdrawItem(1, models.NewQuantity(1)).
+		Return(withdrawn, nil)
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+	notified := make(chan models.Item, 1)
+	itemService.LowStock = func(item models.Item) { notified <- item }
+
+	req, err := http.NewRequest("GET", "/api/items/withdraw/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/withdraw/{itemId}", itemService.WithdrawItem)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+	select {
+	case item := <-notified:
+		if item.Name != "Milk" {
+			t.Errorf("wrong item: got %v want %v", item.Name, "Milk")
+		}
+	case <-time.After(time.Second):
+		t.Error("low stock not notified")
+	}
+}
+
+func TestNotifyLowStockRoutesChannels(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockChannelRepository := mocks.NewMockChannelRepository(ctrl)
+	mockCategoryRepository := mocks.NewMockCategoryRepository(ctrl)
+
+	received := make(chan string, 2)
+	standIn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.URL.Path
+	}))
+	defer standIn.Close()
+
+	food, cleaning := 1, 2
+	mockChannelRepository.
+		EXPECT().
+		ReadChannels().
+		Return([]models.Channel{
+			{ID: 1, Name: "Kitchen", Kind: models.ChannelWebhook, URL: standIn.URL + "/kitchen", Rules: []models.ChannelRule{{CategoryID: &food}}},
+			{ID: 2, Name: "Laundry", Kind: models.ChannelWebhook, URL: standIn.URL + "/laundry", Rules: []models.ChannelRule{{CategoryID: &cleaning}}},
+		}, nil)
+	mockCategoryRepository.
+		EXPECT().
+		ReadCategories().
+		Return(models.Categories{{ID: food, Name: "Food"}, {ID: cleaning, Name: "Cleaning"}}, nil)
+
+	notificationService := NewNotificationService(mocks.NewMockSubscriptionRepository(ctrl), mockChannelRepository, mocks.NewMockItemRepository(ctrl), mockCategoryRepository, nil)
+	notificationService.NotifyLowStock(models.Item{ID: 1, Name: "Milk", CategoryID: &food, Desired: models.NewQuantity(2)})
+
+	if path := <-received; path != "/kitchen" {
+		t.Errorf("wrong channel: got %v want %v", path, "/kitchen")
+	}
+	if len(received) != 0 {
+		t.Errorf("wrong number of notifications: got %v want %v", 1+len(received), 1)
+	}
+}
+
+func TestNotifyDecisionEmailsContact(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+
+	mockItemRepository.
+		EXPECT().
+		ReadItem(1).
+		Return(models.Item{ID: 1, Name: "Gloves"}, nil)
+
+	mailer := &fakeMailer{}
+	notificationService := NewNotificationService(mocks.NewMockSubscriptionRepository(ctrl), mocks.NewMockChannelRepository(ctrl), mockItemRepository, mocks.NewMockCategoryRepository(ctrl), mailer)
+	notificationService.NotifyDecision(models.WithdrawalRequest{ID: 1, ItemID: 1, Quantity: models.NewQuantity(10), Requester: "Alice", Contact: "alice@example.com", Status: models.RequestApproved, Approver: "Bob"})
+
+	if len(mailer.messages) != 1 {
+		t.Fatalf("wrong number of emails: got %v want %v", len(mailer.messages), 1)
+	}
+	if to := mailer.messages[0].To; len(to) != 1 || to[0] != "alice@example.com" {
+		t.Errorf("wrong recipients: got %v want %v", to, []string{"alice@example.com"})
+	}
+}
+
+func TestNotifyDecisionWithoutContact(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mailer := &fakeMailer{}
+	notificationService := NewNotificationService(mocks.NewMockSubscriptionRepository(ctrl), mocks.NewMockChannelRepository(ctrl), mocks.NewMockItemRepository(ctrl), mocks.NewMockCategoryRepository(ctrl), mailer)
+	notificationService.NotifyDecision(models.WithdrawalRequest{ID: 1, ItemID: 1, Quantity: models.NewQuantity(10), Requester: "Alice", Status: models.RequestRejected, Approver: "Bob"})
+
+	if len(mailer.messages) != 0 {
+		t.Errorf("wrong number of emails: got %v want %v", len(mailer.messages), 0)
+	}
+}
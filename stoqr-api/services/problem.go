@@ -0,0 +1,85 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/forecast"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/notify"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+	"github.com/leandroberetta/stoqr/stoqr-api/server"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// problemTypes are the types and titles of the problems caused by known errors
+var problemTypes = []struct {
+	err   error
+	name  string
+	title string
+}{
+	{gorm.ErrRecordNotFound, "not-found", "Resource not found"},
+	{repositories.ErrInsufficientStock, "insufficient-stock", "Insufficient stock"},
+	{repositories.ErrSerialized, "serialized-item", "Operation not supported on serialized items"},
+	{repositories.ErrBorrowable, "borrowable-item", "Borrowable items are checked out"},
+	{repositories.ErrKit, "kit", "Operation not supported on kits"},
+	{repositories.ErrInvalidComponent, "invalid-component", "Invalid component"},
+	{repositories.ErrInvalidConversion, "invalid-conversion", "Invalid conversion"},
+	{repositories.ErrInvalidCategory, "invalid-category", "Invalid category"},
+	{repositories.ErrInvalidTag, "invalid-tag", "Invalid tag"},
+	{repositories.ErrDuplicateName, "duplicate-name", "Name already used"},
+	{repositories.ErrInvalidRange, "invalid-range", "Invalid range"},
+	{repositories.ErrUndoExpired, "undo-expired", "Undo window has passed"},
+	{repositories.ErrUndoConflict, "undo-conflict", "Operation cannot be undone"},
+	{repositories.ErrApprovalRequired, "approval-required", "Withdrawal requires approval"},
+	{repositories.ErrInvalidPolicy, "invalid-policy", "Invalid policy"},
+	{repositories.ErrRequestClosed, "request-closed", "Request was already decided"},
+	{repositories.ErrReservationClosed, "reservation-closed", "Reservation is not active"},
+	{repositories.ErrNotSerialized, "not-serialized", "Item is not serialized"},
+	{repositories.ErrAssetStatus, "asset-status", "Invalid asset status change"},
+	{repositories.ErrNotBorrowable, "not-borrowable", "Item is not borrowable"},
+	{repositories.ErrLoanClosed, "loan-closed", "Loan was already returned"},
+	{repositories.ErrStocktakeClosed, "stocktake-closed", "Stocktake is not open"},
+	{repositories.ErrStaleCount, "stale-count", "Count is out of date"},
+	{repositories.ErrOutOfScope, "out-of-scope", "Item is out of the stocktake scope"},
+	{repositories.ErrInvalidSubscription, "invalid-subscription", "Invalid subscription"},
+	{repositories.ErrInvalidChannel, "invalid-channel", "Invalid channel"},
+	{notify.ErrUnknownTemplate, "unknown-template", "Unknown template"},
+	{models.ErrInvalidQuantity, "invalid-quantity", "Invalid quantity"},
+	{models.ErrIncompatibleUnits, "incompatible-units", "Incompatible units"},
+	{forecast.ErrInvalidParams, "invalid-forecast", "Invalid forecast parameters"},
+	{forecast.ErrNotEnoughData, "not-enough-data", "Not enough data to forecast"},
+	{errInvalidWindow, "invalid-window", "Invalid window"},
+	{errUnknownSort, "unknown-sort", "Unknown sort field"},
+	{errUnknownMethod, "unknown-method", "Unknown valuation method"},
+	{errInvalidRequest, "invalid-request", "Invalid request"},
+	{errBodyTooLarge, "body-too-large", "Request body too large"},
+}
+
+// errInvalidRequest is the error of a request with invalid fields
+var errInvalidRequest = errors.New("the request has invalid fields")
+
+// writeProblem logs an error and writes it as a problem with a status and optionally the
+// fields causing it. The detail of server errors is not sent to the client.
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, err error, fields ...server.FieldError) {
+	logError(r, status, err)
+	if status >= http.StatusInternalServerError {
+		span := trace.SpanFromContext(r.Context())
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	problem := server.Problem{Status: status, Errors: fields}
+	for _, problemType := range problemTypes {
+		if errors.Is(err, problemType.err) {
+			problem.Type = "/problems/" + problemType.name
+			problem.Title = problemType.title
+			break
+		}
+	}
+	if err != nil && status < http.StatusInternalServerError {
+		problem.Detail = err.Error()
+	}
+	server.WriteProblem(w, r, problem)
+}
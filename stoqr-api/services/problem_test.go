@@ -0,0 +1,188 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/mocks"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+	"github.com/leandroberetta/stoqr/stoqr-api/server"
+)
+
+func decodeProblem(t *testing.T, rr *httptest.ResponseRecorder) server.Problem {
+	t.Helper()
+	if contentType := rr.Header().Get("content-type"); contentType != server.ProblemContentType {
+		t.Fatalf("wrong content type: want %v, got %v", server.ProblemContentType, contentType)
+	}
+	problem := server.Problem{}
+	if err := json.NewDecoder(rr.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	return problem
+}
+
+func TestWithdrawItemProblem(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+	item := models.Item{ID: 1, Name: "Milk", Desired: models.NewQuantity(2)}
+
+	mockItemRepository.
+		EXPECT().
+		ReadItem(1).
+		Return(item, nil)
+
+	mockItemRepository.
+		EXPECT().
+		WithdrawItem(1, models.NewQuantity(1)).
+		Return(models.Item{}, repositories.ErrInsufficientStock)
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+
+	req, err := http.NewRequest("GET", "/api/items/withdraw/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(server.RequestIDHeader, "req-1")
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/withdraw/{itemId}", itemService.WithdrawItem)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusConflict {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusConflict)
+	}
+	problem := decodeProblem(t, rr)
+	if problem.Type != "/problems/insufficient-stock" {
+		t.Errorf("wrong type: want %v, got %v", "/problems/insufficient-stock", problem.Type)
+	}
+	if problem.Detail != repositories.ErrInsufficientStock.Error() {
+		t.Errorf("wrong detail: want %v, got %v", repositories.ErrInsufficientStock.Error(), problem.Detail)
+	}
+	if problem.Instance != "/api/items/withdraw/1" {
+		t.Errorf("wrong instance: want %v, got %v", "/api/items/withdraw/1", problem.Instance)
+	}
+	if problem.RequestID != "req-1" {
+		t.Errorf("wrong request id: want %v, got %v", "req-1", problem.RequestID)
+	}
+}
+
+func TestRestockItemFieldErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	itemService := NewItemService(mocks.NewMockItemRepository(ctrl), mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+
+	req, err := http.NewRequest("POST", "/api/items/1/restock", bytes.NewReader([]byte(`{"quantity":0,"unitCost":-1}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/{itemId}/restock", itemService.RestockItem)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusBadRequest)
+	}
+	problem := decodeProblem(t, rr)
+	fields := []string{}
+	for _, field := range problem.Errors {
+		fields = append(fields, field.Field)
+	}
+	if len(fields) != 2 || fields[0] != "quantity" || fields[1] != "unitCost" {
+		t.Errorf("wrong field errors: want %v, got %v", []string{"quantity", "unitCost"}, fields)
+	}
+}
+
+func TestCreateReservationFieldErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	reservationService := NewReservationService(mocks.NewMockReservationRepository(ctrl), mocks.NewMockItemRepository(ctrl))
+
+	req, err := http.NewRequest("POST", "/api/reservations", bytes.NewReader([]byte(`{"itemId":1,"quantity":0}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(reservationService.CreateReservation)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusBadRequest)
+	}
+	problem := decodeProblem(t, rr)
+	fields := []string{}
+	for _, field := range problem.Errors {
+		fields = append(fields, field.Field)
+	}
+	if len(fields) != 2 || fields[0] != "quantity" || fields[1] != "holder" {
+		t.Errorf("wrong field errors: want %v, got %v", []string{"quantity", "holder"}, fields)
+	}
+}
+
+func TestCheckOutProblem(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockLoanRepository := mocks.NewMockLoanRepository(ctrl)
+
+	mockLoanRepository.
+		EXPECT().
+		CheckOut(gomock.Any()).
+		Return(repositories.ErrAssetStatus)
+
+	loanService := NewLoanService(mockLoanRepository)
+
+	req, err := http.NewRequest("POST", "/api/loans", bytes.NewReader([]byte(`{"itemId":1,"borrower":"Alice"}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(loanService.CreateLoan)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusConflict {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusConflict)
+	}
+	problem := decodeProblem(t, rr)
+	if problem.Type != "/problems/asset-status" {
+		t.Errorf("wrong type: want %v, got %v", "/problems/asset-status", problem.Type)
+	}
+}
+
+func TestProblemHidesServerErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+
+	mockItemRepository.
+		EXPECT().
+		ReadItems(gomock.Any()).
+		Return(nil, errors.New("pq: connection refused"))
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+
+	req, err := http.NewRequest("GET", "/api/items", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(itemService.ReadItems)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusInternalServerError {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusInternalServerError)
+	}
+	problem := decodeProblem(t, rr)
+	if problem.Detail != "" || problem.Type != "about:blank" {
+		t.Errorf("wrong problem: want no detail, got %+v", problem)
+	}
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+	"github.com/leandroberetta/stoqr/stoqr-api/server"
+)
+
+// Shopping list groupings
+const (
+	GroupByCategory = "category"
+	GroupByTag      = "tag"
+	GroupByNone     = "none"
+)
+
+// ReportService contains the business logic of stock movements and inventory reports
+type ReportService struct {
+	Items      repositories.ItemRepository
+	Movements  repositories.MovementRepository
+	Categories repositories.CategoryRepository
+}
+
+// ReadMovements is the api method to get the stock movements of an item
+func (svc *ReportService) ReadMovements(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["itemId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	movements, err := svc.Movements.ReadMovements(id, time.Now())
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(movements)
+}
+
+// ReadValuation is the api method to get the value of the inventory, by default using FIFO
+func (svc *ReportService) ReadValuation(w http.ResponseWriter, r *http.Request) {
+	method := r.FormValue("method")
+	if method == "" {
+		method = MethodFIFO
+	}
+	ledgers, err := svc.replay(method, time.Now(), nil)
+	if err != nil {
+		writeProblem(w, r, statusForReportError(err), err)
+		return
+	}
+	items, err := svc.Items.ReadItems(repositories.ItemFilter{})
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	valuation := models.Valuation{Method: method, Items: []models.ItemValuation{}}
+	for _, item := range items {
+		if item.IsKit() {
+			continue
+		}
+		value := 0
+		if ledger, ok := ledgers[item.ID]; ok {
+			value = ledger.value(item.Actual)
+		}
+		valuation.Items = append(valuation.Items, models.ItemValuation{
+			ItemID:   item.ID,
+			Name:     item.Name,
+			Quantity: item.Actual,
+			Value:    value,
+		})
+		valuation.Total += value
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(valuation)
+}
+
+// ReadConsumption is the api method to get the cost of the goods withdrawn in a period,
+// by default the current month using FIFO
+func (svc *ReportService) ReadConsumption(w http.ResponseWriter, r *http.Request) {
+	method := r.FormValue("method")
+	if method == "" {
+		method = MethodFIFO
+	}
+	now := time.Now()
+	from, err := parseTime(r.FormValue("from"), time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	to, err := parseTime(r.FormValue("to"), now)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	consumed := map[int]*models.ItemConsumption{}
+	_, err = svc.replay(method, to, func(movement models.Movement, cost int) {
+		withdrawn := movement.Withdrawn()
+		if withdrawn == 0 || movement.CreatedAt.Before(from) {
+			return
+		}
+		if withdrawn < 0 {
+			cost = -cost
+		}
+		if _, ok := consumed[movement.ItemID]; !ok {
+			consumed[movement.ItemID] = &models.ItemConsumption{ItemID: movement.ItemID}
+		}
+		consumed[movement.ItemID].Quantity += withdrawn
+		consumed[movement.ItemID].Cost += cost
+	})
+	if err != nil {
+		writeProblem(w, r, statusForReportError(err), err)
+		return
+	}
+	items, err := svc.Items.ReadItems(repositories.ItemFilter{})
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	consumption := models.Consumption{Method: method, From: from, To: to, Items: []models.ItemConsumption{}}
+	for _, item := range items {
+		if c, ok := consumed[item.ID]; ok {
+			c.Name = item.Name
+			consumption.Items = append(consumption.Items, *c)
+			consumption.Total += c.Cost
+		}
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(consumption)
+}
+
+// ReadShoppingList is the api method to get the items below their desired stock, optionally
+// filtered by category and tag, grouped by category (the default), by tag or not grouped
+func (svc *ReportService) ReadShoppingList(w http.ResponseWriter, r *http.Request) {
+	filter, err := itemFilter(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	group := r.FormValue("group")
+	if group == "" {
+		group = GroupByCategory
+	}
+	if group != GroupByCategory && group != GroupByTag && group != GroupByNone {
+		writeProblem(w, r, http.StatusBadRequest, errInvalidRequest, server.FieldError{Field: "group", Message: "must be category, tag or none"})
+		return
+	}
+	items, err := svc.Items.ReadItems(filter)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	categories, err := svc.Categories.ReadCategories()
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(shoppingList(items, categories, group))
+}
+
+// shoppingList groups the items below their desired stock, groups are sorted by
+// name leaving items without category or tag at the end
+func shoppingList(items []models.Item, categories models.Categories, group string) []models.ShoppingListGroup {
+	const others = "Others"
+	entries := map[string][]models.ShoppingListEntry{}
+	for _, item := range items {
+		if item.Actual >= item.Desired {
+			continue
+		}
+		entry := models.ShoppingListEntry{Item: item, Missing: item.Desired - item.Actual}
+		names := []string{others}
+		switch {
+		case group == GroupByNone:
+			names = []string{""}
+		case group == GroupByCategory && item.CategoryID != nil:
+			names = []string{categories.Path(*item.CategoryID)}
+		case group == GroupByTag && len(item.Tags) > 0:
+			names = []string{}
+			for _, tag := range item.Tags {
+				names = append(names, tag.Name)
+			}
+		}
+		for _, name := range names {
+			entries[name] = append(entries[name], entry)
+		}
+	}
+	groups := []models.ShoppingListGroup{}
+	for name, e := range entries {
+		groups = append(groups, models.ShoppingListGroup{Name: name, Entries: e})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if (groups[i].Name == others) != (groups[j].Name == others) {
+			return groups[j].Name == others
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+// replay applies every movement before a given time to a ledger per item,
+// calling visit with the cost of each movement if not nil
+func (svc *ReportService) replay(method string, to time.Time, visit func(models.Movement, int)) (map[int]*costLedger, error) {
+	if _, err := newCostLedger(method); err != nil {
+		return nil, err
+	}
+	movements, err := svc.Movements.ReadMovements(0, to)
+	if err != nil {
+		return nil, err
+	}
+	ledgers := map[int]*costLedger{}
+	for _, movement := range movements {
+		ledger, ok := ledgers[movement.ItemID]
+		if !ok {
+			ledger, _ = newCostLedger(method)
+			ledgers[movement.ItemID] = ledger
+		}
+		cost := ledger.apply(movement)
+		if visit != nil {
+			visit(movement, cost)
+		}
+	}
+	return ledgers, nil
+}
+
+// AddRoutes configures the reports routes into a given router
+func (svc *ReportService) AddRoutes(r *mux.Router) {
+	r.HandleFunc("/api/items/{itemId}/movements", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/items/{itemId}/movements", svc.ReadMovements).Methods(http.MethodGet)
+	r.HandleFunc("/api/reports/valuation", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/reports/valuation", svc.ReadValuation).Methods(http.MethodGet)
+	r.HandleFunc("/api/reports/consumption", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/reports/consumption", svc.ReadConsumption).Methods(http.MethodGet)
+	r.HandleFunc("/api/shopping-list", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/shopping-list", svc.ReadShoppingList).Methods(http.MethodGet)
+}
+
+// NewReportService creates a new report service
+func NewReportService(items repositories.ItemRepository, movements repositories.MovementRepository, categories repositories.CategoryRepository) *ReportService {
+	return &ReportService{Items: items, Movements: movements, Categories: categories}
+}
+
+func statusForReportError(err error) int {
+	if err == errUnknownMethod {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// parseTime parses a RFC 3339 timestamp or a date, returning fallback if value is empty
+func parseTime(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/leandroberetta/stoqr/stoqr-api/mocks"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+)
+
+func TestReadValuationOK(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+	mockMovementRepository := mocks.NewMockMovementRepository(ctrl)
+
+	mockMovementRepository.
+		EXPECT().
+		ReadMovements(0, gomock.Any()).
+		Return([]models.Movement{
+			{ItemID: 1, Kind: models.MovementReceipt, Quantity: models.NewQuantity(2), Cost: 200},
+			{ItemID: 1, Kind: models.MovementReceipt, Quantity: models.NewQuantity(2), Cost: 600},
+			{ItemID: 1, Kind: models.MovementWithdrawal, Quantity: models.NewQuantity(1)},
+		}, nil)
+
+	mockItemRepository.
+		EXPECT().
+		ReadItems(repositories.ItemFilter{}).
+		Return([]models.Item{{ID: 1, Name: "Test", Desired: models.NewQuantity(3), Actual: models.NewQuantity(3)}}, nil)
+
+	reportService := NewReportService(mockItemRepository, mockMovementRepository, mocks.NewMockCategoryRepository(ctrl))
+
+	req, err := http.NewRequest("GET", "/api/reports/valuation?method=average", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(reportService.ReadValuation)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+
+	valuation := models.Valuation{}
+	json.Unmarshal(rr.Body.Bytes(), &valuation)
+
+	if valuation.Total != 600 {
+		t.Errorf("wrong total: got %v want %v", valuation.Total, 600)
+	}
+}
+
+func TestReadValuationBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+	mockMovementRepository := mocks.NewMockMovementRepository(ctrl)
+
+	reportService := NewReportService(mockItemRepository, mockMovementRepository, mocks.NewMockCategoryRepository(ctrl))
+
+	req, err := http.NewRequest("GET", "/api/reports/valuation?method=lifo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(reportService.ReadValuation)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusBadRequest)
+	}
+}
+
+func TestReadConsumptionOK(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+	mockMovementRepository := mocks.NewMockMovementRepository(ctrl)
+
+	january := time.Date(2021, time.January, 10, 0, 0, 0, 0, time.UTC)
+	february := time.Date(2021, time.February, 10, 0, 0, 0, 0, time.UTC)
+
+	mockMovementRepository.
+		EXPECT().
+		ReadMovements(0, gomock.Any()).
+		Return([]models.Movement{
+			{ItemID: 1, Kind: models.MovementReceipt, Quantity: models.NewQuantity(2), Cost: 200, CreatedAt: january},
+			{ItemID: 1, Kind: models.MovementReceipt, Quantity: models.NewQuantity(2), Cost: 600, CreatedAt: january},
+			{ItemID: 1, Kind: models.MovementWithdrawal, Quantity: models.NewQuantity(1), CreatedAt: january},
+			{ItemID: 1, Kind: models.MovementWithdrawal, Quantity: models.NewQuantity(2), CreatedAt: february},
+		}, nil)
+
+	mockItemRepository.
+		EXPECT().
+		ReadItems(repositories.ItemFilter{}).
+		Return([]models.Item{{ID: 1, Name: "Test", Desired: models.NewQuantity(3), Actual: models.NewQuantity(1)}}, nil)
+
+	reportService := NewReportService(mockItemRepository, mockMovementRepository, mocks.NewMockCategoryRepository(ctrl))
+
+	req, err := http.NewRequest("GET", "/api/reports/consumption?from=2021-02-01&to=2021-03-01", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(reportService.ReadConsumption)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+
+	consumption := models.Consumption{}
+	json.Unmarshal(rr.Body.Bytes(), &consumption)
+
+	if consumption.Total != 400 {
+		t.Errorf("wrong total: got %v want %v", consumption.Total, 400)
+	}
+}
+
+func TestReadShoppingListOK(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+	mockCategoryRepository := mocks.NewMockCategoryRepository(ctrl)
+	cleaning := 1
+
+	mockItemRepository.
+		EXPECT().
+		ReadItems(repositories.ItemFilter{Tag: "weekly"}).
+		Return([]models.Item{
+			{ID: 1, Name: "Soap", Desired: models.NewQuantity(2), Actual: models.NewQuantity(1), CategoryID: &cleaning},
+			{ID: 2, Name: "Bleach", Desired: models.NewQuantity(1), Actual: models.NewQuantity(1), CategoryID: &cleaning},
+			{ID: 3, Name: "Milk", Desired: models.NewQuantity(4), Actual: 0},
+		}, nil)
+
+	mockCategoryRepository.
+		EXPECT().
+		ReadCategories().
+		Return(models.Categories{{ID: 1, Name: "Cleaning"}}, nil)
+
+	reportService := NewReportService(mockItemRepository, mocks.NewMockMovementRepository(ctrl), mockCategoryRepository)
+
+	req, err := http.NewRequest("GET", "/api/shopping-list?tag=weekly", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(reportService.ReadShoppingList)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+
+	groups := []models.ShoppingListGroup{}
+	json.Unmarshal(rr.Body.Bytes(), &groups)
+
+	if len(groups) != 2 || groups[0].Name != "Cleaning" || len(groups[0].Entries) != 1 || groups[1].Entries[0].Missing != models.NewQuantity(4) {
+		t.Errorf("wrong shopping list: got %+v", groups)
+	}
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+	"github.com/leandroberetta/stoqr/stoqr-api/server"
+	"gorm.io/gorm"
+)
+
+// DefaultExpirySchedule is the cron schedule expiring the reservations past their expiration by default
+const DefaultExpirySchedule = "*/5 * * * *"
+
+// ReservationService contains the business logic of reservations
+type ReservationService struct {
+	Repository repositories.ReservationRepository
+	Items      repositories.ItemRepository
+}
+
+// CreateReservation is the api method to set aside a quantity of an item
+func (svc *ReservationService) CreateReservation(w http.ResponseWriter, r *http.Request) {
+	reservation := models.Reservation{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&reservation)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	fields := []server.FieldError{}
+	if reservation.Quantity <= 0 {
+		fields = append(fields, server.FieldError{Field: "quantity", Message: "must be a positive number"})
+	}
+	if reservation.Holder == "" {
+		fields = append(fields, server.FieldError{Field: "holder", Message: "is required"})
+	}
+	if reservation.ExpiresAt != nil && reservation.ExpiresAt.Before(time.Now()) {
+		fields = append(fields, server.FieldError{Field: "expiresAt", Message: "must not be in the past"})
+	}
+	if len(fields) > 0 {
+		writeProblem(w, r, http.StatusBadRequest, errInvalidRequest, fields...)
+		return
+	}
+	defer r.Body.Close()
+	err = svc.Repository.CreateReservation(&reservation)
+	if err != nil {
+		writeProblem(w, r, statusForReservationError(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reservation)
+}
+
+// ReadReservation is the api method to get a reservation
+func (svc *ReservationService) ReadReservation(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["reservationId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	reservation, err := svc.Repository.ReadReservation(id)
+	if err != nil {
+		writeProblem(w, r, statusForReservationError(err), err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(reservation)
+}
+
+// ReadReservations is the api method to get the reservations, optionally of a single item
+func (svc *ReservationService) ReadReservations(w http.ResponseWriter, r *http.Request) {
+	itemID := 0
+	if value := r.FormValue("item"); value != "" {
+		var err error
+		if itemID, err = strconv.Atoi(value); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, err)
+			return
+		}
+	}
+	reservations, err := svc.Repository.ReadReservations(itemID)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(reservations)
+}
+
+// ReleaseReservation is the api method to give a reservation back to the available stock
+func (svc *ReservationService) ReleaseReservation(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["reservationId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	err = svc.Repository.ReleaseReservation(id)
+	if err != nil {
+		writeProblem(w, r, statusForReservationError(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// WithdrawReservation is the api method to withdraw the reserved stock, by default
+// all that remains unless the quantity (and optionally a compatible unit) is given
+func (svc *ReservationService) WithdrawReservation(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["reservationId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	var quantity models.Quantity
+	if value := r.FormValue("quantity"); value != "" {
+		quantity, err = models.ParseQuantity(value)
+		if err == nil && quantity <= 0 {
+			err = fmt.Errorf("%w: %q", models.ErrInvalidQuantity, value)
+		}
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, err, server.FieldError{Field: "quantity", Message: "must be a positive number"})
+			return
+		}
+	}
+	if unit := r.FormValue("unit"); quantity != 0 && unit != "" {
+		reservation, err := svc.Repository.ReadReservation(id)
+		if err != nil {
+			writeProblem(w, r, statusForReservationError(err), err)
+			return
+		}
+		item, err := svc.Items.ReadItem(reservation.ItemID)
+		if err != nil {
+			writeProblem(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		if quantity, err = item.Convert(quantity, unit); err != nil {
+			writeProblem(w, r, http.StatusBadRequest, err)
+			return
+		}
+	}
+	item, err := svc.Repository.WithdrawReservation(id, quantity)
+	if err != nil {
+		writeProblem(w, r, statusForReservationError(err), err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// AddRoutes configures the reservations routes into a given router
+func (svc *ReservationService) AddRoutes(r *mux.Router) {
+	r.HandleFunc("/api/reservations", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/reservations", svc.CreateReservation).Methods(http.MethodPost)
+	r.HandleFunc("/api/reservations", svc.ReadReservations).Methods(http.MethodGet)
+	r.HandleFunc("/api/reservations/{reservationId}", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/reservations/{reservationId}", svc.ReadReservation).Methods(http.MethodGet)
+	r.HandleFunc("/api/reservations/{reservationId}/release", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/reservations/{reservationId}/release", svc.ReleaseReservation).Methods(http.MethodPost)
+	r.HandleFunc("/api/reservations/{reservationId}/withdraw", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/reservations/{reservationId}/withdraw", svc.WithdrawReservation).Methods(http.MethodGet)
+}
+
+// NewReservationService creates a new reservation service
+func NewReservationService(repository repositories.ReservationRepository, items repositories.ItemRepository) *ReservationService {
+	return &ReservationService{Repository: repository, Items: items}
+}
+
+func statusForReservationError(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repositories.ErrApprovalRequired):
+		return http.StatusForbidden
+	case errors.Is(err, repositories.ErrReservationClosed),
+		errors.Is(err, repositories.ErrInsufficientStock):
+		return http.StatusConflict
+	case errors.Is(err, repositories.ErrKit),
+		errors.Is(err, repositories.ErrSerialized),
+		errors.Is(err, models.ErrInvalidQuantity):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
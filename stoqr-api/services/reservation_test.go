@@ -0,0 +1,151 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/mocks"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+)
+
+func TestCreateReservationOK(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockReservationRepository := mocks.NewMockReservationRepository(ctrl)
+
+	mockReservationRepository.
+		EXPECT().
+		CreateReservation(gomock.AssignableToTypeOf(&models.Reservation{})).
+		Return(nil)
+
+	reservationService := NewReservationService(mockReservationRepository, mocks.NewMockItemRepository(ctrl))
+
+	req, err := http.NewRequest("POST", "/api/reservations", bytes.NewReader([]byte(`{"itemId":1,"quantity":6,"holder":"Party"}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(reservationService.CreateReservation)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusCreated {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusCreated)
+	}
+}
+
+func TestCreateReservationConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockReservationRepository := mocks.NewMockReservationRepository(ctrl)
+
+	mockReservationRepository.
+		EXPECT().
+		CreateReservation(gomock.Any()).
+		Return(repositories.ErrInsufficientStock)
+
+	reservationService := NewReservationService(mockReservationRepository, mocks.NewMockItemRepository(ctrl))
+
+	req, err := http.NewRequest("POST", "/api/reservations", bytes.NewReader([]byte(`{"itemId":1,"quantity":6,"holder":"Party"}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(reservationService.CreateReservation)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusConflict {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusConflict)
+	}
+}
+
+func TestCreateReservationRequiresApproval(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockReservationRepository := mocks.NewMockReservationRepository(ctrl)
+
+	mockReservationRepository.
+		EXPECT().
+		CreateReservation(gomock.Any()).
+		Return(repositories.ErrApprovalRequired)
+
+	reservationService := NewReservationService(mockReservationRepository, mocks.NewMockItemRepository(ctrl))
+
+	req, err := http.NewRequest("POST", "/api/reservations", bytes.NewReader([]byte(`{"itemId":1,"quantity":10,"holder":"Alice"}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(reservationService.CreateReservation)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusForbidden {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusForbidden)
+	}
+}
+
+func TestWithdrawReservationOK(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockReservationRepository := mocks.NewMockReservationRepository(ctrl)
+
+	mockReservationRepository.
+		EXPECT().
+		WithdrawReservation(1, models.Quantity(0)).
+		Return(models.Item{ID: 1, Name: "Cups", Actual: models.NewQuantity(4), Available: models.NewQuantity(4)}, nil)
+
+	reservationService := NewReservationService(mockReservationRepository, mocks.NewMockItemRepository(ctrl))
+
+	req, err := http.NewRequest("GET", "/api/reservations/1/withdraw", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/reservations/{reservationId}/withdraw", reservationService.WithdrawReservation)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+
+	item := models.Item{}
+	json.Unmarshal(rr.Body.Bytes(), &item)
+
+	if item.Available != models.NewQuantity(4) {
+		t.Errorf("wrong available value: got %v want %v", item.Available, models.NewQuantity(4))
+	}
+}
+
+func TestReleaseReservationConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockReservationRepository := mocks.NewMockReservationRepository(ctrl)
+
+	mockReservationRepository.
+		EXPECT().
+		ReleaseReservation(1).
+		Return(repositories.ErrReservationClosed)
+
+	reservationService := NewReservationService(mockReservationRepository, mocks.NewMockItemRepository(ctrl))
+
+	req, err := http.NewRequest("POST", "/api/reservations/1/release", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/reservations/{reservationId}/release", reservationService.ReleaseReservation)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusConflict {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusConflict)
+	}
+}
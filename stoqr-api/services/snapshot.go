@@ -0,0 +1,91 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+	"gorm.io/gorm"
+)
+
+// DefaultSnapshotSchedule is the cron schedule recording the stock of every item by default
+const DefaultSnapshotSchedule = "@daily"
+
+// DefaultHistoryDays is how many days back the stock history goes when no start is given
+const DefaultHistoryDays = 30
+
+// historySteps are the named steps of a stock history, any Go duration is accepted too
+var historySteps = map[string]time.Duration{
+	"hour": time.Hour,
+	"day":  24 * time.Hour,
+	"week": 7 * 24 * time.Hour,
+}
+
+// ReadStockHistory is the api method to get the stock of an item over time for charting,
+// from a start (from, 30 days ago by default) to an end (to, now by default) every step
+// (step, hour, day, week or a duration like 6h, a day by default)
+func (svc *ItemService) ReadStockHistory(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["itemId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	now := time.Now()
+	to, err := parseTime(r.FormValue("to"), now)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	from, err := parseTime(r.FormValue("from"), to.AddDate(0, 0, -DefaultHistoryDays))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	step, err := historyStep(r.FormValue("step"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	points, err := svc.repository(r).ReadStockHistory(id, from, to, step)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			writeProblem(w, r, http.StatusNotFound, err)
+		case errors.Is(err, repositories.ErrInvalidRange), errors.Is(err, repositories.ErrKit):
+			writeProblem(w, r, http.StatusBadRequest, err)
+		default:
+			writeProblem(w, r, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(points)
+}
+
+// TakeSnapshot is the api method to record the stock of every item now
+func (svc *ItemService) TakeSnapshot(w http.ResponseWriter, r *http.Request) {
+	count, err := svc.repository(r).TakeSnapshot()
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]int64{"items": count})
+}
+
+// historyStep parses the step of a stock history
+func historyStep(value string) (time.Duration, error) {
+	if value == "" {
+		return historySteps["day"], nil
+	}
+	if step, ok := historySteps[value]; ok {
+		return step, nil
+	}
+	return time.ParseDuration(value)
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/mocks"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+	"gorm.io/gorm"
+)
+
+func TestReadItemAsOf(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+
+	asOf := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	mockItemRepository.
+		EXPECT().
+		ReadItemAt(1, gomock.Eq(asOf)).
+		Return(models.Item{ID: 1, Name: "Milk", Actual: models.NewQuantity(3), Available: models.NewQuantity(3)}, nil)
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+
+	req, err := http.NewRequest("GET", "/api/items/1?as_of=2021-03-01T12:00:00Z", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/{itemId}", itemService.ReadItem)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+
+	item := models.Item{}
+	json.Unmarshal(rr.Body.Bytes(), &item)
+
+	if want := models.NewQuantity(3); item.Actual != want {
+		t.Errorf("wrong actual: got %v want %v", item.Actual, want)
+	}
+}
+
+func TestReadItemAsOfBeforeCreation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+
+	mockItemRepository.
+		EXPECT().
+		ReadItemAt(1, gomock.Any()).
+		Return(models.Item{}, gorm.ErrRecordNotFound)
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+
+	req, err := http.NewRequest("GET", "/api/items/1?as_of=2021-03-01", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/{itemId}", itemService.ReadItem)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusNotFound)
+	}
+}
+
+func TestReadItemsAsOf(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+
+	asOf := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.Local)
+	mockItemRepository.
+		EXPECT().
+		ReadItems(repositories.ItemFilter{AsOf: asOf}).
+		Return([]models.Item{{ID: 1, Name: "Milk"}}, nil)
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+
+	req, err := http.NewRequest("GET", "/api/items?as_of=2021-03-01", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(itemService.ReadItems)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+}
+
+func TestReadStockHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+
+	from := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2021, time.March, 8, 0, 0, 0, 0, time.UTC)
+	mockItemRepository.
+		EXPECT().
+		ReadStockHistory(1, gomock.Eq(from), gomock.Eq(to), 7*24*time.Hour).
+		Return([]models.StockPoint{{At: from, Actual: models.NewQuantity(5)}, {At: to, Actual: models.NewQuantity(2)}}, nil)
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+
+	req, err := http.NewRequest("GET", "/api/items/1/history?from=2021-03-01T00:00:00Z&to=2021-03-08T00:00:00Z&step=week", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/{itemId}/history", itemService.ReadStockHistory)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+
+	points := []models.StockPoint{}
+	json.Unmarshal(rr.Body.Bytes(), &points)
+
+	if len(points) != 2 {
+		t.Fatalf("wrong number of points: got %v want %v", len(points), 2)
+	}
+}
+
+func TestReadStockHistoryInvalidStep(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+
+	req, err := http.NewRequest("GET", "/api/items/1/history?step=fortnight", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/{itemId}/history", itemService.ReadStockHistory)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusBadRequest)
+	}
+}
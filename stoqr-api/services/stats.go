@@ -0,0 +1,130 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+)
+
+// DefaultStatsWindows are the windows, in days, of the item stats when none is given
+var DefaultStatsWindows = []int{7, 30, 90}
+
+// DefaultSortWindow is the window, in days, of the stats used to sort the items when none is given
+const DefaultSortWindow = 30
+
+// maxStatsWindow is the longest window, in days, that can be requested
+const maxStatsWindow = 3650
+
+var errInvalidWindow = errors.New("invalid window")
+
+var errUnknownSort = errors.New("unknown sort field")
+
+// ReadItemStats is the api method to get the consumption stats of an item over
+// the given windows of days (window=7,30), the default windows if none is given
+func (svc *ItemService) ReadItemStats(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["itemId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	windows, err := statsWindows(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if len(windows) == 0 {
+		windows = DefaultStatsWindows
+	}
+	item, err := svc.repository(r).ReadItem(id)
+	if err != nil {
+		writeProblem(w, r, http.StatusNotFound, err)
+		return
+	}
+	report := models.ItemStatsReport{ItemID: item.ID, Name: item.Name, Available: item.Available}
+	now := time.Now()
+	for _, window := range windows {
+		withdrawn, err := svc.Movements.ReadWithdrawn(now.AddDate(0, 0, -window), now)
+		if err != nil {
+			writeProblem(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		report.Stats = append(report.Stats, models.NewItemStats(item.Available, withdrawn[item.ID], window, now))
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// itemStats sets the stats of the items over a window of days
+func (svc *ItemService) itemStats(items []models.Item, window int) error {
+	now := time.Now()
+	withdrawn, err := svc.Movements.ReadWithdrawn(now.AddDate(0, 0, -window), now)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		stats := models.NewItemStats(items[i].Available, withdrawn[items[i].ID], window, now)
+		items[i].Stats = &stats
+	}
+	return nil
+}
+
+// statsWindows reads the windows of days from the query parameters of a request,
+// either repeated or separated by commas
+func statsWindows(r *http.Request) ([]int, error) {
+	windows := []int{}
+	r.ParseForm()
+	for _, value := range r.Form["window"] {
+		for _, field := range strings.Split(value, ",") {
+			window, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil || window <= 0 || window > maxStatsWindow {
+				return nil, fmt.Errorf("%w: %s", errInvalidWindow, field)
+			}
+			windows = append(windows, window)
+		}
+	}
+	return windows, nil
+}
+
+// sortItems sorts the items by a field, in descending order if prefixed by a minus sign.
+// Items without a value for a stats field, because nothing was consumed, go last.
+func sortItems(items []models.Item, field string) error {
+	descending := strings.HasPrefix(field, "-")
+	field = strings.TrimPrefix(field, "-")
+	var less func(a, b *models.Item) (bool, bool)
+	switch field {
+	case "name":
+		less = func(a, b *models.Item) (bool, bool) { return a.Name < b.Name, true }
+	case "available":
+		less = func(a, b *models.Item) (bool, bool) { return a.Available < b.Available, true }
+	case "dailyConsumption":
+		less = func(a, b *models.Item) (bool, bool) {
+			return a.Stats.DailyConsumption < b.Stats.DailyConsumption, true
+		}
+	case "daysRemaining", "runOutDate":
+		less = func(a, b *models.Item) (bool, bool) {
+			if a.Stats.DaysRemaining == nil || b.Stats.DaysRemaining == nil {
+				return a.Stats.DaysRemaining != nil, false
+			}
+			return *a.Stats.DaysRemaining < *b.Stats.DaysRemaining, true
+		}
+	default:
+		return fmt.Errorf("%w: %s", errUnknownSort, field)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		isLess, comparable := less(&items[i], &items[j])
+		if descending && comparable {
+			isLess, _ = less(&items[j], &items[i])
+		}
+		return isLess
+	})
+	return nil
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/mocks"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+)
+
+func TestReadItemStatsOK(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+	mockMovementRepository := mocks.NewMockMovementRepository(ctrl)
+
+	mockItemRepository.
+		EXPECT().
+		ReadItem(1).
+		Return(models.Item{ID: 1, Name: "Milk", Actual: models.NewQuantity(4), Available: models.NewQuantity(4)}, nil)
+
+	mockMovementRepository.
+		EXPECT().
+		ReadWithdrawn(gomock.Any(), gomock.Any()).
+		Return(map[int]models.Quantity{1: models.NewQuantity(14)}, nil).
+		Times(2)
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mockMovementRepository)
+
+	req, err := http.NewRequest("GET", "/api/items/1/stats?window=7,14", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/{itemId}/stats", itemService.ReadItemStats)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+
+	report := models.ItemStatsReport{}
+	json.Unmarshal(rr.Body.Bytes(), &report)
+
+	if len(report.Stats) != 2 {
+		t.Fatalf("wrong number of windows: got %v want %v", len(report.Stats), 2)
+	}
+	if want := models.NewQuantity(2); report.Stats[0].DailyConsumption != want {
+		t.Errorf("wrong daily consumption: got %v want %v", report.Stats[0].DailyConsumption, want)
+	}
+	if want := 4.0; report.Stats[1].DaysRemaining == nil || *report.Stats[1].DaysRemaining != want {
+		t.Errorf("wrong days remaining: got %v want %v", report.Stats[1].DaysRemaining, want)
+	}
+}
+
+func TestReadItemsSortedByRunOut(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+	mockMovementRepository := mocks.NewMockMovementRepository(ctrl)
+
+	mockItemRepository.
+		EXPECT().
+		ReadItems(repositories.ItemFilter{}).
+		Return([]models.Item{
+			{ID: 1, Name: "Salt", Available: models.NewQuantity(10)},
+			{ID: 2, Name: "Bread", Available: models.NewQuantity(10)},
+			{ID: 3, Name: "Milk", Available: models.NewQuantity(10)},
+		}, nil)
+
+	mockMovementRepository.
+		EXPECT().
+		ReadWithdrawn(gomock.Any(), gomock.Any()).
+		Return(map[int]models.Quantity{2: models.NewQuantity(10), 3: models.NewQuantity(20)}, nil)
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mockMovementRepository)
+
+	req, err := http.NewRequest("GET", "/api/items?sort=runOutDate&window=10", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(itemService.ReadItems)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+
+	items := []models.Item{}
+	json.Unmarshal(rr.Body.Bytes(), &items)
+
+	names := []string{}
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	if len(names) != 3 || names[0] != "Milk" || names[1] != "Bread" || names[2] != "Salt" {
+		t.Errorf("wrong order: got %v want %v", names, []string{"Milk", "Bread", "Salt"})
+	}
+}
+
+func TestReadItemsUnknownSort(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+	mockMovementRepository := mocks.NewMockMovementRepository(ctrl)
+
+	mockItemRepository.
+		EXPECT().
+		ReadItems(gomock.Any()).
+		Return([]models.Item{}, nil)
+
+	mockMovementRepository.
+		EXPECT().
+		ReadWithdrawn(gomock.Any(), gomock.Any()).
+		Return(map[int]models.Quantity{}, nil)
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mockMovementRepository)
+
+	req, err := http.NewRequest("GET", "/api/items?sort=color", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(itemService.ReadItems)
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusBadRequest)
+	}
+}
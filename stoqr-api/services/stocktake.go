@@ -0,0 +1,254 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+	"github.com/leandroberetta/stoqr/stoqr-api/server"
+	"gorm.io/gorm"
+)
+
+// StocktakeService contains the business logic of stocktakes
+type StocktakeService struct {
+	Repository repositories.StocktakeRepository
+	Items      repositories.ItemRepository
+}
+
+// CreateStocktake is the api method to open a stocktake for a category and/or location
+func (svc *StocktakeService) CreateStocktake(w http.ResponseWriter, r *http.Request) {
+	stocktake := models.Stocktake{}
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&stocktake)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	defer r.Body.Close()
+	err = svc.Repository.CreateStocktake(&stocktake)
+	if err != nil {
+		writeProblem(w, r, statusForStocktakeError(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(stocktake)
+}
+
+// ReadStocktake is the api method to get a stocktake, an open stocktake includes
+// a line per item in its scope with the variance between counted and actual stock
+func (svc *StocktakeService) ReadStocktake(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["stocktakeId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	stocktake, err := svc.Repository.ReadStocktake(id)
+	if err != nil {
+		writeProblem(w, r, statusForStocktakeError(err), err)
+		return
+	}
+	if stocktake.Status == models.StocktakeOpen {
+		filter := repositories.ItemFilter{Location: stocktake.Location}
+		if stocktake.CategoryID != nil {
+			filter.CategoryID = *stocktake.CategoryID
+		}
+		items, err := svc.Items.ReadItems(filter)
+		if err != nil {
+			writeProblem(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		stocktake.Lines = stocktakeLines(stocktake, items)
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(stocktake)
+}
+
+// ReadStocktakes is the api method to get every stocktake
+func (svc *StocktakeService) ReadStocktakes(w http.ResponseWriter, r *http.Request) {
+	stocktakes, err := svc.Repository.ReadStocktakes()
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(stocktakes)
+}
+
+// UpdateCount is the api method to set the counted quantity of an item
+func (svc *StocktakeService) UpdateCount(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["stocktakeId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	itemID, err := strconv.Atoi(params["itemId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	count := models.StocktakeCount{}
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&count)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if count.Counted < 0 {
+		writeProblem(w, r, http.StatusBadRequest, errInvalidRequest, server.FieldError{Field: "counted", Message: "must not be negative"})
+		return
+	}
+	defer r.Body.Close()
+	count, err = svc.Repository.RecordCount(id, itemID, count.Counted, false)
+	if err != nil {
+		writeProblem(w, r, statusForStocktakeError(err), err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(count)
+}
+
+// ScanItem is the api method to count one unit of an item, or the quantity (and
+// optionally a compatible unit) given, it's meant to be called scanning the QR of the item
+func (svc *StocktakeService) ScanItem(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["stocktakeId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	itemID, err := strconv.Atoi(params["itemId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	quantity := models.NewQuantity(1)
+	if value := r.FormValue("quantity"); value != "" {
+		quantity, err = models.ParseQuantity(value)
+		if err == nil && quantity <= 0 {
+			err = fmt.Errorf("%w: %q", models.ErrInvalidQuantity, value)
+		}
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, err, server.FieldError{Field: "quantity", Message: "must be a positive number"})
+			return
+		}
+	}
+	item, err := svc.Items.ReadItem(itemID)
+	if err != nil {
+		writeProblem(w, r, http.StatusNotFound, err)
+		return
+	}
+	quantity, err = item.Convert(quantity, r.FormValue("unit"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	count, err := svc.Repository.RecordCount(id, itemID, quantity, true)
+	if err != nil {
+		writeProblem(w, r, statusForStocktakeError(err), err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(count)
+}
+
+// CommitStocktake is the api method to apply the counted quantities to the stock
+func (svc *StocktakeService) CommitStocktake(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["stocktakeId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	stocktake, err := svc.Repository.CommitStocktake(id)
+	if err != nil {
+		writeProblem(w, r, statusForStocktakeError(err), err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(stocktake)
+}
+
+// CancelStocktake is the api method to close a stocktake without changing the stock
+func (svc *StocktakeService) CancelStocktake(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["stocktakeId"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err)
+		return
+	}
+	err = svc.Repository.CancelStocktake(id)
+	if err != nil {
+		writeProblem(w, r, statusForStocktakeError(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddRoutes configures the stocktakes routes into a given router
+func (svc *StocktakeService) AddRoutes(r *mux.Router) {
+	r.HandleFunc("/api/stocktakes", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/stocktakes", svc.CreateStocktake).Methods(http.MethodPost)
+	r.HandleFunc("/api/stocktakes", svc.ReadStocktakes).Methods(http.MethodGet)
+	r.HandleFunc("/api/stocktakes/{stocktakeId}", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/stocktakes/{stocktakeId}", svc.ReadStocktake).Methods(http.MethodGet)
+	r.HandleFunc("/api/stocktakes/{stocktakeId}/items/{itemId}", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/stocktakes/{stocktakeId}/items/{itemId}", svc.UpdateCount).Methods(http.MethodPut)
+	r.HandleFunc("/api/stocktakes/{stocktakeId}/scan/{itemId}", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/stocktakes/{stocktakeId}/scan/{itemId}", svc.ScanItem).Methods(http.MethodGet)
+	r.HandleFunc("/api/stocktakes/{stocktakeId}/commit", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/stocktakes/{stocktakeId}/commit", svc.CommitStocktake).Methods(http.MethodPost)
+	r.HandleFunc("/api/stocktakes/{stocktakeId}/cancel", server.Options).Methods(http.MethodOptions)
+	r.HandleFunc("/api/stocktakes/{stocktakeId}/cancel", svc.CancelStocktake).Methods(http.MethodPost)
+}
+
+// NewStocktakeService creates a new stocktake service
+func NewStocktakeService(repository repositories.StocktakeRepository, items repositories.ItemRepository) *StocktakeService {
+	return &StocktakeService{Repository: repository, Items: items}
+}
+
+// stocktakeLines compares the items in the scope of a stocktake with their counts, the
+// variance is against the stock when the item was counted, kits are left out
+func stocktakeLines(stocktake models.Stocktake, items []models.Item) []models.StocktakeLine {
+	counts := map[int]models.StocktakeCount{}
+	for _, count := range stocktake.Counts {
+		counts[count.ItemID] = count
+	}
+	lines := []models.StocktakeLine{}
+	for _, item := range items {
+		if item.IsKit() {
+			continue
+		}
+		line := models.StocktakeLine{ItemID: item.ID, Name: item.Name, Actual: item.Actual}
+		if count, ok := counts[item.ID]; ok {
+			counted, variance := count.Counted, count.Counted-count.Expected
+			line.Counted = &counted
+			line.Variance = &variance
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func statusForStocktakeError(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repositories.ErrStocktakeClosed),
+		errors.Is(err, repositories.ErrStaleCount):
+		return http.StatusConflict
+	case errors.Is(err, repositories.ErrOutOfScope),
+		errors.Is(err, repositories.ErrKit),
+		errors.Is(err, repositories.ErrSerialized),
+		errors.Is(err, repositories.ErrInvalidCategory),
+		errors.Is(err, models.ErrInvalidQuantity):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/mocks"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+)
+
+func TestReadStocktakeOK(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockStocktakeRepository := mocks.NewMockStocktakeRepository(ctrl)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+
+	mockStocktakeRepository.
+		EXPECT().
+		ReadStocktake(1).
+		Return(models.Stocktake{
+			ID:       1,
+			Location: "pantry",
+			Status:   models.StocktakeOpen,
+			Counts:   []models.StocktakeCount{{ItemID: 1, Counted: models.NewQuantity(2), Expected: models.NewQuantity(3)}},
+		}, nil)
+
+	mockItemRepository.
+		EXPECT().
+		ReadItems(repositories.ItemFilter{Location: "pantry"}).
+		Return([]models.Item{
+			{ID: 1, Name: "Soap", Actual: models.NewQuantity(1)},
+			{ID: 2, Name: "Rice", Actual: models.NewQuantity(1)},
+		}, nil)
+
+	stocktakeService := NewStocktakeService(mockStocktakeRepository, mockItemRepository)
+
+	req, err := http.NewRequest("GET", "/api/stocktakes/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/stocktakes/{stocktakeId}", stocktakeService.ReadStocktake)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+
+	stocktake := models.Stocktake{}
+	json.Unmarshal(rr.Body.Bytes(), &stocktake)
+
+	if len(stocktake.Lines) != 2 || *stocktake.Lines[0].Variance != models.NewQuantity(-1) || stocktake.Lines[1].Counted != nil {
+		t.Errorf("wrong lines: got %+v", stocktake.Lines)
+	}
+}
+
+func TestScanItemOK(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockStocktakeRepository := mocks.NewMockStocktakeRepository(ctrl)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+
+	mockItemRepository.
+		EXPECT().
+		ReadItem(2).
+		Return(models.Item{ID: 2, Name: "Rice", Unit: "kg"}, nil)
+
+	mockStocktakeRepository.
+		EXPECT().
+		RecordCount(1, 2, models.Quantity(500), true).
+		Return(models.StocktakeCount{ItemID: 2, Counted: models.Quantity(500)}, nil)
+
+	stocktakeService := NewStocktakeService(mockStocktakeRepository, mockItemRepository)
+
+	req, err := http.NewRequest("GET", "/api/stocktakes/1/scan/2?quantity=500&unit=g", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/stocktakes/{stocktakeId}/scan/{itemId}", stocktakeService.ScanItem)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+}
+
+func TestCommitStocktakeConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockStocktakeRepository := mocks.NewMockStocktakeRepository(ctrl)
+
+	mockStocktakeRepository.
+		EXPECT().
+		CommitStocktake(1).
+		Return(models.Stocktake{}, repositories.ErrStocktakeClosed)
+
+	stocktakeService := NewStocktakeService(mockStocktakeRepository, mocks.NewMockItemRepository(ctrl))
+
+	req, err := http.NewRequest("POST", "/api/stocktakes/1/commit", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/stocktakes/{stocktakeId}/commit", stocktakeService.CommitStocktake)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusConflict {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusConflict)
+	}
+}
+
+func TestCommitStocktakeStaleCount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockStocktakeRepository := mocks.NewMockStocktakeRepository(ctrl)
+
+	mockStocktakeRepository.
+		EXPECT().
+		CommitStocktake(1).
+		Return(models.Stocktake{}, fmt.Errorf("%w: recount item 2", repositories.ErrStaleCount))
+
+	stocktakeService := NewStocktakeService(mockStocktakeRepository, mocks.NewMockItemRepository(ctrl))
+
+	req, err := http.NewRequest("POST", "/api/stocktakes/1/commit", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/stocktakes/{stocktakeId}/commit", stocktakeService.CommitStocktake)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusConflict {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusConflict)
+	}
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/logging"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// contextualItemRepository is an item repository able to run its statements in a context
+type contextualItemRepository interface {
+	InContext(ctx context.Context) repositories.ItemRepository
+}
+
+// repository returns the item repository running its statements in the context of a request
+// if it can, so they are traced as part of the request
+func (svc *ItemService) repository(r *http.Request) repositories.ItemRepository {
+	if repository, ok := svc.Repository.(contextualItemRepository); ok {
+		return repository.InContext(r.Context())
+	}
+	return svc.Repository
+}
+
+// operation records a span for each call to a handler of the service, nothing if there is no
+// tracer, and adds the operation and the item to the logs of the call
+func (svc *ItemService) operation(name string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tracer := svc.Tracer
+		if tracer == nil {
+			tracer = noop.Tracer{}
+		}
+		ctx, span := tracer.Start(r.Context(), "ItemService."+name, trace.WithSpanKind(trace.SpanKindInternal))
+		defer span.End()
+		attrs := []slog.Attr{slog.String("operation", name)}
+		if id, ok := mux.Vars(r)["itemId"]; ok {
+			span.SetAttributes(attribute.String("stoqr.item.id", id))
+			attrs = append(attrs, slog.String("item_id", id))
+		}
+		handler(w, r.WithContext(logging.With(ctx, attrs...)))
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/server"
+)
+
+// maxBodySize is the largest body of a request, in bytes
+const maxBodySize = 1 << 20
+
+// maxTextLength is the longest name or location of an item, in characters
+const maxTextLength = 100
+
+// errBodyTooLarge is the error of a request whose body is larger than maxBodySize
+var errBodyTooLarge = errors.New("request body too large")
+
+// errTrailingData is the error of a request whose body has data after the JSON value
+var errTrailingData = errors.New("unexpected data after the JSON value")
+
+// duplicateName is the field error of an item named like another item of its category
+var duplicateName = server.FieldError{Field: "name", Message: "is already used by another item of the category"}
+
+// decodeJSON decodes the body of a request into a value, rejecting bodies larger than
+// maxBodySize, unknown fields and anything after the value
+func decodeJSON(r *http.Request, value interface{}) error {
+	defer r.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	if err != nil {
+		return err
+	}
+	if len(body) > maxBodySize {
+		return errBodyTooLarge
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(value); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errTrailingData
+	}
+	return nil
+}
+
+// writeDecodeProblem writes the problem of a body that couldn't be decoded, unknown fields
+// and fields of the wrong type are reported as field errors
+func writeDecodeProblem(w http.ResponseWriter, r *http.Request, err error) {
+	var typeError *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, errBodyTooLarge):
+		writeProblem(w, r, http.StatusRequestEntityTooLarge, err)
+	case errors.As(err, &typeError):
+		field := server.FieldError{Field: typeError.Field, Message: fmt.Sprintf("cannot be a %s", typeError.Value)}
+		writeProblem(w, r, http.StatusUnprocessableEntity, fmt.Errorf("%w: %v", errInvalidRequest, err), field)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		name := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		field := server.FieldError{Field: name, Message: "is not a known field"}
+		writeProblem(w, r, http.StatusUnprocessableEntity, fmt.Errorf("%w: %v", errInvalidRequest, err), field)
+	default:
+		writeProblem(w, r, http.StatusBadRequest, err)
+	}
+}
+
+// itemErrors returns the errors of the fields of an item, trimming its name and location
+func itemErrors(item *models.Item) []server.FieldError {
+	fields := []server.FieldError{}
+	item.Name = strings.TrimSpace(item.Name)
+	item.Location = strings.TrimSpace(item.Location)
+	if item.Name == "" {
+		fields = append(fields, server.FieldError{Field: "name", Message: "is required"})
+	} else if utf8.RuneCountInString(item.Name) > maxTextLength {
+		fields = append(fields, server.FieldError{Field: "name", Message: fmt.Sprintf("must be at most %d characters", maxTextLength)})
+	}
+	if item.Desired < 0 {
+		fields = append(fields, server.FieldError{Field: "desired", Message: "must not be negative"})
+	}
+	if item.Actual < 0 {
+		fields = append(fields, server.FieldError{Field: "actual", Message: "must not be negative"})
+	}
+	if utf8.RuneCountInString(item.Location) > maxTextLength {
+		fields = append(fields, server.FieldError{Field: "location", Message: fmt.Sprintf("must be at most %d characters", maxTextLength)})
+	}
+	if item.CategoryID != nil && *item.CategoryID <= 0 {
+		fields = append(fields, server.FieldError{Field: "categoryId", Message: "must be positive"})
+	}
+	return fields
+}
+
+// restockErrors returns the errors of the fields of a restock
+func restockErrors(restock models.Restock) []server.FieldError {
+	fields := []server.FieldError{}
+	if restock.Quantity <= 0 {
+		fields = append(fields, server.FieldError{Field: "quantity", Message: "must be a positive number"})
+	}
+	if restock.UnitCost < 0 {
+		fields = append(fields, server.FieldError{Field: "unitCost", Message: "must not be negative"})
+	}
+	return fields
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/leandroberetta/stoqr/stoqr-api/mocks"
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+)
+
+func createItemRequest(t *testing.T, itemService *ItemService, body string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest("POST", "/api/items", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(itemService.CreateItem)
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestCreateItemValidation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	itemService := NewItemService(mocks.NewMockItemRepository(ctrl), mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+
+	rr := createItemRequest(t, itemService, `{"name":"  ","desired":-1,"actual":-2,"categoryId":0}`)
+
+	if status := rr.Code; status != http.StatusUnprocessableEntity {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusUnprocessableEntity)
+	}
+	problem := decodeProblem(t, rr)
+	fields := []string{}
+	for _, field := range problem.Errors {
+		fields = append(fields, field.Field)
+	}
+	want := []string{"name", "desired", "actual", "categoryId"}
+	if fmt.Sprint(fields) != fmt.Sprint(want) {
+		t.Errorf("wrong field errors: want %v, got %v", want, fields)
+	}
+}
+
+func TestCreateItemStrictDecoding(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	itemService := NewItemService(mocks.NewMockItemRepository(ctrl), mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+
+	cases := []struct {
+		body   string
+		status int
+		field  string
+	}{
+		{`{"name":"Milk","quantity":1}`, http.StatusUnprocessableEntity, "quantity"},
+		{`{"name":1}`, http.StatusUnprocessableEntity, "name"},
+		{`{"name":"Milk"} {"name":"Eggs"}`, http.StatusBadRequest, ""},
+		{`{"name":"` + strings.Repeat("a", maxBodySize) + `"}`, http.StatusRequestEntityTooLarge, ""},
+	}
+	for _, c := range cases {
+		rr := createItemRequest(t, itemService, c.body)
+
+		if status := rr.Code; status != c.status {
+			t.Errorf("handler returned wrong status code: got %v want %v",
+				status, c.status)
+			continue
+		}
+		problem := decodeProblem(t, rr)
+		if c.field != "" && (len(problem.Errors) != 1 || problem.Errors[0].Field != c.field) {
+			t.Errorf("wrong field errors: want %v, got %v", c.field, problem.Errors)
+		}
+	}
+}
+
+func TestUpdateItemDuplicateName(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockItemRepository := mocks.NewMockItemRepository(ctrl)
+
+	mockItemRepository.
+		EXPECT().
+		UpdateItem(1, models.Item{Name: "Milk", Desired: models.NewQuantity(2)}).
+		Return(repositories.ErrDuplicateName)
+
+	itemService := NewItemService(mockItemRepository, mocks.NewMockApprovalRepository(ctrl), mocks.NewMockMovementRepository(ctrl))
+
+	req, err := http.NewRequest("PUT", "/api/items/1", bytes.NewReader([]byte(`{"name":" Milk ","desired":2}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/items/{itemId}", itemService.UpdateItem)
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusUnprocessableEntity {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusUnprocessableEntity)
+	}
+	problem := decodeProblem(t, rr)
+	if len(problem.Errors) != 1 || problem.Errors[0].Field != "name" {
+		t.Errorf("wrong field errors: want %v, got %v", "name", problem.Errors)
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+)
+
+// Valuation methods
+const (
+	MethodFIFO    = "fifo"
+	MethodAverage = "average"
+)
+
+var errUnknownMethod = errors.New("unknown valuation method")
+
+// costLayer is a quantity received and its total cost
+type costLayer struct {
+	quantity models.Quantity
+	cost     int64
+}
+
+// share returns the cost of part of the quantity of a layer
+func (layer costLayer) share(quantity models.Quantity) int64 {
+	if quantity == layer.quantity {
+		return layer.cost
+	}
+	if layer.quantity <= 0 {
+		return 0
+	}
+	return (layer.cost*int64(quantity) + int64(layer.quantity)/2) / int64(layer.quantity)
+}
+
+// costLedger replays the movements of an item to know the cost of the units in stock.
+// Units whose cost is unknown (stock that existed before receipts were recorded or
+// found in a stocktake) are costed at the unit cost of the last receipt. The receipts
+// keep their total cost and the costs are only divided by a quantity when units are
+// issued. Costs are kept in thousandths of a cent so that decimal quantities don't lose
+// precision, and rounded to cents when reported.
+type costLedger struct {
+	method   string
+	layers   []costLayer
+	quantity models.Quantity
+	cost     int64
+	last     costLayer
+}
+
+func newCostLedger(method string) (*costLedger, error) {
+	if method != MethodFIFO && method != MethodAverage {
+		return nil, errUnknownMethod
+	}
+	return &costLedger{method: method}, nil
+}
+
+// apply applies a movement to the ledger returning the cost of the units it moved. The
+// units given back by the undo of a withdrawal are costed at the last known unit cost and
+// the undo of a receipt takes back the most recent units.
+func (l *costLedger) apply(movement models.Movement) int {
+	switch movement.Kind {
+	case models.MovementReceipt:
+		l.receive(movement.Quantity, movement.Cost)
+	case models.MovementWithdrawal:
+		return cents(l.issue(movement.Quantity))
+	case models.MovementAdjustment, models.MovementUndo:
+		if movement.Quantity > 0 {
+			layer := costLayer{quantity: movement.Quantity, cost: l.last.share(movement.Quantity)}
+			l.add(layer)
+			return cents(layer.cost)
+		}
+		if movement.Kind == models.MovementUndo {
+			return cents(l.takeBack(-movement.Quantity))
+		}
+		return cents(l.issue(-movement.Quantity))
+	}
+	return 0
+}
+
+// receive adds a quantity received at a total cost in cents
+func (l *costLedger) receive(quantity models.Quantity, cost int) {
+	l.last = costLayer{quantity: quantity, cost: int64(cost) * int64(models.NewQuantity(1))}
+	l.add(l.last)
+}
+
+func (l *costLedger) add(layer costLayer) {
+	l.quantity += layer.quantity
+	l.cost += layer.cost
+	l.layers = append(l.layers, layer)
+}
+
+// issue takes units out of the ledger returning their cost
+func (l *costLedger) issue(quantity models.Quantity) int64 {
+	known := quantity
+	if known > l.quantity {
+		known = l.quantity
+	}
+	cost := l.last.share(quantity - known)
+	if known <= 0 {
+		return cost
+	}
+	if l.method == MethodAverage {
+		issued := costLayer{quantity: l.quantity, cost: l.cost}.share(known)
+		l.cost -= issued
+		l.quantity -= known
+		return cost + issued
+	}
+	for known > 0 {
+		layer := &l.layers[0]
+		taken := known
+		if taken > layer.quantity {
+			taken = layer.quantity
+		}
+		issued := layer.share(taken)
+		cost += issued
+		l.cost -= issued
+		l.quantity -= taken
+		layer.cost -= issued
+		layer.quantity -= taken
+		known -= taken
+		if layer.quantity == 0 {
+			l.layers = l.layers[1:]
+		}
+	}
+	return cost
+}
+
+// takeBack removes the most recent units from the ledger returning their cost, the
+// average method removes them at the average cost
+func (l *costLedger) takeBack(quantity models.Quantity) int64 {
+	if l.method == MethodAverage || quantity >= l.quantity {
+		return l.issue(quantity)
+	}
+	cost := int64(0)
+	for quantity > 0 {
+		layer := &l.layers[len(l.layers)-1]
+		taken := quantity
+		if taken > layer.quantity {
+			taken = layer.quantity
+		}
+		removed := layer.share(taken)
+		cost += removed
+		l.cost -= removed
+		l.quantity -= taken
+		layer.cost -= removed
+		layer.quantity -= taken
+		quantity -= taken
+		if layer.quantity == 0 {
+			l.layers = l.layers[:len(l.layers)-1]
+		}
+	}
+	return cost
+}
+
+// value returns the value in cents of the units on hand, which may differ
+// from the ledger when the stock was corrected by hand
+func (l *costLedger) value(onHand models.Quantity) int {
+	if onHand <= 0 {
+		return 0
+	}
+	if onHand >= l.quantity {
+		return cents(l.cost + l.last.share(onHand-l.quantity))
+	}
+	ledger := *l
+	ledger.layers = append([]costLayer{}, l.layers...)
+	ledger.issue(l.quantity - onHand)
+	return cents(ledger.cost)
+}
+
+// cents rounds a cost kept by the ledger to cents
+func cents(cost int64) int {
+	scale := int64(models.NewQuantity(1))
+	return int((cost + scale/2) / scale)
+}
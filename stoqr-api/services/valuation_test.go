@@ -0,0 +1,108 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/leandroberetta/stoqr/stoqr-api/models"
+)
+
+func TestCostLedger(t *testing.T) {
+	movements := []models.Movement{
+		{Kind: models.MovementReceipt, Quantity: models.NewQuantity(10), Cost: 1000},
+		{Kind: models.MovementReceipt, Quantity: models.NewQuantity(10), Cost: 2000},
+		{Kind: models.MovementWithdrawal, Quantity: models.NewQuantity(15)},
+	}
+
+	cases := []struct {
+		method string
+		cost   int
+		value  int
+	}{
+		{method: MethodFIFO, cost: 10*100 + 5*200, value: 5 * 200},
+		{method: MethodAverage, cost: 15 * 150, value: 5 * 150},
+	}
+
+	for _, c := range cases {
+		t.Run(c.method, func(t *testing.T) {
+			ledger, err := newCostLedger(c.method)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cost := 0
+			for _, movement := range movements {
+				cost += ledger.apply(movement)
+			}
+			if cost != c.cost {
+				t.Errorf("wrong cost: got %v want %v", cost, c.cost)
+			}
+			if value := ledger.value(models.NewQuantity(5)); value != c.value {
+				t.Errorf("wrong value: got %v want %v", value, c.value)
+			}
+		})
+	}
+}
+
+func TestCostLedgerUnknownCost(t *testing.T) {
+	ledger, _ := newCostLedger(MethodFIFO)
+	ledger.receive(models.NewQuantity(2), 600)
+
+	if cost := cents(ledger.issue(models.NewQuantity(3))); cost != 900 {
+		t.Errorf("wrong cost: got %v want %v", cost, 900)
+	}
+	if value := ledger.value(models.NewQuantity(4)); value != 1200 {
+		t.Errorf("wrong value: got %v want %v", value, 1200)
+	}
+}
+
+func TestCostLedgerDecimalQuantities(t *testing.T) {
+	ledger, _ := newCostLedger(MethodAverage)
+	ledger.receive(models.Quantity(1500), 299)
+
+	if cost := cents(ledger.issue(models.Quantity(250))); cost != 50 {
+		t.Errorf("wrong cost: got %v want %v", cost, 50)
+	}
+	if value := ledger.value(models.Quantity(1250)); value != 249 {
+		t.Errorf("wrong value: got %v want %v", value, 249)
+	}
+}
+
+func TestCostLedgerExactTotals(t *testing.T) {
+	ledger, _ := newCostLedger(MethodFIFO)
+	ledger.receive(models.NewQuantity(3), 100)
+
+	if cost := cents(ledger.issue(models.NewQuantity(1))); cost != 33 {
+		t.Errorf("wrong cost: got %v want %v", cost, 33)
+	}
+	if value := ledger.value(models.NewQuantity(2)); value != 67 {
+		t.Errorf("wrong value: got %v want %v", value, 67)
+	}
+	if cost := cents(ledger.issue(models.NewQuantity(2))); cost != 67 {
+		t.Errorf("wrong cost: got %v want %v", cost, 67)
+	}
+}
+
+func TestCostLedgerUndo(t *testing.T) {
+	ledger, _ := newCostLedger(MethodFIFO)
+	movements := []models.Movement{
+		{Kind: models.MovementReceipt, Quantity: models.NewQuantity(10), Cost: 1000},
+		{Kind: models.MovementReceipt, Quantity: models.NewQuantity(10), Cost: 2000},
+		{Kind: models.MovementUndo, Quantity: models.NewQuantity(-10), Cost: 2000},
+		{Kind: models.MovementWithdrawal, Quantity: models.NewQuantity(4)},
+	}
+	costs := []int{0, 0, 2000, 400}
+
+	for i, movement := range movements {
+		if cost := ledger.apply(movement); cost != costs[i] {
+			t.Errorf("wrong cost of movement %d: got %v want %v", i, cost, costs[i])
+		}
+	}
+	if value := ledger.value(models.NewQuantity(6)); value != 600 {
+		t.Errorf("wrong value: got %v want %v", value, 600)
+	}
+}
+
+func TestCostLedgerUnknownMethod(t *testing.T) {
+	if _, err := newCostLedger("lifo"); err != errUnknownMethod {
+		t.Errorf("wrong error: want %v, got %v", errUnknownMethod, err)
+	}
+}
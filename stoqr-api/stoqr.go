@@ -1,39 +1,277 @@
 package main
 
 import (
+	"context"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
-	"github.com/gorilla/mux"
 	"github.com/leandroberetta/stoqr/stoqr-api/database"
+	"github.com/leandroberetta/stoqr/stoqr-api/logging"
 	"github.com/leandroberetta/stoqr/stoqr-api/models"
+	"github.com/leandroberetta/stoqr/stoqr-api/notify"
+	"github.com/leandroberetta/stoqr/stoqr-api/ratelimit"
 	"github.com/leandroberetta/stoqr/stoqr-api/repositories"
+	"github.com/leandroberetta/stoqr/stoqr-api/scheduler"
 	"github.com/leandroberetta/stoqr/stoqr-api/server"
 	"github.com/leandroberetta/stoqr/stoqr-api/services"
+	"github.com/leandroberetta/stoqr/stoqr-api/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Build information, set with -ldflags "-X main.version=..." when building
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
 )
 
 func main() {
-	log.Println("Starting STOQR")
+	logs, err := logging.FromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(slog.New(logs))
+	slog.Info("Starting STOQR", "version", version)
+
+	db := database.Connect()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	if err := database.RegisterMetrics(db, registry); err != nil {
+		fatal(err)
+	}
+	tracerProvider, err := tracing.FromEnv(context.Background(), semconv.ServiceVersion(version))
+	if err != nil {
+		fatal(err)
+	}
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	if tracerProvider != nil {
+		otel.SetTracerProvider(tracerProvider)
+		if err := database.RegisterTracing(db, tracerProvider); err != nil {
+			fatal(err)
+		}
+	}
+	err = database.Migrate(db,
+		&models.Item{},
+		&models.Component{},
+		&models.Movement{},
+		&models.Operation{},
+		&models.Conversion{},
+		&models.Category{},
+		&models.Tag{},
+		&models.Stocktake{},
+		&models.StocktakeCount{},
+		&models.Reservation{},
+		&models.Loan{},
+		&models.Asset{},
+		&models.AssetEvent{},
+		&models.Policy{},
+		&models.WithdrawalRequest{},
+		&models.Snapshot{},
+		&models.Job{},
+		&models.Subscription{},
+		&models.Channel{},
+		&models.ChannelRule{},
+		&models.RateLimit{},
+	)
+	if err != nil {
+		fatal(err)
+	}
 
-	database := database.Connect()
-	database.AutoMigrate(&models.Item{})
+	itemRepository := repositories.NewItemRepositorySQL(db)
+	movementRepository := repositories.NewMovementRepositorySQL(db)
+	categoryRepository := repositories.NewCategoryRepositorySQL(db)
+	stocktakeRepository := repositories.NewStocktakeRepositorySQL(db)
+	reservationRepository := repositories.NewReservationRepositorySQL(db)
+	loanRepository := repositories.NewLoanRepositorySQL(db)
+	assetRepository := repositories.NewAssetRepositorySQL(db)
+	approvalRepository := repositories.NewApprovalRepositorySQL(db)
+	jobRepository := repositories.NewJobRepositorySQL(db)
+	subscriptionRepository := repositories.NewSubscriptionRepositorySQL(db)
+	channelRepository := repositories.NewChannelRepositorySQL(db)
+	rateLimitRepository := repositories.NewRateLimitRepositorySQL(db)
+	itemService := services.NewItemService(itemRepository, approvalRepository, movementRepository)
+	if value := os.Getenv("STOQR_API_UNDO_WINDOW"); value != "" {
+		window, err := time.ParseDuration(value)
+		if err != nil {
+			fatal(err)
+		}
+		itemService.UndoWindow = window
+	}
+	reportService := services.NewReportService(itemRepository, movementRepository, categoryRepository)
+	categoryService := services.NewCategoryService(categoryRepository)
+	stocktakeService := services.NewStocktakeService(stocktakeRepository, itemRepository)
+	reservationService := services.NewReservationService(reservationRepository, itemRepository)
+	loanService := services.NewLoanService(loanRepository)
+	assetService := services.NewAssetService(assetRepository)
+	approvalService := services.NewApprovalService(approvalRepository)
+	jobService := services.NewJobService(jobRepository)
+	loggingService := services.NewLoggingService(logs)
+	notificationService := services.NewNotificationService(subscriptionRepository, channelRepository, itemRepository, categoryRepository, mailer())
+	itemService.LowStock = notificationService.NotifyLowStock
+	approvalService.Notify = notificationService.NotifyDecision
+	itemService.Metrics = services.NewItemMetrics(registry, itemRepository)
+	if tracerProvider != nil {
+		itemService.Tracer = tracerProvider.Tracer("github.com/leandroberetta/stoqr/stoqr-api/services")
+	}
+	healthService := services.NewHealthService(services.Build{Version: version, Commit: commit, Date: buildDate}, db.Name(),
+		services.Check{Name: "database", Check: func(ctx context.Context) error { return database.Ping(ctx, db) }},
+		services.Check{Name: "migrations", Check: func(ctx context.Context) error { return database.CheckMigrations(ctx, db) }},
+	)
 
-	itemRepository := repositories.NewItemRepositorySQL(database)
-	itemService := services.NewItemService(itemRepository)
+	jobs := scheduler.New(jobRepository, os.Getenv("STOQR_API_INSTANCE"))
+	err = jobs.Add("snapshots", env("STOQR_API_SNAPSHOT_SCHEDULE", services.DefaultSnapshotSchedule), models.MissedRunOnce,
+		func(ctx context.Context) error {
+			_, err := itemRepository.TakeSnapshot()
+			return err
+		})
+	if err != nil {
+		fatal(err)
+	}
+	err = jobs.Add("digest", env("STOQR_API_DIGEST_SCHEDULE", services.DefaultDigestSchedule), models.MissedRunOnce,
+		notificationService.SendDigest)
+	if err != nil {
+		fatal(err)
+	}
+	err = jobs.Add("expire-reservations", env("STOQR_API_EXPIRY_SCHEDULE", services.DefaultExpirySchedule), models.MissedSkip,
+		func(ctx context.Context) error {
+			_, err := reservationRepository.ExpireReservations()
+			return err
+		})
+	if err != nil {
+		fatal(err)
+	}
 
-	server := server.NewServer()
-	server.Router.Use(mux.CORSMethodMiddleware(server.Router))
+	config, err := server.ConfigFromEnv()
+	if err != nil {
+		fatal(err)
+	}
+	assetService.PublicURL = config.BaseURL()
+	var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if config.RateLimit.Store == server.RateLimitStoreDatabase {
+		rateLimitStore = rateLimitRepository
+		err = jobs.Add("rate-limits", env("STOQR_API_RATE_LIMIT_SWEEP_SCHEDULE", server.DefaultRateLimitSweepSchedule), models.MissedSkip,
+			func(ctx context.Context) error {
+				_, err := rateLimitRepository.DeleteIdle(time.Now().Add(-config.RateLimit.LongestPeriod()))
+				return err
+			})
+		if err != nil {
+			fatal(err)
+		}
+	}
+	rateLimiter := server.NewRateLimiter(config.RateLimit, rateLimitStore)
+	for _, template := range []string{
+		"/api/items/withdraw/{itemId}",
+		"/api/loans/scan/{itemId}",
+		"/api/reservations/{reservationId}/withdraw",
+		"/api/stocktakes/{stocktakeId}/scan/{itemId}",
+	} {
+		rateLimiter.Route(template)
+	}
+	httpMetrics := server.NewHTTPMetrics(registry)
+	tracingMiddleware := server.Tracing(tracerProvider)
+	server := server.NewServer(config)
+	// The QR codes of the units are public images any site (e.g. a label printer) can load
+	publicCORS := config.CORS
+	publicCORS.AllowedOrigins = []string{"*"}
+	publicCORS.AllowedMethods = []string{http.MethodGet, http.MethodOptions}
+	publicCORS.AllowCredentials = false
+	server.CORS.Route("/api/assets/{serial}/qr", publicCORS)
+	healthService.Draining = server.Draining
+	healthService.AddRoutes(server.Router)
+	server.Router.Use(httpMetrics.Middleware)
+	if tracerProvider != nil {
+		server.Router.Use(tracingMiddleware)
+	}
+	server.Router.Use(rateLimiter.Middleware)
+	server.Router.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{})).Methods(http.MethodGet)
 	itemService.AddRoutes(server.Router)
+	reportService.AddRoutes(server.Router)
+	categoryService.AddRoutes(server.Router)
+	stocktakeService.AddRoutes(server.Router)
+	reservationService.AddRoutes(server.Router)
+	loanService.AddRoutes(server.Router)
+	assetService.AddRoutes(server.Router)
+	approvalService.AddRoutes(server.Router)
+	jobService.AddRoutes(server.Router)
+	notificationService.AddRoutes(server.Router)
+	loggingService.AddRoutes(server.Router)
 
 	ch := make(chan os.Signal, 1)
-	signal.Notify(ch, os.Interrupt)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
 
-	server.Start()
+	if err := server.Start(); err != nil {
+		fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		jobs.Run(ctx)
+	}()
 
-	<-ch
+	code := 0
+	select {
+	case sig := <-ch:
+		slog.Info("Received a signal, draining requests", "signal", sig.String())
+	case err := <-server.Errors():
+		slog.Error("Serving", "error", err)
+		code = 1
+	}
 
-	server.Stop()
+	cancel()
+	if err := server.Stop(); err != nil {
+		slog.Error("Shutting down", "error", err)
+		code = 1
+	}
+	<-stopped
+	ctx, cancel = context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+	if tracerProvider != nil {
+		if err := tracerProvider.Shutdown(ctx); err != nil {
+			slog.Error("Exporting the remaining spans", "error", err)
+		}
+	}
+
+	slog.Info("Shutdown complete")
+	os.Exit(code)
+}
+
+// env returns the value of an environment variable or a fallback if it is not set
+func env(name string, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// mailer creates the SMTP mailer of the notifications, nil if no SMTP server is configured
+func mailer() notify.Mailer {
+	host := os.Getenv("STOQR_API_SMTP_HOST")
+	if host == "" {
+		slog.Info("No SMTP server configured, emails are disabled")
+		return nil
+	}
+	port, err := strconv.Atoi(env("STOQR_API_SMTP_PORT", "587"))
+	if err != nil {
+		fatal(err)
+	}
+	from := env("STOQR_API_SMTP_FROM", "stoqr@"+host)
+	return notify.NewSMTPMailer(host, port, os.Getenv("STOQR_API_SMTP_USERNAME"), os.Getenv("STOQR_API_SMTP_PASSWORD"), from)
+}
 
-	log.Println("Shutdown complete")
+// fatal logs an error that keeps the api from starting and exits
+func fatal(err error) {
+	slog.Error("Starting STOQR", "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,70 @@
+// Package tracing configures the OpenTelemetry SDK, which records the spans of the requests
+// and of the statements they run and exports them to an OpenTelemetry collector (OTLP over
+// HTTP) or stdout. The trace context of the requests follows W3C Trace Context.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// DefaultServiceName is the name of the service in the exported spans by default
+const DefaultServiceName = "stoqr-api"
+
+// ErrUnknownExporter is returned when the exporter of the spans is not supported
+var ErrUnknownExporter = errors.New("unknown trace exporter")
+
+// FromEnv creates the tracer provider configured with the OpenTelemetry environment
+// variables, nil if OTEL_TRACES_EXPORTER is unset or none. The exporter is otlp or console
+// (stdout), the OTLP exporter reads its endpoint and headers from OTEL_EXPORTER_OTLP_ENDPOINT
+// (or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT) and OTEL_EXPORTER_OTLP_HEADERS and the resource
+// reads OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES. Without OTEL_TRACES_SAMPLER, the
+// traces are sampled with the ratio of OTEL_TRACES_SAMPLER_ARG unless their parent decided.
+// The attributes are added to the resource of the spans.
+func FromEnv(ctx context.Context, attributes ...attribute.KeyValue) (*sdktrace.TracerProvider, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch name := os.Getenv("OTEL_TRACES_EXPORTER"); name {
+	case "", "none":
+		return nil, nil
+	case "console", "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "otlp":
+		exporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownExporter, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(DefaultServiceName)),
+		resource.WithAttributes(attributes...),
+		resource.WithFromEnv())
+	if err != nil {
+		return nil, err
+	}
+	options := []sdktrace.TracerProviderOption{sdktrace.WithBatcher(exporter), sdktrace.WithResource(res)}
+	if os.Getenv("OTEL_TRACES_SAMPLER") == "" {
+		ratio := 1.0
+		if value := os.Getenv("OTEL_TRACES_SAMPLER_ARG"); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil || parsed < 0 || parsed > 1 {
+				return nil, fmt.Errorf("invalid sampling ratio: %s", value)
+			}
+			ratio = parsed
+		}
+		options = append(options, sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))))
+	}
+	return sdktrace.NewTracerProvider(options...), nil
+}
@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		err  error
+		none bool
+	}{
+		{env: map[string]string{}, none: true},
+		{env: map[string]string{"OTEL_TRACES_EXPORTER": "none"}, none: true},
+		{env: map[string]string{"OTEL_TRACES_EXPORTER": "otlp"}},
+		{env: map[string]string{"OTEL_TRACES_EXPORTER": "otlp", "OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318/"}},
+		{env: map[string]string{"OTEL_TRACES_EXPORTER": "console", "OTEL_TRACES_SAMPLER_ARG": "0.1"}},
+		{env: map[string]string{"OTEL_TRACES_EXPORTER": "zipkin"}, err: ErrUnknownExporter},
+		{env: map[string]string{"OTEL_TRACES_EXPORTER": "otlp", "OTEL_TRACES_SAMPLER_ARG": "2"}, err: errors.New("invalid sampling ratio")},
+	}
+
+	for _, test := range tests {
+		for _, name := range []string{"OTEL_TRACES_EXPORTER", "OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_TRACES_SAMPLER", "OTEL_TRACES_SAMPLER_ARG"} {
+			os.Unsetenv(name)
+		}
+		for name, value := range test.env {
+			os.Setenv(name, value)
+		}
+		provider, err := FromEnv(context.Background(), attribute.String("service.version", "test"))
+		if (err != nil) != (test.err != nil) {
+			t.Errorf("wrong error for %v: want %v, got %v", test.env, test.err, err)
+		}
+		if errors.Is(test.err, ErrUnknownExporter) && !errors.Is(err, ErrUnknownExporter) {
+			t.Errorf("wrong error for %v: want %v, got %v", test.env, ErrUnknownExporter, err)
+		}
+		if (provider == nil) != (test.none || test.err != nil) {
+			t.Errorf("wrong provider for %v: got %v", test.env, provider)
+		}
+		if provider != nil {
+			provider.Shutdown(context.Background())
+		}
+		for name := range test.env {
+			os.Unsetenv(name)
+		}
+	}
+}